@@ -0,0 +1,157 @@
+// Package audit checks Network sites for hygiene issues, such as duplicate IP
+// addresses and devices which haven't been provisioned in a while. Each check
+// is a Rule with an ID and a severity, so that rules can be suppressed.
+package audit
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Severity is how much a finding matters.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// AllSeverities lists the severities, least severe first.
+var AllSeverities = []Severity{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// Valid returns true if s is one of AllSeverities.
+func (s Severity) Valid() bool {
+	return slices.Contains(AllSeverities, s)
+}
+
+// Compare returns -1 if s is less severe than other, 1 if it's more severe,
+// and 0 if they're the same.
+func (s Severity) Compare(other Severity) int {
+	return cmp.Compare(slices.Index(AllSeverities, s), slices.Index(AllSeverities, other))
+}
+
+// Kinds of things a finding can be about.
+const (
+	KindDevice  = "device"
+	KindClient  = "client"
+	KindVoucher = "voucher"
+)
+
+// Finding is an issue a rule found with a device, client or voucher.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	SiteID   string   `json:"siteId"`
+	Site     string   `json:"site"`
+	Kind     string   `json:"kind"`
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Message  string   `json:"message"`
+}
+
+// Inventory is what a site has, which rules check.
+type Inventory struct {
+	Site     *types.Site
+	Devices  []*types.Device
+	Clients  []*types.Client
+	Vouchers []*types.Voucher
+}
+
+// Fetch returns the inventory of a site.
+func Fetch(network types.NetworkV1, site *types.Site) (*Inventory, error) {
+	siteID := types.SiteID(site.ID)
+	devices, err := client.AllDeviceDetails(network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := client.AllClients(network, siteID, "")
+	if err != nil {
+		return nil, err
+	}
+	vouchers, err := client.AllVouchers(network, siteID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Inventory{Site: site, Devices: devices, Clients: clients, Vouchers: vouchers}, nil
+}
+
+// Options configure the rules.
+type Options struct {
+	// Now is when the audit happens.
+	Now time.Time
+	// ProvisionedWithin is how recently devices must have been provisioned.
+	ProvisionedWithin time.Duration
+	// LongConnectedAfter is how long a client may stay connected before it's
+	// reported.
+	LongConnectedAfter time.Duration
+}
+
+// DefaultOptions returns the options used unless configured otherwise.
+func DefaultOptions() Options {
+	return Options{
+		Now:                time.Now(),
+		ProvisionedWithin:  time.Hour * 24 * 90,
+		LongConnectedAfter: time.Hour * 24 * 30,
+	}
+}
+
+// Rule is a check of a site's inventory.
+type Rule struct {
+	ID          string   `json:"id"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+	// Check returns the findings of the rule. Rule, Severity and the site of
+	// findings are filled in by Run.
+	Check func(inventory *Inventory, options *Options) []*Finding `json:"-"`
+}
+
+// Rules are the rules Run checks, unless suppressed. Add a rule to make Run
+// check it.
+var Rules = []*Rule{
+	duplicateIPRule,
+	unnamedClientRule,
+	unsupportedDeviceRule,
+	unprovisionedDeviceRule,
+	expiredVoucherRule,
+	longConnectedClientRule,
+}
+
+// RuleIDs returns the IDs of Rules.
+func RuleIDs() []string {
+	ids := make([]string, 0, len(Rules))
+	for _, rule := range Rules {
+		ids = append(ids, rule.ID)
+	}
+	return ids
+}
+
+// Run checks each inventory against every rule not in suppress, and returns
+// the findings at least as severe as minSeverity: most severe first, then by
+// site, rule and name.
+func Run(inventories []*Inventory, options *Options, suppress []string, minSeverity Severity) []*Finding {
+	findings := []*Finding{}
+	for _, rule := range Rules {
+		if slices.Contains(suppress, rule.ID) || rule.Severity.Compare(minSeverity) < 0 {
+			continue
+		}
+		for _, inventory := range inventories {
+			for _, finding := range rule.Check(inventory, options) {
+				finding.Rule = rule.ID
+				finding.Severity = rule.Severity
+				finding.SiteID = inventory.Site.ID
+				finding.Site = inventory.Site.Name
+				findings = append(findings, finding)
+			}
+		}
+	}
+	slices.SortStableFunc(findings, func(a, b *Finding) int {
+		return cmp.Or(b.Severity.Compare(a.Severity), cmp.Compare(a.Site, b.Site),
+			cmp.Compare(a.Rule, b.Rule), cmp.Compare(a.Name, b.Name))
+	})
+	return findings
+}
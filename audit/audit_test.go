@@ -0,0 +1,86 @@
+package audit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/audit"
+	"github.com/ClifHouck/unified/types"
+)
+
+var now = time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+
+func inventory() *audit.Inventory {
+	return &audit.Inventory{
+		Site: &types.Site{ID: "default", Name: "HQ"},
+		Devices: []*types.Device{
+			{ID: "gw", Name: "Gateway", Model: "UDM", Supported: true, IPAddress: "10.0.0.1",
+				MacAddress: "00:00:00:00:00:01", ProvisionedAt: now.Add(-time.Hour)},
+			{ID: "sw", Name: "Old Switch", Model: "US-8", Supported: false, IPAddress: "10.0.0.2",
+				MacAddress: "00:00:00:00:00:02", ProvisionedAt: now.Add(-time.Hour * 24 * 200)},
+		},
+		Clients: []*types.Client{
+			// The gateway listed as a client too, which isn't a conflict.
+			{ID: "c0", Name: "Gateway", IPAddress: "10.0.0.1", MacAddress: "00-00-00-00-00-01", ConnectedAt: now},
+			{ID: "c1", Name: "Laptop", IPAddress: "10.0.0.2", MacAddress: "aa:00:00:00:00:01", ConnectedAt: now},
+			{ID: "c2", Name: "aa:00:00:00:00:02", IPAddress: "10.0.0.3", MacAddress: "AA:00:00:00:00:02", ConnectedAt: now},
+			{ID: "c3", Name: "VPN", IPAddress: "10.0.1.1", Type: "VPN", ConnectedAt: now.Add(-time.Hour * 24 * 45)},
+		},
+		Vouchers: []*types.Voucher{
+			{ID: "v1", Name: "Lobby", Code: "12345", Expired: true},
+			{ID: "v2", Name: "Lobby", Code: "67890"},
+		},
+	}
+}
+
+func options() *audit.Options {
+	options := audit.DefaultOptions()
+	options.Now = now
+	return &options
+}
+
+// Returns the findings as "rule id" strings.
+func summarize(findings []*audit.Finding) []string {
+	summary := []string{}
+	for _, finding := range findings {
+		summary = append(summary, finding.Rule+" "+finding.ID)
+	}
+	return summary
+}
+
+func TestRun(t *testing.T) {
+	findings := audit.Run([]*audit.Inventory{inventory()}, options(), nil, audit.SeverityInfo)
+	assert.Equal(t, []string{
+		"duplicate-ip c1",
+		"duplicate-ip sw",
+		"unprovisioned-device sw",
+		"unsupported-device sw",
+		"expired-voucher v1",
+		"long-connected-client c3",
+		"unnamed-client c2",
+	}, summarize(findings))
+
+	require.NotEmpty(t, findings)
+	assert.Equal(t, audit.SeverityCritical, findings[0].Severity)
+	assert.Equal(t, "HQ", findings[0].Site)
+	assert.Equal(t, "10.0.0.2 is shared by device 'Old Switch', client 'Laptop'", findings[0].Message)
+}
+
+func TestRunSuppressesRulesAndSeverities(t *testing.T) {
+	findings := audit.Run([]*audit.Inventory{inventory()}, options(),
+		[]string{"duplicate-ip"}, audit.SeverityWarning)
+	assert.Equal(t, []string{"unprovisioned-device sw", "unsupported-device sw"}, summarize(findings))
+}
+
+func TestRuleIDsAreUnique(t *testing.T) {
+	ids := audit.RuleIDs()
+	seen := map[string]bool{}
+	for _, id := range ids {
+		assert.False(t, seen[id], id)
+		seen[id] = true
+	}
+	assert.Len(t, ids, len(audit.Rules))
+}
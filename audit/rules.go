@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClifHouck/unified/client"
+)
+
+var duplicateIPRule = &Rule{
+	ID:          "duplicate-ip",
+	Severity:    SeverityCritical,
+	Description: "Devices or clients of a site which share an IP address",
+	Check: func(inventory *Inventory, _ *Options) []*Finding {
+		type holder struct{ kind, id, name, mac string }
+		holders := map[string][]holder{}
+		ips := []string{}
+		add := func(ip string, h holder) {
+			if ip == "" {
+				return
+			}
+			for _, existing := range holders[ip] {
+				// The same device may be listed as a client too.
+				if h.mac != "" && existing.mac == h.mac {
+					return
+				}
+			}
+			if len(holders[ip]) == 0 {
+				ips = append(ips, ip)
+			}
+			holders[ip] = append(holders[ip], h)
+		}
+		for _, device := range inventory.Devices {
+			add(device.IPAddress, holder{KindDevice, device.ID, device.Name, client.NormalizeMAC(device.MacAddress)})
+		}
+		for _, c := range inventory.Clients {
+			add(c.IPAddress, holder{KindClient, c.ID, c.Name, client.NormalizeMAC(c.MacAddress)})
+		}
+
+		findings := []*Finding{}
+		for _, ip := range ips {
+			if len(holders[ip]) < 2 {
+				continue
+			}
+			names := []string{}
+			for _, h := range holders[ip] {
+				names = append(names, fmt.Sprintf("%s '%s'", h.kind, h.name))
+			}
+			for _, h := range holders[ip] {
+				findings = append(findings, &Finding{
+					Kind:    h.kind,
+					ID:      h.id,
+					Name:    h.name,
+					Message: fmt.Sprintf("%s is shared by %s", ip, strings.Join(names, ", ")),
+				})
+			}
+		}
+		return findings
+	},
+}
+
+var unnamedClientRule = &Rule{
+	ID:          "unnamed-client",
+	Severity:    SeverityInfo,
+	Description: "Clients without a name, or named after their MAC address",
+	Check: func(inventory *Inventory, _ *Options) []*Finding {
+		findings := []*Finding{}
+		for _, c := range inventory.Clients {
+			mac := client.NormalizeMAC(c.MacAddress)
+			if c.Name != "" && (mac == "" || client.NormalizeMAC(c.Name) != mac) {
+				continue
+			}
+			findings = append(findings, &Finding{
+				Kind:    KindClient,
+				ID:      c.ID,
+				Name:    c.Name,
+				Message: fmt.Sprintf("client at %s has no name", cmp.Or(c.IPAddress, c.MacAddress, c.ID)),
+			})
+		}
+		return findings
+	},
+}
+
+var unsupportedDeviceRule = &Rule{
+	ID:          "unsupported-device",
+	Severity:    SeverityWarning,
+	Description: "Devices the Network application reports as unsupported",
+	Check: func(inventory *Inventory, _ *Options) []*Finding {
+		findings := []*Finding{}
+		for _, device := range inventory.Devices {
+			if device.Supported {
+				continue
+			}
+			findings = append(findings, &Finding{
+				Kind:    KindDevice,
+				ID:      device.ID,
+				Name:    device.Name,
+				Message: fmt.Sprintf("%s is not supported", device.Model),
+			})
+		}
+		return findings
+	},
+}
+
+var unprovisionedDeviceRule = &Rule{
+	ID:          "unprovisioned-device",
+	Severity:    SeverityWarning,
+	Description: "Devices which haven't been provisioned within the provisioning window",
+	Check: func(inventory *Inventory, options *Options) []*Finding {
+		findings := []*Finding{}
+		for _, device := range inventory.Devices {
+			message := ""
+			switch {
+			case device.ProvisionedAt.IsZero():
+				message = "has never been provisioned"
+			case options.Now.Sub(device.ProvisionedAt) > options.ProvisionedWithin:
+				message = fmt.Sprintf("was last provisioned %s ago", formatAge(options.Now.Sub(device.ProvisionedAt)))
+			default:
+				continue
+			}
+			findings = append(findings, &Finding{
+				Kind:    KindDevice,
+				ID:      device.ID,
+				Name:    device.Name,
+				Message: message,
+			})
+		}
+		return findings
+	},
+}
+
+var expiredVoucherRule = &Rule{
+	ID:          "expired-voucher",
+	Severity:    SeverityInfo,
+	Description: "Vouchers which have expired but haven't been deleted",
+	Check: func(inventory *Inventory, _ *Options) []*Finding {
+		findings := []*Finding{}
+		for _, voucher := range inventory.Vouchers {
+			if !voucher.Expired {
+				continue
+			}
+			message := "expired"
+			if !voucher.ExpiresAt.IsZero() {
+				message = "expired at " + voucher.ExpiresAt.Format("2006-01-02 15:04")
+			}
+			findings = append(findings, &Finding{
+				Kind:    KindVoucher,
+				ID:      voucher.ID,
+				Name:    voucher.Name,
+				Message: fmt.Sprintf("voucher %s %s", voucher.Code, message),
+			})
+		}
+		return findings
+	},
+}
+
+// The Network API lists only connected clients, with when they connected and
+// not when they were last seen, so this reports long sessions rather than
+// clients which have gone quiet, e.g. a forgotten device on a guest network.
+var longConnectedClientRule = &Rule{
+	ID:       "long-connected-client",
+	Severity: SeverityInfo,
+	Description: "Clients which have stayed connected for longer than the long connection window. " +
+		"Clients are listed only while connected, so this is how long they've been connected, not last seen",
+	Check: func(inventory *Inventory, options *Options) []*Finding {
+		findings := []*Finding{}
+		for _, c := range inventory.Clients {
+			if c.ConnectedAt.IsZero() || options.Now.Sub(c.ConnectedAt) <= options.LongConnectedAfter {
+				continue
+			}
+			findings = append(findings, &Finding{
+				Kind:    KindClient,
+				ID:      c.ID,
+				Name:    c.Name,
+				Message: fmt.Sprintf("connected %s ago", formatAge(options.Now.Sub(c.ConnectedAt))),
+			})
+		}
+		return findings
+	},
+}
+
+// Formats an age in days, or hours if less than a day.
+func formatAge(age time.Duration) string {
+	if age < time.Hour*24 {
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
@@ -0,0 +1,195 @@
+// Package cep implements simple complex event processing over Protect
+// events. An Engine is given rules, such as "a door sensor opened and the
+// porch camera detected a person within 30 seconds", and synthesizes a
+// types.CompositeEvent whenever one matches.
+//
+// Composite events are delivered as ordinary ProtectEvents, so a stream
+// passed through Engine.Pipe can be consumed by a
+// client.ProtectEventStreamHandler, with SetCompositeEventHandler receiving
+// the composite events.
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// Clock tells the Engine the time at which it observed an event. Rule
+// windows are measured in observation time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock is a Clock which only moves when told to, for replaying recorded
+// events in tests.
+type FakeClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the clock's current time.
+func (fc *FakeClock) Now() time.Time {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	return fc.now
+}
+
+// Advance moves the clock forward by d.
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	fc.now = fc.now.Add(d)
+}
+
+// Engine feeds events to a set of rules, and synthesizes a composite event
+// each time a rule matches.
+type Engine struct {
+	rules []Rule
+	clock Clock
+
+	matched int
+	mutex   sync.Mutex
+}
+
+// NewEngine returns an Engine evaluating rules. A nil clock uses the system
+// clock.
+func NewEngine(clock Clock, rules ...Rule) *Engine {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &Engine{rules: rules, clock: clock}
+}
+
+// Observe feeds a single event to every rule, returning a composite event
+// for each rule it completed. Composite events are never fed back into the
+// rules.
+func (e *Engine) Observe(event *types.ProtectEvent) []*types.ProtectEvent {
+	if event == nil || event.ItemType == types.CompositeEventType {
+		return nil
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	at := e.clock.Now()
+	composites := []*types.ProtectEvent{}
+	for _, rule := range e.rules {
+		observed := rule.observe(event, at)
+		if observed == nil {
+			continue
+		}
+
+		composite, err := e.newComposite(rule.Name(), observed)
+		if err != nil {
+			log.WithField("rule", rule.Name()).Error(err.Error())
+			continue
+		}
+		composites = append(composites, composite)
+	}
+	return composites
+}
+
+func (e *Engine) newComposite(rule string, observed []observation) (*types.ProtectEvent, error) {
+	e.matched++
+
+	item := &types.CompositeEvent{
+		ProtectEventItem: types.ProtectEventItem{
+			ID:       fmt.Sprintf("%s-%d", rule, e.matched),
+			ModelKey: types.ModelKeyEvent.String(),
+			Type:     types.CompositeEventType,
+			Start:    types.NewUnixMillis(observed[0].at),
+			End:      types.NewUnixMillis(observed[len(observed)-1].at),
+		},
+		Rule:    rule,
+		Devices: []string{},
+		Events:  make([]*types.ProtectEvent, 0, len(observed)),
+	}
+
+	for _, seen := range observed {
+		item.Events = append(item.Events, seen.event)
+
+		eventItem := seen.event.EventItem()
+		if eventItem == nil || eventItem.Device == "" {
+			continue
+		}
+		if !slices.Contains(item.Devices, eventItem.Device) {
+			item.Devices = append(item.Devices, eventItem.Device)
+		}
+	}
+	if len(item.Devices) == 1 {
+		item.Device = item.Devices[0]
+	}
+
+	rawItem, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ProtectEvent{
+		Type:     "add",
+		Item:     item,
+		ItemType: types.CompositeEventType,
+		RawItem:  rawItem,
+	}, nil
+}
+
+// Pipe returns a stream carrying every event from stream, each followed by
+// any composite events it completed. The returned stream is closed when
+// stream closes, or the context is done.
+func (e *Engine) Pipe(ctx context.Context, stream <-chan *types.ProtectEvent) <-chan *types.ProtectEvent {
+	out := make(chan *types.ProtectEvent)
+
+	send := func(event *types.ProtectEvent) bool {
+		select {
+		case out <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case event, ok := <-stream:
+				if !ok || event == nil {
+					return
+				}
+				if !send(event) {
+					return
+				}
+				for _, composite := range e.Observe(event) {
+					if !send(composite) {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
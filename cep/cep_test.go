@@ -0,0 +1,133 @@
+package cep_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/cep"
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// A recorded event, and how long after the previous one it was received.
+type fixtureEvent struct {
+	After string              `json:"after"`
+	Event *types.ProtectEvent `json:"event"`
+}
+
+func loadFixture(t *testing.T, name string) []fixtureEvent {
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+
+	var fixture []fixtureEvent
+	require.NoError(t, json.Unmarshal(data, &fixture))
+	return fixture
+}
+
+// Replays a fixture through an engine, advancing the fake clock between
+// events, and returns the composite events produced.
+func replay(t *testing.T, name string, rules ...cep.Rule) ([]*types.CompositeEvent, time.Time) {
+	start := time.UnixMilli(1700000000000)
+	clock := cep.NewFakeClock(start)
+	engine := cep.NewEngine(clock, rules...)
+
+	composites := []*types.CompositeEvent{}
+	for _, recorded := range loadFixture(t, name) {
+		after, err := time.ParseDuration(recorded.After)
+		require.NoError(t, err)
+		clock.Advance(after)
+
+		for _, event := range engine.Observe(recorded.Event) {
+			assert.Equal(t, "add", event.Type)
+			assert.Equal(t, types.CompositeEventType, event.ItemType)
+			composite, ok := event.Item.(*types.CompositeEvent)
+			require.True(t, ok)
+			composites = append(composites, composite)
+		}
+	}
+	return composites, start
+}
+
+func eventIDs(composite *types.CompositeEvent) []string {
+	ids := []string{}
+	for _, event := range composite.Events {
+		ids = append(ids, event.EventItem().ID)
+	}
+	return ids
+}
+
+var (
+	doorOpened  = cep.Match{Types: []string{"sensorOpened"}, Devices: []string{"door"}}
+	porchPerson = cep.Match{
+		Types:            []string{"smartDetectZone"},
+		Devices:          []string{"porch"},
+		SmartDetectTypes: []string{"person"},
+	}
+)
+
+func TestCoOccurrence(t *testing.T) {
+	composites, start := replay(t, "porch.json",
+		cep.CoOccurrence("door-and-person", time.Second*30, doorOpened, porchPerson))
+
+	require.Len(t, composites, 1)
+	composite := composites[0]
+	assert.Equal(t, "door-and-person", composite.Rule)
+	assert.Equal(t, []string{"e1", "e4"}, eventIDs(composite))
+	assert.Equal(t, []string{"door", "porch"}, composite.Devices)
+	assert.Equal(t, start, composite.StartTime())
+	assert.Equal(t, start.Add(time.Second*20), composite.EndTime())
+}
+
+func TestSequence(t *testing.T) {
+	motionOn := func(device string) cep.Match {
+		return cep.Match{Types: []string{"motion"}, Devices: []string{device}}
+	}
+	composites, _ := replay(t, "hallway.json",
+		cep.Sequence("hallway", time.Minute, motionOn("cam1"), motionOn("cam2"), motionOn("cam3")))
+
+	require.Len(t, composites, 1)
+	assert.Equal(t, []string{"m1", "m3", "m4"}, eventIDs(composites[0]))
+	assert.Equal(t, []string{"cam1", "cam2", "cam3"}, composites[0].Devices)
+}
+
+func TestCount(t *testing.T) {
+	composites, _ := replay(t, "hallway.json",
+		cep.Count("busy", time.Second*10, 3, cep.Match{Types: []string{"motion"}}))
+
+	require.Len(t, composites, 1)
+	assert.Equal(t, []string{"m1", "m2", "m3"}, eventIDs(composites[0]))
+}
+
+func TestPipeDeliversCompositesToStreamHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine := cep.NewEngine(cep.NewFakeClock(time.Now()),
+		cep.CoOccurrence("door-and-person", time.Second*30, doorOpened, porchPerson))
+
+	stream := make(chan *types.ProtectEvent)
+	handler := client.NewProtectEventStreamHandler(ctx, engine.Pipe(ctx, stream))
+
+	received := make(chan *types.CompositeEvent, 1)
+	handler.SetCompositeEventHandler(func(_ string, event *types.CompositeEvent) {
+		received <- event
+	})
+	go handler.Process()
+
+	for _, recorded := range loadFixture(t, "porch.json") {
+		stream <- recorded.Event
+	}
+
+	select {
+	case composite := <-received:
+		assert.Equal(t, "door-and-person", composite.Rule)
+	case <-time.After(time.Second * 5):
+		t.Fatal("no composite event delivered")
+	}
+}
@@ -0,0 +1,221 @@
+package cep
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// Match selects events. Every non-empty field must match; empty fields match
+// anything.
+type Match struct {
+	// Types are event registry keys, e.g. "motion" or "sensorOpened".
+	Types []string
+	// Devices are device IDs.
+	Devices []string
+	// SmartDetectTypes match smart detections of any of these types, e.g.
+	// "person".
+	SmartDetectTypes []string
+	// MessageTypes defaults to "add", so that each event is only matched
+	// when it starts, rather than once more for every update Protect sends.
+	MessageTypes []string
+}
+
+// Matches returns true if event is selected by m.
+func (m *Match) Matches(event *types.ProtectEvent) bool {
+	messageTypes := m.MessageTypes
+	if len(messageTypes) == 0 {
+		messageTypes = []string{"add"}
+	}
+	if !slices.Contains(messageTypes, event.Type) {
+		return false
+	}
+	if len(m.Types) > 0 && !slices.Contains(m.Types, event.ItemType) {
+		return false
+	}
+
+	item := event.EventItem()
+	if len(m.Devices) > 0 && (item == nil || !slices.Contains(m.Devices, item.Device)) {
+		return false
+	}
+
+	if len(m.SmartDetectTypes) > 0 {
+		return slices.ContainsFunc(event.SmartDetectTypes(), func(detected string) bool {
+			return slices.Contains(m.SmartDetectTypes, detected)
+		})
+	}
+	return true
+}
+
+// Rule recognizes a pattern of events. Rules are created by Sequence,
+// CoOccurrence and Count, and are not safe for concurrent use on their own;
+// Engine serializes access to them.
+type Rule interface {
+	// Name identifies the rule in the CompositeEvents it produces.
+	Name() string
+	// observe feeds the rule an event seen at time at. If the event completes
+	// the rule's pattern, the events which matched are returned, oldest
+	// first, and the rule starts over.
+	observe(event *types.ProtectEvent, at time.Time) []observation
+}
+
+type observation struct {
+	event *types.ProtectEvent
+	at    time.Time
+}
+
+type sequenceRule struct {
+	name   string
+	within time.Duration
+	steps  []Match
+
+	// Partially matched sequences, each holding the events which matched
+	// its first steps.
+	runs [][]observation
+}
+
+// maxSequenceRuns bounds how many partially matched sequences a rule tracks,
+// dropping the oldest first.
+const maxSequenceRuns = 64
+
+// Sequence returns a rule which matches when events matching each of steps
+// occur in order, with the last step no more than within after the first.
+// Unrelated events in between are ignored.
+func Sequence(name string, within time.Duration, steps ...Match) Rule {
+	return &sequenceRule{name: name, within: within, steps: steps}
+}
+
+func (sr *sequenceRule) Name() string {
+	return sr.name
+}
+
+func (sr *sequenceRule) observe(event *types.ProtectEvent, at time.Time) []observation {
+	if len(sr.steps) == 0 {
+		return nil
+	}
+	seen := observation{event: event, at: at}
+
+	runs := [][]observation{}
+	for _, run := range sr.runs {
+		if at.Sub(run[0].at) > sr.within {
+			continue
+		}
+		if sr.steps[len(run)].Matches(event) {
+			run = append(slices.Clip(run), seen)
+			if len(run) == len(sr.steps) {
+				sr.runs = nil
+				return run
+			}
+		}
+		runs = append(runs, run)
+	}
+
+	if sr.steps[0].Matches(event) {
+		if len(sr.steps) == 1 {
+			sr.runs = nil
+			return []observation{seen}
+		}
+		runs = append(runs, []observation{seen})
+	}
+
+	if len(runs) > maxSequenceRuns {
+		runs = runs[len(runs)-maxSequenceRuns:]
+	}
+	sr.runs = runs
+	return nil
+}
+
+type coOccurrenceRule struct {
+	name    string
+	within  time.Duration
+	matches []Match
+
+	// The most recent event seen for each match, if any.
+	latest []*observation
+}
+
+// CoOccurrence returns a rule which matches when an event matching each of
+// matches has occurred, in any order, within a window of the given length.
+func CoOccurrence(name string, within time.Duration, matches ...Match) Rule {
+	return &coOccurrenceRule{
+		name:    name,
+		within:  within,
+		matches: matches,
+		latest:  make([]*observation, len(matches)),
+	}
+}
+
+func (cr *coOccurrenceRule) Name() string {
+	return cr.name
+}
+
+func (cr *coOccurrenceRule) observe(event *types.ProtectEvent, at time.Time) []observation {
+	matched := false
+	for i := range cr.matches {
+		if cr.matches[i].Matches(event) {
+			cr.latest[i] = &observation{event: event, at: at}
+			matched = true
+		}
+	}
+	if !matched || len(cr.matches) == 0 {
+		return nil
+	}
+
+	observed := []observation{}
+	for _, latest := range cr.latest {
+		if latest == nil || at.Sub(latest.at) > cr.within {
+			return nil
+		}
+		// A single event may satisfy more than one match.
+		if !slices.ContainsFunc(observed, func(o observation) bool { return o.event == latest.event }) {
+			observed = append(observed, *latest)
+		}
+	}
+
+	cr.latest = make([]*observation, len(cr.matches))
+	slices.SortStableFunc(observed, func(a, b observation) int {
+		return a.at.Compare(b.at)
+	})
+	return observed
+}
+
+type countRule struct {
+	name      string
+	within    time.Duration
+	threshold int
+	match     Match
+
+	window []observation
+}
+
+// Count returns a rule which matches when at least threshold events matching
+// match occur within a sliding window of the given length.
+func Count(name string, within time.Duration, threshold int, match Match) Rule {
+	return &countRule{name: name, within: within, threshold: threshold, match: match}
+}
+
+func (cr *countRule) Name() string {
+	return cr.name
+}
+
+func (cr *countRule) observe(event *types.ProtectEvent, at time.Time) []observation {
+	if !cr.match.Matches(event) {
+		return nil
+	}
+
+	window := []observation{}
+	for _, seen := range cr.window {
+		if at.Sub(seen.at) <= cr.within {
+			window = append(window, seen)
+		}
+	}
+	window = append(window, observation{event: event, at: at})
+
+	if len(window) >= cr.threshold {
+		cr.window = nil
+		return window
+	}
+	cr.window = window
+	return nil
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// NormalizeMAC returns mac as lower case, colon separated hex, e.g.
+// "aa:bb:cc:dd:ee:ff". The address may be separated by colons, dashes or
+// dots, or not at all. Returns "" if mac isn't a MAC address.
+func NormalizeMAC(mac string) string {
+	digits := strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+	if len(digits) != 12 {
+		return ""
+	}
+	_, err := hex.DecodeString(digits)
+	if err != nil {
+		return ""
+	}
+	octets := make([]string, 0, 6)
+	for i := 0; i < len(digits); i += 2 {
+		octets = append(octets, digits[i:i+2])
+	}
+	return strings.Join(octets, ":")
+}
+
+// PortRef names a switch port.
+type PortRef struct {
+	// Device is the switch, by name or ID.
+	Device string `json:"device"`
+	Port   int    `json:"port"`
+}
+
+// ParsePortRef parses a port reference of the form "device:port", e.g.
+// "Office Switch:5".
+func ParsePortRef(text string) (*PortRef, error) {
+	sep := strings.LastIndex(text, ":")
+	if sep <= 0 {
+		return nil, fmt.Errorf("invalid port '%s', expected device:port", text)
+	}
+	port, err := strconv.Atoi(text[sep+1:])
+	if err != nil || port < 0 {
+		return nil, fmt.Errorf("invalid port index in '%s'", text)
+	}
+	return &PortRef{Device: text[:sep], Port: port}, nil
+}
+
+func (pr *PortRef) String() string {
+	return fmt.Sprintf("%s:%d", pr.Device, pr.Port)
+}
+
+// NetworkLocation is where a device is connected to a site's network. Fields
+// which couldn't be determined are nil.
+type NetworkLocation struct {
+	// Client is the device as a Network client.
+	Client *types.Client `json:"client,omitempty"`
+	// Device is the device as an adopted Network device, for devices which
+	// are managed by Network rather than just connected to it.
+	Device *types.Device `json:"device,omitempty"`
+	// Uplink is the switch or access point the device is connected to.
+	Uplink *types.Device `json:"uplink,omitempty"`
+	// Port is the switch port the device is connected to. The Network API
+	// doesn't report which port a client is on, so this is only known for
+	// devices assigned a port with Correlator.AssignPort.
+	Port *SitePort `json:"port,omitempty"`
+}
+
+// ProtectDeviceLocation is a Protect device and where it's connected.
+type ProtectDeviceLocation struct {
+	Device   *ProtectDevice   `json:"device"`
+	Location *NetworkLocation `json:"location"`
+}
+
+// Correlator matches devices to the clients and devices of a site's network,
+// by MAC or IP address.
+type Correlator struct {
+	clients []*types.Client
+	devices []*types.Device
+	ports   map[string]*PortRef
+}
+
+// NewCorrelator returns a Correlator for a site's clients and devices. The
+// devices should come from DeviceDetails, so their uplinks and ports are
+// known.
+func NewCorrelator(clients []*types.Client, devices []*types.Device) *Correlator {
+	return &Correlator{
+		clients: clients,
+		devices: devices,
+		ports:   map[string]*PortRef{},
+	}
+}
+
+// FetchCorrelator returns a Correlator for a site's current clients and
+// devices.
+func FetchCorrelator(network types.NetworkV1, siteID types.SiteID) (*Correlator, error) {
+	clients, err := AllClients(network, siteID, "")
+	if err != nil {
+		return nil, err
+	}
+	devices, err := AllDeviceDetails(network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	return NewCorrelator(clients, devices), nil
+}
+
+// Normalizes a port assignment key: MAC addresses are normalized, and names
+// and IDs lower cased.
+func portKey(key string) string {
+	mac := NormalizeMAC(key)
+	if mac != "" {
+		return mac
+	}
+	return strings.ToLower(key)
+}
+
+// AssignPort records the switch port which the device with the given MAC
+// address, name or ID is connected to.
+func (c *Correlator) AssignPort(key string, ref *PortRef) {
+	c.ports[portKey(key)] = ref
+}
+
+// Returns the network device with the given name, ignoring case, or ID.
+func (c *Correlator) findDevice(nameOrID string) *types.Device {
+	for _, device := range c.devices {
+		if device.ID == nameOrID || strings.EqualFold(device.Name, nameOrID) {
+			return device
+		}
+	}
+	return nil
+}
+
+// Resolves a port reference to one of the site's ports.
+func (c *Correlator) resolvePort(ref *PortRef) (*SitePort, error) {
+	device := c.findDevice(ref.Device)
+	if device == nil {
+		return nil, fmt.Errorf("no device '%s' in site", ref.Device)
+	}
+	for _, port := range Ports([]*types.Device{device}) {
+		if port.Idx == ref.Port {
+			return port, nil
+		}
+	}
+	return nil, fmt.Errorf("device '%s' has no port %d", device.Name, ref.Port)
+}
+
+// Locate finds where the device with the given MAC or IP address is
+// connected. Either address may be empty. Keys are further names or IDs the
+// device's port may have been assigned under.
+func (c *Correlator) Locate(mac, ip string, keys ...string) (*NetworkLocation, error) {
+	location := &NetworkLocation{}
+	mac = NormalizeMAC(mac)
+
+	for _, client := range c.clients {
+		if (mac != "" && NormalizeMAC(client.MacAddress) == mac) || (ip != "" && client.IPAddress == ip) {
+			location.Client = client
+			location.Uplink = c.findDevice(client.UplinkDeviceID)
+			break
+		}
+	}
+	if location.Client == nil {
+		for _, device := range c.devices {
+			if (mac != "" && NormalizeMAC(device.MacAddress) == mac) || (ip != "" && device.IPAddress == ip) {
+				location.Device = device
+				location.Uplink = c.findDevice(device.Uplink.DeviceID)
+				break
+			}
+		}
+	}
+
+	for _, key := range append([]string{mac}, keys...) {
+		ref, ok := c.ports[portKey(key)]
+		if key == "" || !ok {
+			continue
+		}
+		port, err := c.resolvePort(ref)
+		if err != nil {
+			return nil, err
+		}
+		location.Port = port
+		location.Uplink = c.findDevice(port.DeviceID)
+		break
+	}
+
+	return location, nil
+}
+
+// LocateProtectDevice finds where a Protect device is connected, by its MAC
+// address alone, as Protect doesn't report devices' IP addresses. Its port is
+// only known if assigned, under its MAC address, name or ID.
+func (c *Correlator) LocateProtectDevice(device *ProtectDevice) (*NetworkLocation, error) {
+	return c.Locate(device.Mac, "", device.Name, device.ID)
+}
+
+// LocateProtectDevices finds where each of the Protect devices is connected.
+func (c *Correlator) LocateProtectDevices(devices []*ProtectDevice) ([]*ProtectDeviceLocation, error) {
+	locations := make([]*ProtectDeviceLocation, 0, len(devices))
+	for _, device := range devices {
+		location, err := c.LocateProtectDevice(device)
+		if err != nil {
+			return nil, fmt.Errorf("locating '%s': %w", device.Name, err)
+		}
+		locations = append(locations, &ProtectDeviceLocation{Device: device, Location: location})
+	}
+	return locations, nil
+}
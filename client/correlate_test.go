@@ -0,0 +1,123 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+func TestNormalizeMAC(t *testing.T) {
+	assert.Equal(t, "aa:bb:cc:dd:ee:ff", client.NormalizeMAC("AA-BB-CC-DD-EE-FF"))
+	assert.Equal(t, "aa:bb:cc:dd:ee:ff", client.NormalizeMAC("aabb.ccdd.eeff"))
+	assert.Equal(t, "aa:bb:cc:dd:ee:ff", client.NormalizeMAC("aabbccddeeff"))
+	assert.Empty(t, client.NormalizeMAC("Office Switch"))
+	assert.Empty(t, client.NormalizeMAC("aa:bb:cc:dd:ee:gg"))
+}
+
+func TestParsePortRef(t *testing.T) {
+	ref, err := client.ParsePortRef("Office Switch:5")
+	require.NoError(t, err)
+	assert.Equal(t, &client.PortRef{Device: "Office Switch", Port: 5}, ref)
+
+	for _, text := range []string{"Office Switch", ":5", "Office Switch:x"} {
+		_, err = client.ParsePortRef(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func newTestCorrelator(t *testing.T) *client.Correlator {
+	t.Helper()
+	var clients []*types.Client
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"id": "c1", "name": "Front Door", "type": "WIRED", "ipAddress": "10.0.0.20",
+		 "macAddress": "AA:BB:CC:00:00:01", "uplinkDeviceId": "sw1"},
+		{"id": "c2", "name": "Garage", "type": "WIRED", "ipAddress": "10.0.0.21",
+		 "macAddress": "aa:bb:cc:00:00:02", "uplinkDeviceId": "sw1"}
+	]`), &clients))
+	var device types.Device
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "sw1", "name": "Office Switch", "macAddress": "aa:bb:cc:00:00:10",
+		"interfaces": {"ports": [
+			{"idx": 1, "state": "UP", "poe": {"enabled": true}},
+			{"idx": 2, "state": "UP", "poe": {"enabled": true}}
+		]}
+	}`), &device))
+	return client.NewCorrelator(clients, []*types.Device{&device})
+}
+
+func TestCorrelatorLocate(t *testing.T) {
+	correlator := newTestCorrelator(t)
+
+	location, err := correlator.Locate("aa-bb-cc-00-00-01", "")
+	require.NoError(t, err)
+	require.NotNil(t, location.Client)
+	assert.Equal(t, "c1", location.Client.ID)
+	require.NotNil(t, location.Uplink)
+	assert.Equal(t, "Office Switch", location.Uplink.Name)
+	assert.Nil(t, location.Port, "ports aren't reported by Network")
+
+	location, err = correlator.Locate("", "10.0.0.21")
+	require.NoError(t, err)
+	assert.Equal(t, "c2", location.Client.ID)
+
+	location, err = correlator.Locate("aa:bb:cc:00:00:10", "")
+	require.NoError(t, err)
+	assert.Nil(t, location.Client)
+	require.NotNil(t, location.Device)
+	assert.Equal(t, "sw1", location.Device.ID)
+
+	location, err = correlator.Locate("aa:bb:cc:99:99:99", "")
+	require.NoError(t, err)
+	assert.Equal(t, &client.NetworkLocation{}, location)
+}
+
+func TestCorrelatorAssignedPorts(t *testing.T) {
+	correlator := newTestCorrelator(t)
+	correlator.AssignPort("AABBCC000001", &client.PortRef{Device: "office switch", Port: 2})
+	correlator.AssignPort("Garage", &client.PortRef{Device: "sw1", Port: 9})
+
+	location, err := correlator.LocateProtectDevice(&client.ProtectDevice{ID: "cam1", Mac: "aa:bb:cc:00:00:01"})
+	require.NoError(t, err)
+	require.NotNil(t, location.Port)
+	assert.Equal(t, 2, location.Port.Idx)
+	assert.Equal(t, "sw1", location.Port.DeviceID)
+
+	_, err = correlator.LocateProtectDevice(&client.ProtectDevice{ID: "cam2", Name: "garage"})
+	assert.ErrorContains(t, err, "has no port 9")
+}
+
+func deviceStateEvent(t *testing.T, id string, state types.ProtectDeviceState) *types.ProtectDeviceEvent {
+	t.Helper()
+	var event types.ProtectDeviceEvent
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "update", "modelKey": "camera",
+		"item": {"id": "`+id+`", "modelKey": "camera", "state": "`+string(state)+`"}}`), &event))
+	return &event
+}
+
+func TestWaitForReconnect(t *testing.T) {
+	stream := make(chan *types.ProtectDeviceEvent, 4)
+	stream <- deviceStateEvent(t, "cam1", types.ProtectDeviceStateConnected)
+	stream <- deviceStateEvent(t, "cam2", types.ProtectDeviceStateDisconnected)
+	stream <- deviceStateEvent(t, "cam1", types.ProtectDeviceStateDisconnected)
+	stream <- deviceStateEvent(t, "cam1", types.ProtectDeviceStateConnected)
+
+	reconnect, err := client.WaitForReconnect(context.Background(), stream, "cam1")
+	require.NoError(t, err)
+	assert.True(t, reconnect.Reconnected())
+	assert.GreaterOrEqual(t, reconnect.Downtime(), time.Duration(0))
+
+	stream <- deviceStateEvent(t, "cam1", types.ProtectDeviceStateDisconnected)
+	waitCtx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	reconnect, err = client.WaitForReconnect(waitCtx, stream, "cam1")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, reconnect.Disconnected())
+	assert.False(t, reconnect.Reconnected())
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// Controller is the client of one named UniFi console.
+type Controller struct {
+	Name   string
+	Client *Client
+}
+
+// Fleet queries several controllers at once.
+type Fleet struct {
+	Controllers []*Controller
+	// Timeout bounds each controller's part of a query. Zero doesn't.
+	Timeout time.Duration
+}
+
+// WithContext returns a copy of the client which makes its requests with ctx.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	copied.Network = &networkV1Client{client: &copied}
+	copied.Protect = &protectV1Client{client: &copied}
+	return &copied
+}
+
+// Tagged is an item from a controller of a fleet, and of a site if it's a
+// Network item queried by QueryFleetSites.
+type Tagged[T any] struct {
+	Controller string
+	Site       string
+	Item       T
+}
+
+// MarshalJSON adds "controller", and "site" if set, to the fields of the
+// item. Items which aren't JSON objects are put under "item".
+func (t *Tagged[T]) MarshalJSON() ([]byte, error) {
+	tags := map[string]string{"controller": t.Controller}
+	if t.Site != "" {
+		tags["site"] = t.Site
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return nil, err
+	}
+	item, err := json.Marshal(t.Item)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(item, []byte("{")) {
+		return json.Marshal(struct {
+			Controller string `json:"controller"`
+			Site       string `json:"site,omitempty"`
+			Item       T      `json:"item"`
+		}{t.Controller, t.Site, t.Item})
+	}
+	if bytes.Equal(item, []byte("{}")) {
+		return tagsJSON, nil
+	}
+	// Splice the tags in at the front of the item's object.
+	tagsJSON[len(tagsJSON)-1] = ','
+	return append(tagsJSON, item[1:]...), nil
+}
+
+// ControllerError is why a controller's part of a query failed.
+type ControllerError struct {
+	Controller string
+	Err        error
+}
+
+func (ce *ControllerError) Error() string {
+	return fmt.Sprintf("%s: %s", ce.Controller, ce.Err.Error())
+}
+
+func (ce *ControllerError) Unwrap() error {
+	return ce.Err
+}
+
+// FleetError is returned by queries of which some controllers failed. The
+// results of the others are still returned.
+type FleetError struct {
+	Errors      []*ControllerError
+	Controllers int
+}
+
+func (fe *FleetError) Error() string {
+	messages := make([]string, 0, len(fe.Errors))
+	for _, err := range fe.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d of %d controllers failed: %s", len(fe.Errors), fe.Controllers,
+		strings.Join(messages, "; "))
+}
+
+func (fe *FleetError) Unwrap() []error {
+	errs := make([]error, 0, len(fe.Errors))
+	for _, err := range fe.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// QueryFleet runs query against every controller of the fleet in parallel,
+// and returns the items of every controller tagged with its name, in the
+// order of the controllers. If any controller fails, the error is a
+// *FleetError and the items of the others are still returned.
+func QueryFleet[T any](fleet *Fleet, query func(c *Client) ([]T, error)) ([]*Tagged[T], error) {
+	return runFleet(fleet, func(c *Client) ([]*Tagged[T], error) {
+		items, err := query(c)
+		if err != nil {
+			return nil, err
+		}
+		tagged := make([]*Tagged[T], 0, len(items))
+		for _, item := range items {
+			tagged = append(tagged, &Tagged[T]{Item: item})
+		}
+		return tagged, nil
+	})
+}
+
+// QueryFleetSites is like QueryFleet, but runs query for each site of each
+// controller whose name or ID is in sites, ignoring case, or every site if
+// sites is empty. Items are also tagged with the name of their site.
+func QueryFleetSites[T any](fleet *Fleet, sites []string,
+	query func(c *Client, siteID types.SiteID) ([]T, error)) ([]*Tagged[T], error) {
+	return runFleet(fleet, func(c *Client) ([]*Tagged[T], error) {
+		all, err := AllSites(c.Network, "")
+		if err != nil {
+			return nil, err
+		}
+		tagged := []*Tagged[T]{}
+		for _, site := range all {
+			if len(sites) > 0 && !containsFold(sites, site.ID, site.Name) {
+				continue
+			}
+			items, err := query(c, types.SiteID(site.ID))
+			if err != nil {
+				return nil, fmt.Errorf("site '%s': %w", site.Name, err)
+			}
+			for _, item := range items {
+				tagged = append(tagged, &Tagged[T]{Site: site.Name, Item: item})
+			}
+		}
+		return tagged, nil
+	})
+}
+
+// Returns true if any of values is in list, ignoring case.
+func containsFold(list []string, values ...string) bool {
+	for _, want := range list {
+		for _, value := range values {
+			if strings.EqualFold(want, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func runFleet[T any](fleet *Fleet, query func(c *Client) ([]*Tagged[T], error)) ([]*Tagged[T], error) {
+	results := make([][]*Tagged[T], len(fleet.Controllers))
+	errs := make([]error, len(fleet.Controllers))
+	var wg sync.WaitGroup
+	for i, controller := range fleet.Controllers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := controller.Client.ctx, context.CancelFunc(func() {})
+			if fleet.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, fleet.Timeout)
+			}
+			defer cancel()
+
+			tagged, err := query(controller.Client.WithContext(ctx))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			for _, item := range tagged {
+				item.Controller = controller.Name
+			}
+			results[i] = tagged
+		}()
+	}
+	wg.Wait()
+
+	merged := []*Tagged[T]{}
+	fleetErr := &FleetError{Errors: []*ControllerError{}, Controllers: len(fleet.Controllers)}
+	for i, controller := range fleet.Controllers {
+		if errs[i] != nil {
+			fleetErr.Errors = append(fleetErr.Errors, &ControllerError{Controller: controller.Name, Err: errs[i]})
+			continue
+		}
+		merged = append(merged, results[i]...)
+	}
+	if len(fleetErr.Errors) > 0 {
+		return merged, fleetErr
+	}
+	return merged, nil
+}
@@ -0,0 +1,99 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Returns a controller whose requests handler serves.
+func newFakeController(t *testing.T, name string, handler http.HandlerFunc) *client.Controller {
+	t.Helper()
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	config := client.NewDefaultConfig("key")
+	config.Hostname = serverURL.Host
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return &client.Controller{Name: name, Client: client.NewClient(context.Background(), config, log)}
+}
+
+// Serves one site, with one device named after the controller.
+func siteHandler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page := `"offset":0,"limit":200,"count":1,"totalCount":1`
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/sites"):
+			_, _ = w.Write([]byte(`{"data":[{"id":"site-` + name + `","name":"Default"}],` + page + `}`))
+		case strings.HasSuffix(r.URL.Path, "/sites/site-"+name+"/devices"):
+			_, _ = w.Write([]byte(`{"data":[{"id":"dev","name":"` + name + ` switch"}],` + page + `}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func TestQueryFleetSites(t *testing.T) {
+	fleet := &client.Fleet{
+		Controllers: []*client.Controller{
+			newFakeController(t, "hq", siteHandler("hq")),
+			newFakeController(t, "slow", func(_ http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			}),
+			newFakeController(t, "broken", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"statusCode":500,"statusName":"INTERNAL","message":"oops"}`))
+			}),
+			newFakeController(t, "branch", siteHandler("branch")),
+		},
+		Timeout: time.Millisecond * 200,
+	}
+
+	devices, err := client.QueryFleetSites(fleet, []string{"default"},
+		func(c *client.Client, siteID types.SiteID) ([]*types.DeviceListEntry, error) {
+			return client.AllDevices(c.Network, siteID)
+		})
+
+	require.Len(t, devices, 2)
+	assert.Equal(t, "hq", devices[0].Controller)
+	assert.Equal(t, "Default", devices[0].Site)
+	assert.Equal(t, "hq switch", devices[0].Item.Name)
+	assert.Equal(t, "branch", devices[1].Controller)
+
+	var fleetErr *client.FleetError
+	require.ErrorAs(t, err, &fleetErr)
+	assert.Equal(t, 4, fleetErr.Controllers)
+	require.Len(t, fleetErr.Errors, 2)
+	assert.Equal(t, "slow", fleetErr.Errors[0].Controller)
+	require.ErrorIs(t, fleetErr.Errors[0], context.DeadlineExceeded)
+	assert.Equal(t, "broken", fleetErr.Errors[1].Controller)
+}
+
+func TestTaggedMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(&client.Tagged[*types.Site]{
+		Controller: "hq",
+		Item:       &types.Site{ID: "s1", Name: "Default"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"controller":"hq","id":"s1","name":"Default"}`, string(data))
+
+	data, err = json.Marshal(&client.Tagged[string]{Controller: "hq", Site: "Default", Item: "s1"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"controller":"hq","site":"Default","item":"s1"}`, string(data))
+}
@@ -43,11 +43,12 @@ var networkAPI = map[string]*apiEndpoint{
 		NumURLArgs:  2,
 	},
 	"ClientExecuteAction": {
-		URLFragment: "sites/%s/devices/%s/actions",
-		Method:      http.MethodPost,
-		Description: "Execute an action on a device",
-		Application: "network",
-		NumURLArgs:  2,
+		URLFragment:    "sites/%s/clients/%s/actions",
+		Method:         http.MethodPost,
+		Description:    "Execute an action on a client",
+		Application:    "network",
+		NumURLArgs:     2,
+		HasRequestBody: true,
 	},
 
 	// Devices related
@@ -81,9 +82,9 @@ var networkAPI = map[string]*apiEndpoint{
 		HasRequestBody: true,
 	},
 	"DevicePortExecuteAction": {
-		URLFragment:    "sites/%s/devices/%s/actions",
+		URLFragment:    "sites/%s/devices/%s/interfaces/ports/%d/actions",
 		Method:         http.MethodPost,
-		Description:    "Execute an action on a device",
+		Description:    "Execute an action on a device's port",
 		Application:    "network",
 		NumURLArgs:     3,
 		HasRequestBody: true,
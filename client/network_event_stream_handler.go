@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// (!) DO NOT EDIT (!) Generated by generate_stream_handlers
+
+type NetworkEventStreamHandler struct {
+	ctx    context.Context
+	stream <-chan *types.NetworkEvent
+
+	deviceStateChangedEventHandler func(string, *types.DeviceStateChangedEvent)
+	deviceStateChangedEventMutex   sync.Mutex
+
+	deviceAdoptedEventHandler func(string, *types.DeviceAdoptedEvent)
+	deviceAdoptedEventMutex   sync.Mutex
+
+	clientConnectedEventHandler func(string, *types.ClientConnectedEvent)
+	clientConnectedEventMutex   sync.Mutex
+
+	clientDisconnectedEventHandler func(string, *types.ClientDisconnectedEvent)
+	clientDisconnectedEventMutex   sync.Mutex
+
+	voucherActivatedEventHandler func(string, *types.VoucherActivatedEvent)
+	voucherActivatedEventMutex   sync.Mutex
+
+	voucherExpiredEventHandler func(string, *types.VoucherExpiredEvent)
+	voucherExpiredEventMutex   sync.Mutex
+} // NetworkEventStreamHandler
+
+func NewNetworkEventStreamHandler(ctx context.Context,
+	stream <-chan *types.NetworkEvent) *NetworkEventStreamHandler {
+	handler := &NetworkEventStreamHandler{
+		ctx:    ctx,
+		stream: stream,
+	}
+
+	return handler
+}
+
+func (esh *NetworkEventStreamHandler) Process() {
+	log.Info("Waiting for events...")
+	for {
+		select {
+		case streamEvent := <-esh.stream:
+			if streamEvent == nil {
+				log.Warn("Got nil event. Bailing out!")
+				return
+			}
+
+			var item types.NetworkEventItem
+			err := json.Unmarshal(streamEvent.RawItem, &item)
+			if err != nil {
+				log.Error("Couldn't parse RawItem!")
+				log.Error(err.Error())
+			}
+
+			log.WithFields(log.Fields{
+				"ID":           item.ID,
+				"event.type":   streamEvent.ItemType,
+				"message.type": streamEvent.Type,
+			}).Info("Received NetworkEvent")
+
+			switch event := streamEvent.Item.(type) {
+			case *types.DeviceStateChangedEvent:
+				go esh.invokeDeviceStateChangedEventHandler(streamEvent.Type, event)
+			case *types.DeviceAdoptedEvent:
+				go esh.invokeDeviceAdoptedEventHandler(streamEvent.Type, event)
+			case *types.ClientConnectedEvent:
+				go esh.invokeClientConnectedEventHandler(streamEvent.Type, event)
+			case *types.ClientDisconnectedEvent:
+				go esh.invokeClientDisconnectedEventHandler(streamEvent.Type, event)
+			case *types.VoucherActivatedEvent:
+				go esh.invokeVoucherActivatedEventHandler(streamEvent.Type, event)
+			case *types.VoucherExpiredEvent:
+				go esh.invokeVoucherExpiredEventHandler(streamEvent.Type, event)
+
+			default:
+				log.Errorf("Unknown type encountered: '%s'", streamEvent.ItemType)
+			}
+
+		case <-esh.ctx.Done():
+			log.Warn("Got context.Done!")
+			return
+		}
+	}
+}
+
+func (esh *NetworkEventStreamHandler) SetDeviceStateChangedEventHandler(handler func(string, *types.DeviceStateChangedEvent)) {
+	esh.deviceStateChangedEventMutex.Lock()
+	defer esh.deviceStateChangedEventMutex.Unlock()
+
+	esh.deviceStateChangedEventHandler = handler
+}
+
+func (esh *NetworkEventStreamHandler) invokeDeviceStateChangedEventHandler(eventType string, event *types.DeviceStateChangedEvent) {
+	esh.deviceStateChangedEventMutex.Lock()
+	defer esh.deviceStateChangedEventMutex.Unlock()
+
+	if esh.deviceStateChangedEventHandler != nil {
+		go esh.deviceStateChangedEventHandler(eventType, event)
+	}
+}
+
+func (esh *NetworkEventStreamHandler) SetDeviceAdoptedEventHandler(handler func(string, *types.DeviceAdoptedEvent)) {
+	esh.deviceAdoptedEventMutex.Lock()
+	defer esh.deviceAdoptedEventMutex.Unlock()
+
+	esh.deviceAdoptedEventHandler = handler
+}
+
+func (esh *NetworkEventStreamHandler) invokeDeviceAdoptedEventHandler(eventType string, event *types.DeviceAdoptedEvent) {
+	esh.deviceAdoptedEventMutex.Lock()
+	defer esh.deviceAdoptedEventMutex.Unlock()
+
+	if esh.deviceAdoptedEventHandler != nil {
+		go esh.deviceAdoptedEventHandler(eventType, event)
+	}
+}
+
+func (esh *NetworkEventStreamHandler) SetClientConnectedEventHandler(handler func(string, *types.ClientConnectedEvent)) {
+	esh.clientConnectedEventMutex.Lock()
+	defer esh.clientConnectedEventMutex.Unlock()
+
+	esh.clientConnectedEventHandler = handler
+}
+
+func (esh *NetworkEventStreamHandler) invokeClientConnectedEventHandler(eventType string, event *types.ClientConnectedEvent) {
+	esh.clientConnectedEventMutex.Lock()
+	defer esh.clientConnectedEventMutex.Unlock()
+
+	if esh.clientConnectedEventHandler != nil {
+		go esh.clientConnectedEventHandler(eventType, event)
+	}
+}
+
+func (esh *NetworkEventStreamHandler) SetClientDisconnectedEventHandler(handler func(string, *types.ClientDisconnectedEvent)) {
+	esh.clientDisconnectedEventMutex.Lock()
+	defer esh.clientDisconnectedEventMutex.Unlock()
+
+	esh.clientDisconnectedEventHandler = handler
+}
+
+func (esh *NetworkEventStreamHandler) invokeClientDisconnectedEventHandler(eventType string, event *types.ClientDisconnectedEvent) {
+	esh.clientDisconnectedEventMutex.Lock()
+	defer esh.clientDisconnectedEventMutex.Unlock()
+
+	if esh.clientDisconnectedEventHandler != nil {
+		go esh.clientDisconnectedEventHandler(eventType, event)
+	}
+}
+
+func (esh *NetworkEventStreamHandler) SetVoucherActivatedEventHandler(handler func(string, *types.VoucherActivatedEvent)) {
+	esh.voucherActivatedEventMutex.Lock()
+	defer esh.voucherActivatedEventMutex.Unlock()
+
+	esh.voucherActivatedEventHandler = handler
+}
+
+func (esh *NetworkEventStreamHandler) invokeVoucherActivatedEventHandler(eventType string, event *types.VoucherActivatedEvent) {
+	esh.voucherActivatedEventMutex.Lock()
+	defer esh.voucherActivatedEventMutex.Unlock()
+
+	if esh.voucherActivatedEventHandler != nil {
+		go esh.voucherActivatedEventHandler(eventType, event)
+	}
+}
+
+func (esh *NetworkEventStreamHandler) SetVoucherExpiredEventHandler(handler func(string, *types.VoucherExpiredEvent)) {
+	esh.voucherExpiredEventMutex.Lock()
+	defer esh.voucherExpiredEventMutex.Unlock()
+
+	esh.voucherExpiredEventHandler = handler
+}
+
+func (esh *NetworkEventStreamHandler) invokeVoucherExpiredEventHandler(eventType string, event *types.VoucherExpiredEvent) {
+	esh.voucherExpiredEventMutex.Lock()
+	defer esh.voucherExpiredEventMutex.Unlock()
+
+	if esh.voucherExpiredEventHandler != nil {
+		go esh.voucherExpiredEventHandler(eventType, event)
+	}
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// Default time between polls of SubscribeNetworkEvents.
+const defaultNetworkEventInterval = time.Second * 30
+
+// NetworkEventOptions configure SubscribeNetworkEvents.
+type NetworkEventOptions struct {
+	// Interval is the time between polls. Changes which are undone within an
+	// interval, e.g. a client reconnecting, aren't seen. Defaults to 30
+	// seconds.
+	Interval time.Duration
+	// Statistics also polls the statistics of every online device, to catch
+	// restarts which are quicker than Interval. It costs a request per
+	// device per poll.
+	Statistics bool
+}
+
+// One poll of a site.
+type networkSnapshot struct {
+	at       time.Time
+	devices  []*types.DeviceListEntry
+	uptimes  map[string]int64
+	clients  []*types.Client
+	vouchers []*types.Voucher
+}
+
+func pollNetwork(network types.NetworkV1, siteID types.SiteID, options *NetworkEventOptions) (*networkSnapshot, error) {
+	snapshot := &networkSnapshot{at: time.Now(), uptimes: map[string]int64{}}
+
+	var err error
+	snapshot.devices, err = AllDevices(network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.clients, err = AllClients(network, siteID, "")
+	if err != nil {
+		return nil, err
+	}
+	snapshot.vouchers, err = AllVouchers(network, siteID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Statistics {
+		for _, device := range snapshot.devices {
+			if device.State != types.DeviceStateOnline {
+				continue
+			}
+			stats, err := network.DeviceStatistics(siteID, types.DeviceID(device.ID))
+			if err != nil {
+				log.WithField("device", device.Name).Debug(err.Error())
+				continue
+			}
+			snapshot.uptimes[device.ID] = stats.UptimeSec
+		}
+	}
+	return snapshot, nil
+}
+
+// Indexes items by ID.
+func byID[T any](items []*T, id func(*T) string) map[string]*T {
+	indexed := make(map[string]*T, len(items))
+	for _, item := range items {
+		indexed[id(item)] = item
+	}
+	return indexed
+}
+
+// A typed Network event item, e.g. *types.DeviceAdoptedEvent.
+type networkEventItem interface {
+	EventItem() *types.NetworkEventItem
+}
+
+// Returns the events which turn previous into current.
+func diffNetwork(siteID types.SiteID, previous, current *networkSnapshot) []networkEventItem {
+	items := []networkEventItem{}
+	item := func(id string, eventType string) types.NetworkEventItem {
+		return types.NetworkEventItem{ID: id, Type: eventType, SiteID: siteID, Timestamp: current.at}
+	}
+
+	previousDevices := byID(previous.devices, func(d *types.DeviceListEntry) string { return d.ID })
+	for _, device := range current.devices {
+		before, ok := previousDevices[device.ID]
+		if !ok {
+			items = append(items, &types.DeviceAdoptedEvent{
+				NetworkEventItem: item(device.ID, types.DeviceAdoptedEventType),
+				Name:             device.Name,
+				Model:            device.Model,
+				MacAddress:       device.MacAddress,
+				IPAddress:        device.IPAddress,
+				State:            device.State,
+			})
+			continue
+		}
+		uptimeBefore, hadUptime := previous.uptimes[device.ID]
+		uptime, hasUptime := current.uptimes[device.ID]
+		restarted := hadUptime && hasUptime && uptime < uptimeBefore
+		if before.State != device.State || restarted {
+			items = append(items, &types.DeviceStateChangedEvent{
+				NetworkEventItem: item(device.ID, types.DeviceStateChangedEventType),
+				Name:             device.Name,
+				Model:            device.Model,
+				PreviousState:    before.State,
+				State:            device.State,
+				Restarted:        restarted,
+			})
+		}
+	}
+
+	clientID := func(c *types.Client) string { return c.ID }
+	previousClients := byID(previous.clients, clientID)
+	currentClients := byID(current.clients, clientID)
+	for _, c := range current.clients {
+		if _, ok := previousClients[c.ID]; !ok {
+			items = append(items, &types.ClientConnectedEvent{
+				NetworkEventItem: item(c.ID, types.ClientConnectedEventType),
+				Client:           c,
+			})
+		}
+	}
+	for _, c := range previous.clients {
+		if _, ok := currentClients[c.ID]; !ok {
+			items = append(items, &types.ClientDisconnectedEvent{
+				NetworkEventItem: item(c.ID, types.ClientDisconnectedEventType),
+				Client:           c,
+			})
+		}
+	}
+
+	previousVouchers := byID(previous.vouchers, func(v *types.Voucher) string { return v.ID })
+	for _, voucher := range current.vouchers {
+		before, ok := previousVouchers[voucher.ID]
+		if !ok {
+			continue
+		}
+		if before.ActivatedAt.IsZero() && !voucher.ActivatedAt.IsZero() {
+			items = append(items, &types.VoucherActivatedEvent{
+				NetworkEventItem: item(voucher.ID, types.VoucherActivatedEventType),
+				Voucher:          voucher,
+			})
+		}
+		if !before.Expired && voucher.Expired {
+			items = append(items, &types.VoucherExpiredEvent{
+				NetworkEventItem: item(voucher.ID, types.VoucherExpiredEventType),
+				Voucher:          voucher,
+			})
+		}
+	}
+	return items
+}
+
+// SubscribeNetworkEvents streams changes to a site's devices, clients and
+// vouchers. The Network integration API has no subscriptions, so the site is
+// polled and each poll compared with the last. The first poll only sets the
+// baseline; its error is returned. Later failed polls are logged and retried
+// at the next interval. The stream closes when ctx is done.
+func SubscribeNetworkEvents(ctx context.Context, network types.NetworkV1, siteID types.SiteID,
+	options *NetworkEventOptions) (<-chan *types.NetworkEvent, error) {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = defaultNetworkEventInterval
+	}
+
+	previous, err := pollNetwork(network, siteID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	eventChan := make(chan *types.NetworkEvent)
+	go func() {
+		defer close(eventChan)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				log.Trace("Context done.")
+				return
+			}
+
+			current, pollErr := pollNetwork(network, siteID, options)
+			if pollErr != nil {
+				log.WithField("site", siteID).Warnf("Polling network failed: %s", pollErr.Error())
+				continue
+			}
+
+			for _, item := range diffNetwork(siteID, previous, current) {
+				event, eventErr := types.NewNetworkEvent(item)
+				if eventErr != nil {
+					log.Error(eventErr.Error())
+					continue
+				}
+				select {
+				case eventChan <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			previous = current
+		}
+	}()
+
+	return eventChan, nil
+}
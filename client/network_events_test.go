@@ -0,0 +1,142 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+type networkPoll struct {
+	devices  []*types.DeviceListEntry
+	uptimes  map[string]int64
+	clients  []*types.Client
+	vouchers []*types.Voucher
+}
+
+// Serves the next poll from each call to Devices, repeating the last once they
+// run out. poll starts at -1, before the first.
+type pollingNetwork struct {
+	types.NetworkV1
+
+	mutex sync.Mutex
+	polls []networkPoll
+	poll  int
+}
+
+func onePage[T any](items []*T) ([]*T, *types.Page, error) {
+	return items, &types.Page{Count: len(items), TotalCount: len(items)}, nil
+}
+
+func (pn *pollingNetwork) current() networkPoll {
+	pn.mutex.Lock()
+	defer pn.mutex.Unlock()
+	return pn.polls[min(pn.poll, len(pn.polls)-1)]
+}
+
+func (pn *pollingNetwork) Devices(types.SiteID, *types.PageArguments) ([]*types.DeviceListEntry, *types.Page, error) {
+	pn.mutex.Lock()
+	pn.poll++
+	pn.mutex.Unlock()
+	return onePage(pn.current().devices)
+}
+
+func (pn *pollingNetwork) Clients(types.SiteID, types.Filter, *types.PageArguments) ([]*types.Client, *types.Page, error) {
+	return onePage(pn.current().clients)
+}
+
+func (pn *pollingNetwork) Vouchers(types.SiteID, types.Filter, *types.PageArguments) ([]*types.Voucher, *types.Page, error) {
+	return onePage(pn.current().vouchers)
+}
+
+func (pn *pollingNetwork) DeviceStatistics(_ types.SiteID, id types.DeviceID) (*types.DeviceStatistics, error) {
+	return &types.DeviceStatistics{UptimeSec: pn.current().uptimes[string(id)]}, nil
+}
+
+func TestSubscribeNetworkEvents(t *testing.T) {
+	ap := &types.DeviceListEntry{ID: "ap", Name: "AP", State: types.DeviceStateOnline}
+	sw := &types.DeviceListEntry{ID: "sw", Name: "Switch", State: types.DeviceStateOnline}
+	offlineAP := &types.DeviceListEntry{ID: "ap", Name: "AP", State: types.DeviceStateOffline}
+	laptop := &types.Client{ID: "laptop", Name: "Laptop"}
+	phone := &types.Client{ID: "phone", Name: "Phone"}
+	unused := &types.Voucher{ID: "v1", Code: "12345"}
+	used := &types.Voucher{ID: "v1", Code: "12345", ActivatedAt: time.Now()}
+	expired := &types.Voucher{ID: "v1", Code: "12345", ActivatedAt: time.Now(), Expired: true}
+
+	network := &pollingNetwork{poll: -1, polls: []networkPoll{
+		{},
+		{
+			devices:  []*types.DeviceListEntry{sw},
+			uptimes:  map[string]int64{"sw": 1000},
+			clients:  []*types.Client{laptop},
+			vouchers: []*types.Voucher{unused},
+		},
+		{
+			devices:  []*types.DeviceListEntry{sw, ap},
+			uptimes:  map[string]int64{"sw": 1030, "ap": 500},
+			clients:  []*types.Client{laptop, phone},
+			vouchers: []*types.Voucher{used},
+		},
+		{
+			devices:  []*types.DeviceListEntry{sw, offlineAP},
+			uptimes:  map[string]int64{"sw": 5},
+			clients:  []*types.Client{phone},
+			vouchers: []*types.Voucher{expired},
+		},
+	}}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.SubscribeNetworkEvents(streamCtx, network, "default",
+		&client.NetworkEventOptions{Interval: time.Millisecond, Statistics: true})
+	require.NoError(t, err)
+
+	want := []string{
+		"deviceAdopted sw", "clientConnected laptop",
+		"deviceAdopted ap", "clientConnected phone", "voucherActivated v1",
+		"deviceStateChanged sw", "deviceStateChanged ap", "clientDisconnected laptop", "voucherExpired v1",
+	}
+	got := []string{}
+	events := []*types.NetworkEvent{}
+	for len(got) < len(want) {
+		event := <-stream
+		require.NotNil(t, event)
+		item := event.EventItem()
+		require.NotNil(t, item)
+		assert.Equal(t, types.SiteID("default"), item.SiteID)
+		got = append(got, item.Type+" "+item.ID)
+		events = append(events, event)
+	}
+	assert.Equal(t, want, got)
+
+	restarted, ok := events[5].Item.(*types.DeviceStateChangedEvent)
+	require.True(t, ok)
+	assert.True(t, restarted.Restarted)
+	assert.Equal(t, types.DeviceStateOnline, restarted.State)
+
+	offline, ok := events[6].Item.(*types.DeviceStateChangedEvent)
+	require.True(t, ok)
+	assert.Equal(t, types.DeviceStateOnline, offline.PreviousState)
+	assert.Equal(t, types.DeviceStateOffline, offline.State)
+
+	// Events round trip through JSON.
+	data, err := json.Marshal(events[6])
+	require.NoError(t, err)
+	var decoded types.NetworkEvent
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	decodedOffline, ok := decoded.Item.(*types.DeviceStateChangedEvent)
+	require.True(t, ok)
+	assert.Equal(t, offline.State, decodedOffline.State)
+	assert.True(t, offline.Timestamp.Equal(decodedOffline.Timestamp))
+
+	cancel()
+	for range stream { //nolint:revive // Drain until the stream closes.
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"github.com/ClifHouck/unified/types"
+)
+
+// Largest page the Network API returns.
+const maxNetworkPageLimit = 200
+
+// Fetches every page from a paginated Network endpoint.
+func allPages[T any](fetch func(*types.PageArguments) ([]*T, *types.Page, error)) ([]*T, error) {
+	all := []*T{}
+	pageArgs := &types.PageArguments{Limit: maxNetworkPageLimit}
+	for {
+		items, page, err := fetch(pageArgs)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) == 0 || page == nil || page.Offset+page.Count >= page.TotalCount {
+			return all, nil
+		}
+		pageArgs.Offset = uint32(page.Offset + page.Count) //nolint:gosec // Offsets are never negative.
+	}
+}
+
+// AllSites lists every site matching filter, across all pages.
+func AllSites(network types.NetworkV1, filter types.Filter) ([]*types.Site, error) {
+	return allPages(func(pageArgs *types.PageArguments) ([]*types.Site, *types.Page, error) {
+		return network.Sites(filter, pageArgs)
+	})
+}
+
+// AllClients lists every connected client of a site matching filter, across
+// all pages.
+func AllClients(network types.NetworkV1, siteID types.SiteID, filter types.Filter) ([]*types.Client, error) {
+	return allPages(func(pageArgs *types.PageArguments) ([]*types.Client, *types.Page, error) {
+		return network.Clients(siteID, filter, pageArgs)
+	})
+}
+
+// AllDevices lists every adopted device of a site, across all pages.
+func AllDevices(network types.NetworkV1, siteID types.SiteID) ([]*types.DeviceListEntry, error) {
+	return allPages(func(pageArgs *types.PageArguments) ([]*types.DeviceListEntry, *types.Page, error) {
+		return network.Devices(siteID, pageArgs)
+	})
+}
+
+// AllVouchers lists every voucher of a site matching filter, across all
+// pages.
+func AllVouchers(network types.NetworkV1, siteID types.SiteID, filter types.Filter) ([]*types.Voucher, error) {
+	return allPages(func(pageArgs *types.PageArguments) ([]*types.Voucher, *types.Page, error) {
+		return network.Vouchers(siteID, filter, pageArgs)
+	})
+}
+
+// AllDeviceDetails lists every adopted device of a site, with the details
+// DeviceDetails returns for each.
+func AllDeviceDetails(network types.NetworkV1, siteID types.SiteID) ([]*types.Device, error) {
+	entries, err := AllDevices(network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]*types.Device, 0, len(entries))
+	for _, entry := range entries {
+		device, err := network.DeviceDetails(siteID, types.DeviceID(entry.ID))
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// SitePort is a port of one of a site's devices.
+type SitePort struct {
+	DeviceID    string `json:"deviceId"`
+	DeviceName  string `json:"deviceName"`
+	DeviceModel string `json:"deviceModel"`
+	*types.DevicePort
+}
+
+// PortIdx returns the port's index, as used by DevicePortExecuteAction.
+func (sp *SitePort) PortIdx() types.PortIdx {
+	return types.PortIdx(sp.Idx) //nolint:gosec // Port indexes are never negative.
+}
+
+// Ports returns every port of devices, in device then port order.
+func Ports(devices []*types.Device) []*SitePort {
+	ports := []*SitePort{}
+	for _, device := range devices {
+		for _, port := range device.Interfaces.Ports {
+			ports = append(ports, &SitePort{
+				DeviceID:    device.ID,
+				DeviceName:  device.Name,
+				DeviceModel: device.Model,
+				DevicePort:  port,
+			})
+		}
+	}
+	return ports
+}
+
+// PortFilter selects ports. Empty fields match every port, and a port must
+// match every non-empty field.
+type PortFilter struct {
+	// Devices by name, ignoring case, or ID.
+	Devices    []string
+	Ports      []int
+	States     []types.PortState
+	Connectors []string
+	// PoE only matches ports which can supply power.
+	PoE bool
+	// BelowMaxSpeed only matches ports which negotiated a slower speed than
+	// they support.
+	BelowMaxSpeed bool
+}
+
+// IsEmpty returns true if the filter matches every port.
+func (pf *PortFilter) IsEmpty() bool {
+	return len(pf.Devices) == 0 && len(pf.Ports) == 0 && len(pf.States) == 0 &&
+		len(pf.Connectors) == 0 && !pf.PoE && !pf.BelowMaxSpeed
+}
+
+// Matches returns true if port is selected by the filter.
+func (pf *PortFilter) Matches(port *SitePort) bool {
+	if len(pf.Devices) > 0 && !slices.ContainsFunc(pf.Devices, func(device string) bool {
+		return device == port.DeviceID || strings.EqualFold(device, port.DeviceName)
+	}) {
+		return false
+	}
+	if len(pf.Ports) > 0 && !slices.Contains(pf.Ports, port.Idx) {
+		return false
+	}
+	if len(pf.States) > 0 && !slices.Contains(pf.States, port.State) {
+		return false
+	}
+	if len(pf.Connectors) > 0 && !slices.ContainsFunc(pf.Connectors, func(connector string) bool {
+		return strings.EqualFold(connector, port.Connector)
+	}) {
+		return false
+	}
+	if pf.PoE && !port.IsPoE() {
+		return false
+	}
+	if pf.BelowMaxSpeed && !port.BelowMaxSpeed() {
+		return false
+	}
+	return true
+}
+
+// Filter returns the ports selected by the filter.
+func (pf *PortFilter) Filter(ports []*SitePort) []*SitePort {
+	matched := []*SitePort{}
+	for _, port := range ports {
+		if pf.Matches(port) {
+			matched = append(matched, port)
+		}
+	}
+	return matched
+}
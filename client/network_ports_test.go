@@ -0,0 +1,67 @@
+package client_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+func sitePorts(t *testing.T) []*client.SitePort {
+	t.Helper()
+	var device types.Device
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "sw1",
+		"name": "Office Switch",
+		"model": "USW-Lite-8-PoE",
+		"interfaces": {"ports": [
+			{"idx": 1, "state": "UP", "connector": "RJ45", "maxSpeedMbps": 1000, "speedMbps": 1000,
+			 "poe": {"standard": "802.3at", "type": 2, "enabled": true, "state": "UP"}},
+			{"idx": 2, "state": "UP", "connector": "RJ45", "maxSpeedMbps": 1000, "speedMbps": 100,
+			 "poe": {"standard": "802.3at", "type": 2, "enabled": true, "state": "UP"}},
+			{"idx": 3, "state": "DOWN", "connector": "RJ45", "maxSpeedMbps": 1000, "speedMbps": 0},
+			{"idx": 9, "state": "UP", "connector": "SFP", "maxSpeedMbps": 1000, "speedMbps": 1000}
+		]}
+	}`), &device))
+	return client.Ports([]*types.Device{&device})
+}
+
+func portIndexes(ports []*client.SitePort) []int {
+	indexes := []int{}
+	for _, port := range ports {
+		indexes = append(indexes, port.Idx)
+	}
+	return indexes
+}
+
+func TestPortFilter(t *testing.T) {
+	ports := sitePorts(t)
+	require.Len(t, ports, 4)
+	assert.Equal(t, "Office Switch", ports[0].DeviceName)
+
+	tests := []struct {
+		name   string
+		filter client.PortFilter
+		want   []int
+	}{
+		{"empty", client.PortFilter{}, []int{1, 2, 3, 9}},
+		{"device name", client.PortFilter{Devices: []string{"office switch"}}, []int{1, 2, 3, 9}},
+		{"other device", client.PortFilter{Devices: []string{"sw2"}}, []int{}},
+		{"ports", client.PortFilter{Ports: []int{2, 3}}, []int{2, 3}},
+		{"down", client.PortFilter{States: []types.PortState{types.PortStateDown}}, []int{3}},
+		{"connector", client.PortFilter{Connectors: []string{"sfp"}}, []int{9}},
+		{"poe", client.PortFilter{PoE: true}, []int{1, 2}},
+		{"below max speed", client.PortFilter{BelowMaxSpeed: true}, []int{2}},
+		{"combined", client.PortFilter{PoE: true, Ports: []int{1, 3}}, []int{1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, portIndexes(test.filter.Filter(ports)))
+			assert.Equal(t, test.name == "empty", test.filter.IsEmpty())
+		})
+	}
+}
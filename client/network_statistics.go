@@ -0,0 +1,50 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// DefaultStatisticsWorkers is how many statistics requests
+// FetchDeviceStatistics makes at once unless told otherwise.
+const DefaultStatisticsWorkers = 8
+
+// DeviceStatisticsResult is a device and its statistics, or the error which
+// stopped them being fetched.
+type DeviceStatisticsResult struct {
+	Device     *types.DeviceListEntry
+	Statistics *types.DeviceStatistics
+	Err        error
+}
+
+// FetchDeviceStatistics fetches the statistics of every device, making at
+// most workers requests at once so large sites aren't flooded with requests.
+// Results are in the same order as devices.
+func FetchDeviceStatistics(network types.NetworkV1, siteID types.SiteID, devices []*types.DeviceListEntry,
+	workers int) []*DeviceStatisticsResult {
+	if workers < 1 {
+		workers = DefaultStatisticsWorkers
+	}
+
+	results := make([]*DeviceStatisticsResult, len(devices))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(devices)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				device := devices[i]
+				stats, err := network.DeviceStatistics(siteID, types.DeviceID(device.ID))
+				results[i] = &DeviceStatisticsResult{Device: device, Statistics: stats, Err: err}
+			}
+		}()
+	}
+	for i := range devices {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,62 @@
+package client_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Records how many DeviceStatistics requests are in flight at once.
+type statisticsNetwork struct {
+	types.NetworkV1
+
+	mutex       sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (sn *statisticsNetwork) DeviceStatistics(_ types.SiteID, id types.DeviceID) (*types.DeviceStatistics, error) {
+	sn.mutex.Lock()
+	sn.inFlight++
+	sn.maxInFlight = max(sn.maxInFlight, sn.inFlight)
+	sn.mutex.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	sn.mutex.Lock()
+	sn.inFlight--
+	sn.mutex.Unlock()
+
+	if id == "broken" {
+		return nil, errors.New("unreachable")
+	}
+	return &types.DeviceStatistics{CPUUtilizationPct: float64(len(id))}, nil
+}
+
+func TestFetchDeviceStatisticsBoundsConcurrency(t *testing.T) {
+	devices := []*types.DeviceListEntry{}
+	for i := range 50 {
+		devices = append(devices, &types.DeviceListEntry{ID: fmt.Sprintf("device-%d", i)})
+	}
+	devices = append(devices, &types.DeviceListEntry{ID: "broken"})
+
+	network := &statisticsNetwork{}
+	results := client.FetchDeviceStatistics(network, "default", devices, 4)
+
+	require.Len(t, results, len(devices))
+	assert.LessOrEqual(t, network.maxInFlight, 4)
+	for i, result := range results[:50] {
+		assert.Same(t, devices[i], result.Device)
+		require.NoError(t, result.Err)
+		assert.InDelta(t, float64(len(devices[i].ID)), result.Statistics.CPUUtilizationPct, 0.001)
+	}
+	assert.Error(t, results[50].Err)
+}
@@ -0,0 +1,106 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+// Returns a client for a fake controller which records each request it gets.
+func newRecordingClient(t *testing.T) (*client.Client, *[]recordedRequest) {
+	t.Helper()
+	requests := &[]recordedRequest{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(data, &body)
+		*requests = append(*requests, recordedRequest{Method: r.Method, Path: r.URL.Path, Body: body})
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	config := client.NewDefaultConfig("key")
+	config.Hostname = serverURL.Host
+	return client.NewClient(context.Background(), config, logrus.New()), requests
+}
+
+func TestNetworkActionRouting(t *testing.T) {
+	c, requests := newRecordingClient(t)
+
+	require.NoError(t, c.Network.DeviceExecuteAction("site", "dev", types.NewDeviceRestartRequest()))
+	require.NoError(t, c.Network.DevicePortExecuteAction("site", "dev", 7, types.NewPortPowerCycleRequest()))
+	require.NoError(t, c.Network.ClientExecuteAction("site", "cl",
+		types.NewAuthorizeGuestAccessRequest(types.GuestAccessLimits{TimeLimitMinutes: 60, RxRateLimitKbps: 512})))
+	require.NoError(t, c.Network.ClientExecuteAction("site", "cl", types.NewUnauthorizeGuestAccessRequest()))
+
+	prefix := "/proxy/network/integration/v1/sites/site/"
+	assert.Equal(t, []recordedRequest{
+		{http.MethodPost, prefix + "devices/dev/actions", map[string]any{"action": "RESTART"}},
+		{http.MethodPost, prefix + "devices/dev/interfaces/ports/7/actions", map[string]any{"action": "POWER_CYCLE"}},
+		{http.MethodPost, prefix + "clients/cl/actions", map[string]any{
+			"action":           "AUTHORIZE_GUEST_ACCESS",
+			"timeLimitMinutes": float64(60),
+			"rxRateLimitKbps":  float64(512),
+		}},
+		{http.MethodPost, prefix + "clients/cl/actions", map[string]any{"action": "UNAUTHORIZE_GUEST_ACCESS"}},
+	}, *requests)
+}
+
+// Serves clients from a fixed list, a page at a time.
+type fakeNetwork struct {
+	types.NetworkV1
+
+	clients  []*types.Client
+	pageArgs []types.PageArguments
+}
+
+func (fn *fakeNetwork) Clients(
+	_ types.SiteID,
+	_ types.Filter,
+	pageArgs *types.PageArguments,
+) ([]*types.Client, *types.Page, error) {
+	fn.pageArgs = append(fn.pageArgs, *pageArgs)
+	end := min(int(pageArgs.Offset+pageArgs.Limit), len(fn.clients))
+	page := fn.clients[pageArgs.Offset:end]
+	return page, &types.Page{
+		Offset:     int(pageArgs.Offset),
+		Limit:      int(pageArgs.Limit),
+		Count:      len(page),
+		TotalCount: len(fn.clients),
+	}, nil
+}
+
+func TestAllClientsFetchesEveryPage(t *testing.T) {
+	network := &fakeNetwork{}
+	for range 450 {
+		network.clients = append(network.clients, &types.Client{})
+	}
+
+	clients, err := client.AllClients(network, "site", "")
+	require.NoError(t, err)
+	assert.Len(t, clients, 450)
+	assert.Equal(t, []types.PageArguments{
+		{Offset: 0, Limit: 200},
+		{Offset: 200, Limit: 200},
+		{Offset: 400, Limit: 200},
+	}, network.pageArgs)
+}
@@ -0,0 +1,260 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// Minimum time between refreshes triggered by looking up an unknown device,
+// so a stream of events from a device Protect doesn't list can't hammer the
+// list endpoints.
+const minDeviceMissRefreshInterval = time.Second * 30
+
+// ProtectDevice describes a Protect device, as far as events are concerned.
+type ProtectDevice struct {
+	ID string `json:"id"`
+	// ModelKey is the kind of device, e.g. "camera" or "sensor".
+	ModelKey types.ModelKey           `json:"modelKey"`
+	Name     string                   `json:"name"`
+	State    types.ProtectDeviceState `json:"state,omitempty"`
+	// Mac is only known for cameras, lights, viewers and chimes.
+	Mac string `json:"mac,omitempty"`
+}
+
+// EnrichedProtectEvent is a ProtectEvent with its device resolved to a name
+// and its timestamps converted to time.Time.
+type EnrichedProtectEvent struct {
+	// MessageType is "add" or "update".
+	MessageType string `json:"messageType"`
+	ID          string `json:"id"`
+	// Type is the event's registry key, e.g. "motion".
+	Type           string    `json:"type"`
+	DeviceID       string    `json:"deviceId"`
+	DeviceName     string    `json:"deviceName,omitempty"`
+	DeviceModelKey string    `json:"deviceModelKey,omitempty"`
+	Start          time.Time `json:"start,omitzero"`
+	End            time.Time `json:"end,omitzero"`
+	// Event is the typed event item, e.g. *types.CameraMotionEvent.
+	Event interface{} `json:"event"`
+}
+
+// ProtectDeviceDirectory resolves Protect device IDs to devices. It is filled
+// from the Protect list endpoints, and kept current by feeding it device
+// events.
+type ProtectDeviceDirectory struct {
+	protect types.ProtectV1
+
+	devices     map[string]*ProtectDevice
+	lastRefresh time.Time
+	mutex       sync.RWMutex
+}
+
+// NewProtectDeviceDirectory returns an empty directory. Call Refresh to fill
+// it.
+func NewProtectDeviceDirectory(protect types.ProtectV1) *ProtectDeviceDirectory {
+	return &ProtectDeviceDirectory{
+		protect: protect,
+		devices: map[string]*ProtectDevice{},
+	}
+}
+
+// Refresh replaces the directory's contents with the devices currently
+// listed by Protect. If some device types can't be listed, the devices which
+// could be are still used and the errors are returned.
+func (dd *ProtectDeviceDirectory) Refresh() error {
+	devices := map[string]*ProtectDevice{}
+	var errs []error
+
+	add := func(id string, modelKey types.ModelKey, name string, state types.ProtectDeviceState, mac string) {
+		devices[id] = &ProtectDevice{ID: id, ModelKey: modelKey, Name: name, State: state, Mac: mac}
+	}
+
+	cameras, err := dd.protect.Cameras()
+	errs = append(errs, err)
+	for _, camera := range cameras {
+		add(camera.ID, camera.ModelKey, camera.Name, camera.State, camera.Mac)
+	}
+
+	lights, err := dd.protect.Lights()
+	errs = append(errs, err)
+	for _, light := range lights {
+		add(light.ID, light.ModelKey, light.Name, light.State, light.Mac)
+	}
+
+	sensors, err := dd.protect.Sensors()
+	errs = append(errs, err)
+	for _, sensor := range sensors {
+		add(sensor.ID, sensor.ModelKey, sensor.Name, sensor.State, "")
+	}
+
+	chimes, err := dd.protect.Chimes()
+	errs = append(errs, err)
+	for _, chime := range chimes {
+		add(chime.ID, chime.ModelKey, chime.Name, chime.State, chime.Mac)
+	}
+
+	viewers, err := dd.protect.Viewers()
+	errs = append(errs, err)
+	for _, viewer := range viewers {
+		add(viewer.ID, viewer.ModelKey, viewer.Name, viewer.State, viewer.Mac)
+	}
+
+	nvr, err := dd.protect.NVRs()
+	errs = append(errs, err)
+	if nvr != nil {
+		add(nvr.ID, nvr.ModelKey, nvr.Name, "", "")
+	}
+
+	dd.mutex.Lock()
+	dd.devices = devices
+	dd.lastRefresh = time.Now()
+	dd.mutex.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// Lookup returns the device with the given ID. An unknown ID triggers a
+// Refresh, at most once every 30 seconds, in case the device is new.
+func (dd *ProtectDeviceDirectory) Lookup(id string) (*ProtectDevice, bool) {
+	device, ok := dd.get(id)
+	if ok || id == "" {
+		return device, ok
+	}
+
+	dd.mutex.RLock()
+	sinceRefresh := time.Since(dd.lastRefresh)
+	dd.mutex.RUnlock()
+	if sinceRefresh < minDeviceMissRefreshInterval {
+		return nil, false
+	}
+
+	log.WithField("ID", id).Debug("Unknown device, refreshing device directory")
+	err := dd.Refresh()
+	if err != nil {
+		log.Warn(err.Error())
+	}
+	return dd.get(id)
+}
+
+func (dd *ProtectDeviceDirectory) get(id string) (*ProtectDevice, bool) {
+	dd.mutex.RLock()
+	defer dd.mutex.RUnlock()
+
+	device, ok := dd.devices[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *device
+	return &copied, true
+}
+
+// Devices returns every device in the directory, ordered by name.
+func (dd *ProtectDeviceDirectory) Devices() []*ProtectDevice {
+	dd.mutex.RLock()
+	defer dd.mutex.RUnlock()
+
+	devices := make([]*ProtectDevice, 0, len(dd.devices))
+	for _, device := range dd.devices {
+		copied := *device
+		devices = append(devices, &copied)
+	}
+	slices.SortFunc(devices, func(a, b *ProtectDevice) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.ID, b.ID))
+	})
+	return devices
+}
+
+// Update applies a device event to the directory, picking up renamed, added
+// and removed devices.
+func (dd *ProtectDeviceDirectory) Update(streamEvent *types.ProtectDeviceEvent) {
+	item, ok := deviceEventItem(streamEvent)
+	if !ok || item.ID == "" {
+		return
+	}
+
+	dd.mutex.Lock()
+	defer dd.mutex.Unlock()
+
+	if streamEvent.Type == "remove" {
+		delete(dd.devices, item.ID)
+		return
+	}
+
+	device, ok := dd.devices[item.ID]
+	if !ok {
+		device = &ProtectDevice{ID: item.ID, ModelKey: types.ModelKey(streamEvent.ModelKey)}
+		dd.devices[item.ID] = device
+	}
+	// Update events only carry the fields which changed.
+	if item.Name != "" {
+		device.Name = item.Name
+	}
+	if item.State != "" {
+		device.State = item.State
+	}
+}
+
+// ProcessDeviceEvents applies every event on stream to the directory until
+// the stream closes or the context is done.
+func (dd *ProtectDeviceDirectory) ProcessDeviceEvents(ctx context.Context,
+	stream <-chan *types.ProtectDeviceEvent) {
+	for {
+		select {
+		case streamEvent := <-stream:
+			if streamEvent == nil {
+				log.Warn("Got nil event. Bailing out!")
+				return
+			}
+			dd.Update(streamEvent)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Enrich resolves the device of a ProtectEvent.
+func (dd *ProtectDeviceDirectory) Enrich(streamEvent *types.ProtectEvent) *EnrichedProtectEvent {
+	enriched := &EnrichedProtectEvent{
+		MessageType: streamEvent.Type,
+		Type:        streamEvent.ItemType,
+		Event:       streamEvent.Item,
+	}
+
+	item := streamEvent.EventItem()
+	if item == nil {
+		return enriched
+	}
+	enriched.ID = item.ID
+	enriched.DeviceID = item.Device
+	enriched.Start = item.StartTime()
+	enriched.End = item.EndTime()
+
+	device, ok := dd.Lookup(item.Device)
+	if ok {
+		enriched.DeviceName = device.Name
+		enriched.DeviceModelKey = device.ModelKey.String()
+	}
+	return enriched
+}
+
+// The typed device events don't share an embedded struct, so the common
+// fields are decoded from the raw item instead.
+func deviceEventItem(streamEvent *types.ProtectDeviceEvent) (*types.ProtectDeviceEventItem, bool) {
+	var item types.ProtectDeviceEventItem
+	err := json.Unmarshal(streamEvent.RawItem, &item)
+	if err != nil {
+		log.Warn(err.Error())
+		return nil, false
+	}
+	return &item, true
+}
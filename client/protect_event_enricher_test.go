@@ -0,0 +1,66 @@
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// fakeProtect lists a fixed set of devices. Calling any other ProtectV1
+// method panics.
+type fakeProtect struct {
+	types.ProtectV1
+
+	cameras []*types.Camera
+	sensors []*types.Sensor
+}
+
+func (fp *fakeProtect) Cameras() ([]*types.Camera, error) { return fp.cameras, nil }
+func (fp *fakeProtect) Sensors() ([]*types.Sensor, error) { return fp.sensors, nil }
+func (fp *fakeProtect) Lights() ([]*types.Light, error)   { return nil, nil }
+func (fp *fakeProtect) Chimes() ([]*types.Chime, error)   { return nil, nil }
+func (fp *fakeProtect) Viewers() ([]*types.Viewer, error) { return nil, nil }
+func (fp *fakeProtect) NVRs() (*types.NVR, error)         { return nil, errors.New("no NVR") }
+
+func TestProtectDeviceDirectoryEnrich(t *testing.T) {
+	protect := &fakeProtect{
+		cameras: []*types.Camera{{ID: "cam1", ModelKey: "camera", Name: "Front Door"}},
+		sensors: []*types.Sensor{{ID: "sensor1", ModelKey: "sensor", Name: "Garage"}},
+	}
+	directory := client.NewProtectDeviceDirectory(protect)
+	require.Error(t, directory.Refresh())
+
+	enriched := directory.Enrich(protectEvent(t, `{"type": "add", "item": {"id": "e1",
+		"modelKey": "event", "type": "motion", "start": 1700000000000, "device": "cam1"}}`))
+
+	assert.Equal(t, "add", enriched.MessageType)
+	assert.Equal(t, "motion", enriched.Type)
+	assert.Equal(t, "Front Door", enriched.DeviceName)
+	assert.Equal(t, "camera", enriched.DeviceModelKey)
+	assert.Equal(t, time.UnixMilli(1700000000000), enriched.Start)
+	assert.True(t, enriched.End.IsZero())
+	assert.IsType(t, &types.CameraMotionEvent{}, enriched.Event)
+
+	rename := &types.ProtectDeviceEvent{}
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "update", "item": {"id": "cam1",
+		"modelKey": "camera", "name": "Back Door"}}`), rename))
+	directory.Update(rename)
+
+	device, ok := directory.Lookup("cam1")
+	require.True(t, ok)
+	assert.Equal(t, "Back Door", device.Name)
+
+	sensor, ok := directory.Lookup("sensor1")
+	require.True(t, ok)
+	assert.Equal(t, types.ModelKeySensor, sensor.ModelKey)
+
+	_, ok = directory.Lookup("unknown")
+	assert.False(t, ok)
+}
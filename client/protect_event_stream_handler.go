@@ -60,6 +60,9 @@ type ProtectEventStreamHandler struct {
 
 	cameraSmartDetectLoiterZoneEventHandler func(string, *types.CameraSmartDetectLoiterZoneEvent)
 	cameraSmartDetectLoiterZoneEventMutex   sync.Mutex
+
+	compositeEventHandler func(string, *types.CompositeEvent)
+	compositeEventMutex   sync.Mutex
 } // ProtectEventStreamHandler
 
 func NewProtectEventStreamHandler(ctx context.Context,
@@ -126,6 +129,8 @@ func (esh *ProtectEventStreamHandler) Process() {
 				go esh.invokeCameraSmartDetectLineEventHandler(streamEvent.Type, event)
 			case *types.CameraSmartDetectLoiterZoneEvent:
 				go esh.invokeCameraSmartDetectLoiterZoneEventHandler(streamEvent.Type, event)
+			case *types.CompositeEvent:
+				go esh.invokeCompositeEventHandler(streamEvent.Type, event)
 
 			default:
 				log.Errorf("Unknown type encountered: '%s'", streamEvent.ItemType)
@@ -377,3 +382,19 @@ func (esh *ProtectEventStreamHandler) invokeCameraSmartDetectLoiterZoneEventHand
 		go esh.cameraSmartDetectLoiterZoneEventHandler(eventType, event)
 	}
 }
+
+func (esh *ProtectEventStreamHandler) SetCompositeEventHandler(handler func(string, *types.CompositeEvent)) {
+	esh.compositeEventMutex.Lock()
+	defer esh.compositeEventMutex.Unlock()
+
+	esh.compositeEventHandler = handler
+}
+
+func (esh *ProtectEventStreamHandler) invokeCompositeEventHandler(eventType string, event *types.CompositeEvent) {
+	esh.compositeEventMutex.Lock()
+	defer esh.compositeEventMutex.Unlock()
+
+	if esh.compositeEventHandler != nil {
+		go esh.compositeEventHandler(eventType, event)
+	}
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// DefaultEventTrackerTimeout is how long ProtectEventTracker waits for an
+// event to end before giving up on it.
+const DefaultEventTrackerTimeout = time.Minute * 10
+
+// EventStarted is emitted by ProtectEventTracker when Protect reports that an
+// event, such as motion or a smart detection, has begun.
+type EventStarted struct {
+	ID string `json:"id"`
+	// Type is the event's registry key, e.g. "motion".
+	Type   string    `json:"type"`
+	Device string    `json:"device"`
+	Start  time.Time `json:"start"`
+	// Event is the typed event item, e.g. *types.CameraMotionEvent.
+	Event interface{} `json:"event"`
+}
+
+// EventEnded is emitted by ProtectEventTracker when an event has ended, or
+// when the tracker gave up waiting for it to end.
+type EventEnded struct {
+	ID       string        `json:"id"`
+	Type     string        `json:"type"`
+	Device   string        `json:"device"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration"`
+	// TimedOut is true if Protect never reported an end for the event. End
+	// is then the time the tracker gave up on it.
+	TimedOut bool `json:"timedOut"`
+	// Event is the most recent typed event item seen for this event.
+	Event interface{} `json:"event"`
+}
+
+// ProtectEventTracker correlates the `add` and `update` messages Protect
+// sends for an event by ID, and emits a single EventStarted and EventEnded
+// for each event.
+type ProtectEventTracker struct {
+	ctx     context.Context
+	stream  <-chan *types.ProtectEvent
+	timeout time.Duration
+
+	active map[string]*EventStarted
+	// When events which ended or expired did so, by ID. Protect keeps sending
+	// updates for an event after it ends, which mustn't start it again.
+	// Entries are dropped by Expire once they're older than the timeout.
+	finished map[string]time.Time
+	// The time of the last Expire.
+	expiredAt   time.Time
+	activeMutex sync.Mutex
+
+	eventStartedHandler func(*EventStarted)
+	eventEndedHandler   func(*EventEnded)
+	handlerMutex        sync.Mutex
+}
+
+// NewProtectEventTracker returns a tracker which consumes stream. Events
+// which have not ended after timeout are ended with TimedOut set. A timeout
+// of zero uses DefaultEventTrackerTimeout.
+func NewProtectEventTracker(ctx context.Context,
+	stream <-chan *types.ProtectEvent, timeout time.Duration) *ProtectEventTracker {
+	if timeout == 0 {
+		timeout = DefaultEventTrackerTimeout
+	}
+	return &ProtectEventTracker{
+		ctx:      ctx,
+		stream:   stream,
+		timeout:  timeout,
+		active:   map[string]*EventStarted{},
+		finished: map[string]time.Time{},
+	}
+}
+
+// SetEventStartedHandler sets the function called when an event starts.
+// Handlers are called in order from the goroutine calling Process, so they
+// should return promptly.
+func (et *ProtectEventTracker) SetEventStartedHandler(handler func(*EventStarted)) {
+	et.handlerMutex.Lock()
+	defer et.handlerMutex.Unlock()
+
+	et.eventStartedHandler = handler
+}
+
+// SetEventEndedHandler sets the function called when an event ends.
+// Handlers are called in order from the goroutine calling Process, so they
+// should return promptly.
+func (et *ProtectEventTracker) SetEventEndedHandler(handler func(*EventEnded)) {
+	et.handlerMutex.Lock()
+	defer et.handlerMutex.Unlock()
+
+	et.eventEndedHandler = handler
+}
+
+// Process consumes the stream until it closes or the context is done.
+func (et *ProtectEventTracker) Process() {
+	ticker := time.NewTicker(max(et.timeout/10, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case streamEvent := <-et.stream:
+			if streamEvent == nil {
+				log.Warn("Got nil event. Bailing out!")
+				return
+			}
+			et.Track(streamEvent)
+		case now := <-ticker.C:
+			et.Expire(now)
+		case <-et.ctx.Done():
+			log.Warn("Got context.Done!")
+			return
+		}
+	}
+}
+
+// Track updates the tracker with a single event. Process calls Track for
+// every event on the stream; it is exported for callers feeding events from
+// another source.
+func (et *ProtectEventTracker) Track(streamEvent *types.ProtectEvent) {
+	item := streamEvent.EventItem()
+	if item == nil || item.ID == "" {
+		return
+	}
+
+	et.activeMutex.Lock()
+	if _, isFinished := et.finished[item.ID]; isFinished {
+		// A late update for an event which already ended.
+		et.activeMutex.Unlock()
+		return
+	}
+	started, isActive := et.active[item.ID]
+	if !isActive && !et.expiredAt.IsZero() && et.expiredAt.Sub(item.StartTime()) > et.timeout {
+		// An event which would already have been expired, so may have ended
+		// or expired and been forgotten. Starting it now would only repeat
+		// its start and end.
+		et.activeMutex.Unlock()
+		return
+	}
+	if !isActive && item.Start != 0 {
+		started = &EventStarted{
+			ID:     item.ID,
+			Type:   streamEvent.ItemType,
+			Device: item.Device,
+			Start:  item.StartTime(),
+			Event:  streamEvent.Item,
+		}
+	}
+	if started == nil {
+		// An update for an event we never saw start, and which doesn't say
+		// when it started either. Nothing useful can be done with it.
+		et.activeMutex.Unlock()
+		return
+	}
+
+	if item.End == 0 {
+		updated := *started
+		updated.Event = streamEvent.Item
+		et.active[item.ID] = &updated
+		et.activeMutex.Unlock()
+
+		if !isActive {
+			et.invokeEventStartedHandler(started)
+		}
+		return
+	}
+
+	end := item.EndTime()
+	delete(et.active, item.ID)
+	et.finished[item.ID] = end
+	et.activeMutex.Unlock()
+
+	if !isActive {
+		et.invokeEventStartedHandler(started)
+	}
+
+	et.invokeEventEndedHandler(&EventEnded{
+		ID:       started.ID,
+		Type:     started.Type,
+		Device:   started.Device,
+		Start:    started.Start,
+		End:      end,
+		Duration: end.Sub(started.Start),
+		Event:    streamEvent.Item,
+	})
+}
+
+// Expire ends every active event which started more than the tracker's
+// timeout before now, and forgets events which finished more than the timeout
+// before now. Updates for events which started more than the timeout before
+// the last Expire, and aren't active, are then ignored. Process calls Expire
+// periodically.
+func (et *ProtectEventTracker) Expire(now time.Time) {
+	expired := []*EventStarted{}
+
+	et.activeMutex.Lock()
+	et.expiredAt = now
+	for id, started := range et.active {
+		if now.Sub(started.Start) > et.timeout {
+			expired = append(expired, started)
+			delete(et.active, id)
+			et.finished[id] = now
+		}
+	}
+	for id, finished := range et.finished {
+		if now.Sub(finished) > et.timeout {
+			delete(et.finished, id)
+		}
+	}
+	et.activeMutex.Unlock()
+
+	for _, started := range expired {
+		log.WithFields(log.Fields{
+			"ID":         started.ID,
+			"event.type": started.Type,
+		}).Warn("Event never ended, expiring it")
+
+		et.invokeEventEndedHandler(&EventEnded{
+			ID:       started.ID,
+			Type:     started.Type,
+			Device:   started.Device,
+			Start:    started.Start,
+			End:      now,
+			Duration: now.Sub(started.Start),
+			TimedOut: true,
+			Event:    started.Event,
+		})
+	}
+}
+
+// Active returns the events currently in progress, keyed by device ID.
+func (et *ProtectEventTracker) Active() map[string][]*EventStarted {
+	et.activeMutex.Lock()
+	defer et.activeMutex.Unlock()
+
+	byDevice := map[string][]*EventStarted{}
+	for _, started := range et.active {
+		active := *started
+		byDevice[started.Device] = append(byDevice[started.Device], &active)
+	}
+	return byDevice
+}
+
+// ActiveForDevice returns the events currently in progress on a device, such
+// as a camera.
+func (et *ProtectEventTracker) ActiveForDevice(device string) []*EventStarted {
+	return et.Active()[device]
+}
+
+// Handlers are called without holding handlerMutex, so they may set handlers.
+func (et *ProtectEventTracker) invokeEventStartedHandler(event *EventStarted) {
+	et.handlerMutex.Lock()
+	handler := et.eventStartedHandler
+	et.handlerMutex.Unlock()
+
+	if handler != nil {
+		handler(event)
+	}
+}
+
+func (et *ProtectEventTracker) invokeEventEndedHandler(event *EventEnded) {
+	et.handlerMutex.Lock()
+	handler := et.eventEndedHandler
+	et.handlerMutex.Unlock()
+
+	if handler != nil {
+		handler(event)
+	}
+}
@@ -0,0 +1,152 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+func protectEvent(t *testing.T, data string) *types.ProtectEvent {
+	var event types.ProtectEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	return &event
+}
+
+func TestProtectEventTrackerPairsStartAndEnd(t *testing.T) {
+	tracker := client.NewProtectEventTracker(context.Background(), nil, time.Minute)
+
+	var started []*client.EventStarted
+	var ended []*client.EventEnded
+	tracker.SetEventStartedHandler(func(event *client.EventStarted) {
+		started = append(started, event)
+	})
+	tracker.SetEventEndedHandler(func(event *client.EventEnded) {
+		ended = append(ended, event)
+	})
+
+	tracker.Track(protectEvent(t, `{"type": "add", "item": {"id": "e1", "modelKey": "event",
+		"type": "smartDetectZone", "start": 1700000000000, "device": "cam1",
+		"smartDetectTypes": ["person"]}}`))
+
+	require.Len(t, started, 1)
+	assert.Empty(t, ended)
+	assert.Equal(t, "smartDetectZone", started[0].Type)
+	assert.Equal(t, time.UnixMilli(1700000000000), started[0].Start)
+	require.Len(t, tracker.ActiveForDevice("cam1"), 1)
+
+	tracker.Track(protectEvent(t, `{"type": "update", "item": {"id": "e1", "modelKey": "event",
+		"type": "smartDetectZone", "start": 1700000000000, "end": 1700000012500,
+		"smartDetectTypes": ["person", "vehicle"]}}`))
+
+	require.Len(t, started, 1)
+	require.Len(t, ended, 1)
+	assert.Equal(t, "cam1", ended[0].Device)
+	assert.Equal(t, 12500*time.Millisecond, ended[0].Duration)
+	assert.False(t, ended[0].TimedOut)
+	event, ok := ended[0].Event.(*types.CameraSmartDetectZoneEvent)
+	require.True(t, ok)
+	assert.Equal(t, []string{"person", "vehicle"}, event.SmartDetectTypes)
+	assert.Empty(t, tracker.ActiveForDevice("cam1"))
+}
+
+func TestProtectEventTrackerExpiresEventsWhichNeverEnd(t *testing.T) {
+	tracker := client.NewProtectEventTracker(context.Background(), nil, time.Minute)
+
+	var ended []*client.EventEnded
+	tracker.SetEventEndedHandler(func(event *client.EventEnded) {
+		ended = append(ended, event)
+	})
+
+	tracker.Track(protectEvent(t, `{"type": "add", "item": {"id": "e2", "modelKey": "event",
+		"type": "motion", "start": 1700000000000, "device": "cam1"}}`))
+
+	start := time.UnixMilli(1700000000000)
+	tracker.Expire(start.Add(time.Second * 30))
+	assert.Empty(t, ended)
+
+	tracker.Expire(start.Add(time.Minute * 2))
+	require.Len(t, ended, 1)
+	assert.True(t, ended[0].TimedOut)
+	assert.Equal(t, time.Minute*2, ended[0].Duration)
+	assert.Empty(t, tracker.Active())
+}
+
+func TestProtectEventTrackerIgnoresUpdatesAfterEnd(t *testing.T) {
+	tracker := client.NewProtectEventTracker(context.Background(), nil, time.Minute)
+
+	var started []*client.EventStarted
+	var ended []*client.EventEnded
+	tracker.SetEventStartedHandler(func(event *client.EventStarted) {
+		started = append(started, event)
+	})
+	tracker.SetEventEndedHandler(func(event *client.EventEnded) {
+		ended = append(ended, event)
+	})
+
+	end := `{"type": "update", "item": {"id": "e3", "modelKey": "event", "type": "smartDetectZone",
+		"start": 1700000000000, "end": 1700000005000, "device": "cam1", "smartDetectTypes": ["person"]}}`
+	tracker.Track(protectEvent(t, end))
+	tracker.Track(protectEvent(t, end))
+	tracker.Track(protectEvent(t, `{"type": "update", "item": {"id": "e3", "modelKey": "event",
+		"type": "smartDetectZone", "start": 1700000000000, "device": "cam1",
+		"smartDetectTypes": ["person", "vehicle"]}}`))
+
+	assert.Len(t, started, 1)
+	assert.Len(t, ended, 1)
+	assert.Empty(t, tracker.Active())
+}
+
+func TestProtectEventTrackerIgnoresUpdatesAfterExpiry(t *testing.T) {
+	tracker := client.NewProtectEventTracker(context.Background(), nil, time.Minute)
+
+	var started []*client.EventStarted
+	var ended []*client.EventEnded
+	tracker.SetEventStartedHandler(func(event *client.EventStarted) {
+		started = append(started, event)
+	})
+	tracker.SetEventEndedHandler(func(event *client.EventEnded) {
+		ended = append(ended, event)
+	})
+
+	tracker.Track(protectEvent(t, `{"type": "add", "item": {"id": "e4", "modelKey": "event",
+		"type": "motion", "start": 1700000000000, "device": "cam1"}}`))
+	start := time.UnixMilli(1700000000000)
+	tracker.Expire(start.Add(time.Minute * 2))
+	require.Len(t, ended, 1)
+
+	late := `{"type": "update", "item": {"id": "e4", "modelKey": "event",
+		"type": "motion", "start": 1700000000000, "end": 1700000130000, "device": "cam1"}}`
+	tracker.Track(protectEvent(t, late))
+	assert.Len(t, started, 1)
+	assert.Len(t, ended, 1)
+
+	// Once the event is forgotten, updates for it are still ignored, as it
+	// started too long ago.
+	tracker.Expire(start.Add(time.Minute * 4))
+	tracker.Track(protectEvent(t, late))
+	assert.Len(t, started, 1)
+	assert.Len(t, ended, 1)
+	assert.Empty(t, tracker.Active())
+}
+
+func TestProtectEventTrackerHandlersMaySetHandlers(t *testing.T) {
+	tracker := client.NewProtectEventTracker(context.Background(), nil, time.Minute)
+
+	ended := 0
+	tracker.SetEventStartedHandler(func(_ *client.EventStarted) {
+		tracker.SetEventEndedHandler(func(_ *client.EventEnded) {
+			ended++
+		})
+	})
+
+	tracker.Track(protectEvent(t, `{"type": "update", "item": {"id": "e5", "modelKey": "event",
+		"type": "motion", "start": 1700000000000, "end": 1700000005000, "device": "cam1"}}`))
+	assert.Equal(t, 1, ended)
+}
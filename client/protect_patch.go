@@ -0,0 +1,28 @@
+package client
+
+import (
+	"github.com/ClifHouck/unified/types"
+)
+
+// ValidateCameraPatch fetches the camera and checks the patch request against
+// its FeatureFlags. Returns a *types.PatchValidationError listing every
+// unsupported field, or nil if Protect should accept the patch.
+func ValidateCameraPatch(protect types.ProtectV1, cameraID types.CameraID,
+	req *types.CameraPatchRequest) error {
+	camera, err := protect.CameraDetails(cameraID)
+	if err != nil {
+		return err
+	}
+	return req.ValidateFor(camera)
+}
+
+// ValidatedCameraPatch patches the camera only if the request passes
+// ValidateCameraPatch, rather than leaving Protect to reject it.
+func ValidatedCameraPatch(protect types.ProtectV1, cameraID types.CameraID,
+	req *types.CameraPatchRequest) (*types.Camera, error) {
+	err := ValidateCameraPatch(protect, cameraID, req)
+	if err != nil {
+		return nil, err
+	}
+	return protect.CameraPatch(cameraID, req)
+}
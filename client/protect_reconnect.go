@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// Reconnect records a Protect device going offline and coming back online.
+// Times are zero until that has been seen.
+type Reconnect struct {
+	DisconnectedAt time.Time `json:"disconnectedAt,omitzero"`
+	ConnectedAt    time.Time `json:"connectedAt,omitzero"`
+}
+
+// Disconnected returns true if the device was seen going offline.
+func (r *Reconnect) Disconnected() bool {
+	return !r.DisconnectedAt.IsZero()
+}
+
+// Reconnected returns true if the device was seen coming back online after
+// going offline.
+func (r *Reconnect) Reconnected() bool {
+	return r.Disconnected() && !r.ConnectedAt.IsZero()
+}
+
+// Downtime returns how long the device was offline, or zero if it hasn't
+// come back.
+func (r *Reconnect) Downtime() time.Duration {
+	if !r.Reconnected() {
+		return 0
+	}
+	return r.ConnectedAt.Sub(r.DisconnectedAt)
+}
+
+// WaitForReconnect reads device events, as streamed by SubscribeDeviceEvents,
+// until the device with the given ID goes offline and then comes back
+// online. Subscribe before doing whatever takes the device offline, so its
+// disconnection isn't missed. If ctx is done or the stream closes first,
+// what was seen so far is returned with an error.
+func WaitForReconnect(ctx context.Context, stream <-chan *types.ProtectDeviceEvent,
+	deviceID string) (*Reconnect, error) {
+	reconnect := &Reconnect{}
+	for {
+		select {
+		case streamEvent := <-stream:
+			if streamEvent == nil {
+				return reconnect, errors.New("device event stream closed")
+			}
+			item, ok := deviceEventItem(streamEvent)
+			if !ok || item.ID != deviceID || item.State == "" {
+				continue
+			}
+			if item.State != types.ProtectDeviceStateConnected {
+				if !reconnect.Disconnected() {
+					reconnect.DisconnectedAt = time.Now()
+				}
+				continue
+			}
+			if reconnect.Disconnected() {
+				reconnect.ConnectedAt = time.Now()
+				return reconnect, nil
+			}
+		case <-ctx.Done():
+			return reconnect, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+)
+
+// ANSI terminal colors used by human-readable output.
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
+)
+
+var noColor = false
+
+// Returns true if output to stdout should be colored: stdout is a terminal,
+// and neither --no-color nor the NO_COLOR environment variable is set.
+func useColor() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// Returns true if file is a terminal.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Wraps text in color, if colors are enabled.
+func colorize(enabled bool, color string, text string) string {
+	if !enabled || text == "" {
+		return text
+	}
+	return color + text + colorReset
+}
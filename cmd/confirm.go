@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var assumeYes = false
+var confirmFlagSet = pflag.NewFlagSet("confirm", pflag.ExitOnError)
+
+func init() {
+	confirmFlagSet.BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation")
+}
+
+// Asks the user to confirm an action on stdin. Returns true without asking if
+// --yes is set, and an error if stdin isn't a terminal to ask on.
+func confirm(prompt string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return false, errors.New("stdin is not a terminal, pass --yes to confirm")
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
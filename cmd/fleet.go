@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+var (
+	fleetControllers []string
+	fleetAllProfiles bool
+	fleetTimeout     time.Duration
+	fleetFlagSet     = pflag.NewFlagSet("fleet", pflag.ExitOnError)
+)
+
+// A console in the profiles config setting.
+type profile struct {
+	Host     string `mapstructure:"host"`
+	APIKey   string `mapstructure:"apiKey"`
+	Insecure *bool  `mapstructure:"insecure"`
+}
+
+func init() {
+	fleetFlagSet.StringSliceVar(&fleetControllers, "controllers", nil,
+		"Query these profiles, by name, at once and merge their results")
+	fleetFlagSet.BoolVar(&fleetAllProfiles, "all-profiles", false,
+		"Query every profile at once and merge their results")
+	fleetFlagSet.DurationVar(&fleetTimeout, "controller-timeout", time.Second*30,
+		"How long each profile's controller has to answer with --controllers or --all-profiles")
+
+	for _, command := range []*cobra.Command{
+		listSitesCmd, listDevicesCmd, listClientsCmd, listVouchersCmd,
+		cameraListCmd, viewerListCmd, liveViewListCmd, lightListCmd, chimeListCmd, sensorListCmd,
+	} {
+		command.Flags().AddFlagSet(fleetFlagSet)
+	}
+}
+
+// Returns true if a list command should query the fleet of profiles, rather
+// than just --host.
+func fleetMode() bool {
+	return fleetAllProfiles || len(fleetControllers) > 0
+}
+
+// Reads the profiles config setting, e.g.:
+//
+//	profiles:
+//	  hq:
+//	    host: unifi-hq.example.com
+//	    apiKey: ...
+//	  branch:
+//	    host: 10.1.0.1
+//	    apiKey: ...
+//	    insecure: false
+//
+// Profiles without insecure use --insecure. Viper lower cases the names.
+func readProfiles() (map[string]*profile, error) {
+	profiles := map[string]*profile{}
+	err := viper.UnmarshalKey("profiles", &profiles)
+	if err != nil {
+		return nil, fmt.Errorf("profiles config setting: %w", err)
+	}
+	if len(profiles) == 0 {
+		return nil, errors.New("there are no profiles in the config file")
+	}
+	return profiles, nil
+}
+
+// Returns a fleet of the profiles named by --controllers, or every profile
+// with --all-profiles.
+func getFleet() (*client.Fleet, error) {
+	profiles, err := readProfiles()
+	if err != nil {
+		return nil, err
+	}
+	names := slices.Sorted(maps.Keys(profiles))
+	if !fleetAllProfiles {
+		names = []string{}
+		for _, name := range fleetControllers {
+			name = strings.ToLower(name)
+			if _, ok := profiles[name]; !ok {
+				return nil, fmt.Errorf("no profile '%s', choose from: %s", name,
+					strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+			}
+			names = append(names, name)
+		}
+	}
+
+	fleet := &client.Fleet{Controllers: []*client.Controller{}, Timeout: fleetTimeout}
+	for _, name := range names {
+		profile := profiles[name]
+		config := &client.Config{
+			Hostname:                   cmp.Or(profile.Host, name),
+			APIKey:                     profile.APIKey,
+			WebSocketKeepAliveInterval: keepAliveInterval,
+			InsecureSkipVerify:         insecureSkipVerify,
+		}
+		if profile.Insecure != nil {
+			config.InsecureSkipVerify = *profile.Insecure
+		}
+		ok, reasons := config.IsValid()
+		if !ok {
+			return nil, fmt.Errorf("profile '%s' is invalid: %s", name, strings.Join(reasons, ", "))
+		}
+		fleet.Controllers = append(fleet.Controllers, &client.Controller{
+			Name:   name,
+			Client: client.NewClient(ctx, config, log),
+		})
+	}
+	return fleet, nil
+}
+
+// Requires a site ID, except in fleet mode, where site IDs differ between
+// controllers. There the site is optional, and matched by name or ID.
+func siteArgs(cmd *cobra.Command, args []string) error {
+	if fleetMode() {
+		return cobra.MaximumNArgs(1)(cmd, args)
+	}
+	return cobra.ExactArgs(1)(cmd, args)
+}
+
+// Prints items merged from a fleet, unless every controller failed, and
+// reports the controllers which failed after.
+func printFleetItems[T any](items []*client.Tagged[T], err error, id func(T) string) {
+	var fleetErr *client.FleetError
+	if err != nil && !errors.As(err, &fleetErr) {
+		log.Error(err.Error())
+		return
+	}
+
+	if fleetErr == nil || len(fleetErr.Errors) < fleetErr.Controllers {
+		printTaggedItems(items, id)
+	}
+	if fleetErr != nil {
+		for _, controllerErr := range fleetErr.Errors {
+			log.WithField("controller", controllerErr.Controller).Error(controllerErr.Err.Error())
+		}
+		log.Errorf("%d of %d controllers failed", len(fleetErr.Errors), fleetErr.Controllers)
+	}
+}
+
+// Prints items as JSON, or with --id-only as a line of controller and ID each.
+func printTaggedItems[T any](items []*client.Tagged[T], id func(T) string) {
+	if idOnly {
+		for _, item := range items {
+			fmt.Printf("%s\t%s\n", item.Controller, id(item.Item))
+		}
+		return
+	}
+	err := marshalAndPrintJSON(items)
+	if err != nil {
+		log.Error(err.Error())
+	}
+}
+
+// Lists items from every controller of the fleet.
+func listFleet[T any](query func(c *client.Client) ([]T, error), id func(T) string) {
+	fleet, err := getFleet()
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+	items, err := client.QueryFleet(fleet, query)
+	printFleetItems(items, err, id)
+}
+
+// Lists items from the site given by args, or every site, of every
+// controller of the fleet.
+func listFleetSites[T any](args []string, query func(c *client.Client, siteID types.SiteID) ([]T, error),
+	id func(T) string) {
+	fleet, err := getFleet()
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+	items, err := client.QueryFleetSites(fleet, args, query)
+	printFleetItems(items, err, id)
+}
@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/ClifHouck/unified/client"
 	"github.com/ClifHouck/unified/types"
 )
 
@@ -19,6 +21,22 @@ var pageFlagSet = pflag.NewFlagSet("page", pflag.ExitOnError)
 
 var voucherGenerateReq = &types.VoucherGenerateRequest{}
 
+// Built when declared rather than in init, as 'vouchers print' also adds it to
+// its command, and its file's init function may run before this file's.
+var voucherGenerateFlagSet = newVoucherGenerateFlagSet()
+
+// Flags of the vouchers to generate, except how many.
+func newVoucherGenerateFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("voucherGenerate", pflag.ExitOnError)
+	flags.StringVar(&voucherGenerateReq.Name, "name", "", "Name of vouchers")
+	flags.IntVar(&voucherGenerateReq.AuthorizedGuestLimit, "guest-limit", 0, "Authorized guest limit")
+	flags.IntVar(&voucherGenerateReq.TimeLimitMinutes, "time-limit", 0, "Time limit in minutes")
+	flags.IntVar(&voucherGenerateReq.DataUsageLimitMBytes, "data-limit", 0, "Data limit in megabytes")
+	flags.IntVar(&voucherGenerateReq.RxRateLimitKbps, "rx-limit", 0, "Recieve rate limit in kilobytes")
+	flags.IntVar(&voucherGenerateReq.TxRateLimitKbps, "tx-limit", 0, "Transmit rate limit in kilobytes")
+	return flags
+}
+
 func init() {
 	filterFlagSet.StringVar(&filter, "filter", "", "Filter results based on expression")
 
@@ -75,17 +93,7 @@ func init() {
 	vouchersCmd.AddCommand(voucherDetailsCmd)
 
 	voucherGenerateCmd.Flags().IntVar(&voucherGenerateReq.Count, "count", 0, "Number of vouchers")
-	voucherGenerateCmd.Flags().StringVar(&voucherGenerateReq.Name, "name", "", "Name of vouchers")
-	voucherGenerateCmd.Flags().
-		IntVar(&voucherGenerateReq.AuthorizedGuestLimit, "guest-limit", 0, "Authorized guest limit")
-	voucherGenerateCmd.Flags().
-		IntVar(&voucherGenerateReq.TimeLimitMinutes, "time-limit", 0, "Time limit in minutes")
-	voucherGenerateCmd.Flags().
-		IntVar(&voucherGenerateReq.DataUsageLimitMBytes, "data-limit", 0, "Data limit in megabytes")
-	voucherGenerateCmd.Flags().
-		IntVar(&voucherGenerateReq.RxRateLimitKbps, "rx-limit", 0, "Recieve rate limit in kilobytes")
-	voucherGenerateCmd.Flags().
-		IntVar(&voucherGenerateReq.TxRateLimitKbps, "tx-limit", 0, "Transmit rate limit in kilobytes")
+	voucherGenerateCmd.Flags().AddFlagSet(voucherGenerateFlagSet)
 	vouchersCmd.AddCommand(voucherGenerateCmd)
 
 	vouchersCmd.AddCommand(voucherDeleteCmd)
@@ -94,6 +102,37 @@ func init() {
 	vouchersCmd.AddCommand(voucherDeleteByFilterCmd)
 }
 
+// Returns the site ID given by --site, or the only site if there's just one.
+func resolveSiteID(c *client.Client, site string) (types.SiteID, error) {
+	if site != "" {
+		return types.SiteID(site), nil
+	}
+	sites, err := client.AllSites(c.Network, "")
+	if err != nil {
+		return "", err
+	}
+	if len(sites) != 1 {
+		return "", fmt.Errorf("there are %d sites, pick one with --site", len(sites))
+	}
+	return types.SiteID(sites[0].ID), nil
+}
+
+// Returns the sites with the given names or IDs, or every site if none are
+// given.
+func selectSites(c *client.Client, requested []string) ([]*types.Site, error) {
+	sites, err := client.AllSites(c.Network, "")
+	if err != nil {
+		return nil, err
+	}
+	sites = slices.DeleteFunc(sites, func(site *types.Site) bool {
+		return !matchesAny(requested, site.ID, site.Name)
+	})
+	if len(sites) == 0 {
+		return nil, fmt.Errorf("no sites match %s", strings.Join(requested, ", "))
+	}
+	return sites, nil
+}
+
 var networkCmd = &cobra.Command{
 	Use:   "network",
 	Short: "Make UniFi Network API calls",
@@ -148,8 +187,14 @@ var listDevicesCmd = &cobra.Command{
 	Short: "List all adopted UniFi Network devices by a specific site",
 	Long: `Calls the devices UniFi Network API endpoint for a specific site ID
 and prints the results to stdout.`,
-	Args: cobra.ExactArgs(1),
+	Args: siteArgs,
 	Run: func(_ *cobra.Command, args []string) {
+		if fleetMode() {
+			listFleetSites(args, func(c *client.Client, siteID types.SiteID) ([]*types.DeviceListEntry, error) {
+				return client.AllDevices(c.Network, siteID)
+			}, func(device *types.DeviceListEntry) string { return device.ID })
+			return
+		}
 		c := getClient()
 		devices, page, err := c.Network.Devices(types.SiteID(args[0]), pageArgs)
 		if err != nil {
@@ -236,13 +281,16 @@ memory utilization, uptime, uplink tx/rx rates etc`,
 var actionDevicesCmd = &cobra.Command{
 	Use:   "action [site ID] [device ID] [action]",
 	Short: "Execute an action on a specific adopted device",
-	Args:  cobra.ExactArgs(3),
+	Long: `Execute an action, e.g. RESTART, on a specific adopted device. See also
+the restart command.`,
+	Args: cobra.ExactArgs(3),
 	Run: func(_ *cobra.Command, args []string) {
 		c := getClient()
 
 		action := &types.DeviceActionRequest{
-			Action: args[2],
+			Action: types.DeviceAction(args[2]),
 		}
+		warnUnknownAction(action.Validate())
 
 		err := c.Network.DeviceExecuteAction(types.SiteID(args[0]), types.DeviceID(args[1]), action)
 		if err != nil {
@@ -256,15 +304,18 @@ var actionDevicesCmd = &cobra.Command{
 var actionDevicePortCmd = &cobra.Command{
 	Use:   "port-action [site ID] [device ID] [portIdx] [action]",
 	Short: "Execute an action on a specific adopted device's port",
-	Args:  cobra.ExactArgs(4),
+	Long: `Execute an action, e.g. POWER_CYCLE, on a specific adopted device's port.
+See also the power-cycle command.`,
+	Args: cobra.ExactArgs(4),
 	Run: func(_ *cobra.Command, args []string) {
 		c := getClient()
 
 		action := &types.DevicePortActionRequest{
-			Action: args[3],
+			Action: types.PortAction(args[3]),
 		}
+		warnUnknownAction(action.Validate())
 
-		port, err := strconv.Atoi(args[2])
+		port, err := parsePortIdx(args[2])
 		if err != nil {
 			log.Error(err.Error())
 			return
@@ -272,7 +323,7 @@ var actionDevicePortCmd = &cobra.Command{
 
 		err = c.Network.DevicePortExecuteAction(types.SiteID(args[0]),
 			types.DeviceID(args[1]),
-			types.PortIdx(port),
+			port,
 			action)
 		if err != nil {
 			log.Error(err.Error())
@@ -289,6 +340,12 @@ var listSitesCmd = &cobra.Command{
 Setups using Multi-Site option enabled will return all created sites,
 while if option is disabled it will return just the default site.`,
 	Run: func(_ *cobra.Command, _ []string) {
+		if fleetMode() {
+			listFleet(func(c *client.Client) ([]*types.Site, error) {
+				return client.AllSites(c.Network, types.Filter(filter))
+			}, func(site *types.Site) string { return site.ID })
+			return
+		}
 		c := getClient()
 		sites, page, err := c.Network.Sites(types.Filter(filter), pageArgs)
 		if err != nil {
@@ -331,9 +388,24 @@ var listClientsCmd = &cobra.Command{
 	Short: "List connected clients of a site",
 	Long: `List connected clients of a site (paginated). Clients are either
 physical devices (computers, smartphones, connected by wire or wirelessly),
-or active VPN connections.`,
-	Args: cobra.ExactArgs(1),
+or active VPN connections.
+
+--output table shows one client per line, with the vendor of its network
+interface and the name of the switch or access point it is connected to.`,
+	Args: siteArgs,
 	Run: func(_ *cobra.Command, args []string) {
+		err := validateOutputFormat()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		if fleetMode() {
+			listFleetSites(args, func(c *client.Client, siteID types.SiteID) ([]*types.Client, error) {
+				return client.AllClients(c.Network, siteID, types.Filter(filter))
+			}, func(c *types.Client) string { return c.ID })
+			return
+		}
 		c := getClient()
 		clients, page, err := c.Network.Clients(
 			types.SiteID(args[0]),
@@ -343,6 +415,13 @@ or active VPN connections.`,
 			log.Error(err.Error())
 			return
 		}
+		if outputFormat == outputTable && !idOnly {
+			err = printClientsTable(c, types.SiteID(args[0]), clients)
+			if err != nil {
+				log.Error(err.Error())
+			}
+			return
+		}
 		if idOnly {
 			for _, client := range clients {
 				fmt.Println(client.ID)
@@ -395,13 +474,17 @@ var clientDetailsCmd = &cobra.Command{
 var actionClientCmd = &cobra.Command{
 	Use:   "action [site ID] [client ID] [action]",
 	Short: "Execute an action on a specific client",
-	Args:  cobra.ExactArgs(3),
+	Long: `Execute an action, e.g. UNAUTHORIZE_GUEST_ACCESS, on a specific client.
+See also the authorize-guest and unauthorize-guest commands, which can set
+guest access limits.`,
+	Args: cobra.ExactArgs(3),
 	Run: func(_ *cobra.Command, args []string) {
 		c := getClient()
 
 		action := &types.ClientActionRequest{
-			Action: args[2],
+			Action: types.ClientAction(args[2]),
 		}
+		warnUnknownAction(action.Validate())
 
 		err := c.Network.ClientExecuteAction(
 			types.SiteID(args[0]), types.ClientID(args[1]), action)
@@ -416,8 +499,14 @@ var actionClientCmd = &cobra.Command{
 var listVouchersCmd = &cobra.Command{
 	Use:   "list [site ID]",
 	Short: "List hotspot vouchers of a site",
-	Args:  cobra.ExactArgs(1),
+	Args:  siteArgs,
 	Run: func(_ *cobra.Command, args []string) {
+		if fleetMode() {
+			listFleetSites(args, func(c *client.Client, siteID types.SiteID) ([]*types.Voucher, error) {
+				return client.AllVouchers(c.Network, siteID, types.Filter(filter))
+			}, func(voucher *types.Voucher) string { return voucher.ID })
+			return
+		}
 		c := getClient()
 		vouchers, page, err := c.Network.Vouchers(types.SiteID(args[0]),
 			types.Filter(filter), pageArgs)
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+var guestAccessLimits = types.GuestAccessLimits{}
+
+func init() {
+	devicesCmd.AddCommand(restartDeviceCmd)
+	devicesCmd.AddCommand(powerCyclePortCmd)
+
+	authorizeGuestCmd.Flags().
+		IntVar(&guestAccessLimits.TimeLimitMinutes, "time-limit", 0, "Time limit in minutes")
+	authorizeGuestCmd.Flags().
+		IntVar(&guestAccessLimits.DataUsageLimitMBytes, "data-limit", 0, "Data limit in megabytes")
+	authorizeGuestCmd.Flags().
+		IntVar(&guestAccessLimits.RxRateLimitKbps, "rx-limit", 0, "Receive rate limit in kilobits per second")
+	authorizeGuestCmd.Flags().
+		IntVar(&guestAccessLimits.TxRateLimitKbps, "tx-limit", 0, "Transmit rate limit in kilobits per second")
+	clientsCmd.AddCommand(authorizeGuestCmd)
+	clientsCmd.AddCommand(unauthorizeGuestCmd)
+}
+
+var restartDeviceCmd = &cobra.Command{
+	Use:   "restart [site ID] [device ID]",
+	Short: "Restart an adopted device",
+	Args:  cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		c := getClient()
+		err := c.Network.DeviceExecuteAction(types.SiteID(args[0]), types.DeviceID(args[1]),
+			types.NewDeviceRestartRequest())
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Device restarting")
+	},
+}
+
+var powerCyclePortCmd = &cobra.Command{
+	Use:   "power-cycle [site ID] [device ID] [portIdx]",
+	Short: "Power cycle the PoE device connected to a port of an adopted device",
+	Args:  cobra.ExactArgs(3),
+	Run: func(_ *cobra.Command, args []string) {
+		port, err := parsePortIdx(args[2])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		err = c.Network.DevicePortExecuteAction(types.SiteID(args[0]), types.DeviceID(args[1]),
+			port, types.NewPortPowerCycleRequest())
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Port power cycling")
+	},
+}
+
+var authorizeGuestCmd = &cobra.Command{
+	Use:   "authorize-guest [site ID] [client ID]",
+	Short: "Authorize a client on the guest network",
+	Long: `Authorize a client on the guest network. Limits which are not given are
+left unset, i.e. unlimited or the site's default.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		action := types.NewAuthorizeGuestAccessRequest(guestAccessLimits)
+		err := action.Validate()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		err = c.Network.ClientExecuteAction(types.SiteID(args[0]), types.ClientID(args[1]), action)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Guest authorized")
+	},
+}
+
+var unauthorizeGuestCmd = &cobra.Command{
+	Use:   "unauthorize-guest [site ID] [client ID]",
+	Short: "Revoke a client's guest network authorization",
+	Args:  cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		c := getClient()
+		err := c.Network.ClientExecuteAction(types.SiteID(args[0]), types.ClientID(args[1]),
+			types.NewUnauthorizeGuestAccessRequest())
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Info("Guest unauthorized")
+	},
+}
+
+// Parses a port index argument.
+func parsePortIdx(text string) (types.PortIdx, error) {
+	port, err := strconv.ParseUint(text, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return types.PortIdx(port), nil
+}
+
+// Actions unified doesn't know about are still sent, in case the controller
+// is newer, but with a warning.
+func warnUnknownAction(err error) {
+	if err != nil {
+		log.Warn(err.Error())
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ClifHouck/unified/audit"
+	"github.com/ClifHouck/unified/types"
+)
+
+var (
+	auditSites       []string
+	auditSuppress    []string
+	auditMinSeverity string
+	auditOptions     = audit.DefaultOptions()
+)
+
+func init() {
+	flags := auditCmd.Flags()
+	flags.StringSliceVar(&auditSites, "site", nil,
+		"Only these sites, by name or ID. Defaults to every site")
+	flags.StringSliceVar(&auditSuppress, "suppress", nil,
+		"IDs of rules not to check, in addition to the auditSuppress config setting")
+	flags.StringVar(&auditMinSeverity, "min-severity", string(audit.SeverityInfo),
+		"Only report findings at least this severe, one of: "+strings.Join(types.EnumStrings(audit.AllSeverities), ", "))
+	flags.DurationVar(&auditOptions.ProvisionedWithin, "provisioned-within", auditOptions.ProvisionedWithin,
+		"Report devices which haven't been provisioned for longer than this")
+	flags.DurationVar(&auditOptions.LongConnectedAfter, "long-connected-after", auditOptions.LongConnectedAfter,
+		"Report clients which have been connected for longer than this")
+	flags.AddFlagSet(outputFlagSet)
+	networkCmd.AddCommand(auditCmd)
+
+	auditRulesCmd.Flags().AddFlagSet(outputFlagSet)
+	auditCmd.AddCommand(auditRulesCmd)
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check sites for IP conflicts and hygiene issues",
+	Long: `Check the devices, clients and vouchers of every site against audit rules:
+duplicate IP addresses, unnamed clients, unsupported devices, devices which
+haven't been provisioned recently, expired vouchers which haven't been deleted
+and clients which have stayed connected for a long time.
+
+Each rule has an ID and a severity. List them with 'audit rules'. Rules can be
+suppressed with --suppress, or for good with the auditSuppress config setting,
+e.g.:
+
+  auditSuppress:
+    - unnamed-client
+    - long-connected-client`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		err := validateOutputFormat()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		err = validateChoices("severity", []string{auditMinSeverity}, types.EnumStrings(audit.AllSeverities))
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		suppress := slices.Concat(auditSuppress, viper.GetStringSlice("auditSuppress"))
+		err = validateChoices("rule", suppress, audit.RuleIDs())
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		sites, err := selectSites(c, auditSites)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		inventories := []*audit.Inventory{}
+		for _, site := range sites {
+			inventory, err := audit.Fetch(c.Network, site)
+			if err != nil {
+				log.Error(fmt.Sprintf("site '%s': %s", site.Name, err.Error()))
+				return
+			}
+			inventories = append(inventories, inventory)
+		}
+
+		findings := audit.Run(inventories, &auditOptions, suppress, audit.Severity(auditMinSeverity))
+		if outputFormat == outputJSON {
+			err = marshalAndPrintJSON(findings)
+			if err != nil {
+				log.Error(err.Error())
+			}
+			return
+		}
+
+		table := newTableWriter()
+		writeTableRow(table, "SEVERITY", "SITE", "RULE", "KIND", "NAME", "MESSAGE")
+		for _, finding := range findings {
+			writeTableRow(table, finding.Severity, finding.Site, finding.Rule, finding.Kind,
+				finding.Name, finding.Message)
+		}
+		err = table.Flush()
+		if err != nil {
+			log.Error(err.Error())
+		}
+	},
+}
+
+var auditRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "List the audit rules",
+	Args:  cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		err := validateOutputFormat()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if outputFormat == outputJSON {
+			err = marshalAndPrintJSON(audit.Rules)
+			if err != nil {
+				log.Error(err.Error())
+			}
+			return
+		}
+
+		table := newTableWriter()
+		writeTableRow(table, "ID", "SEVERITY", "DESCRIPTION")
+		for _, rule := range audit.Rules {
+			writeTableRow(table, rule.ID, rule.Severity, rule.Description)
+		}
+		err = table.Flush()
+		if err != nil {
+			log.Error(err.Error())
+		}
+	},
+}
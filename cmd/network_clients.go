@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/oui"
+	"github.com/ClifHouck/unified/types"
+)
+
+var ouiFile string
+
+func init() {
+	listClientsCmd.Flags().AddFlagSet(outputFlagSet)
+	listClientsCmd.Flags().StringVar(&ouiFile, "oui-file", "",
+		"IEEE oui.txt or Wireshark manuf file used to look up vendors for --output table, "+
+			"in addition to the built-in table of common vendors")
+}
+
+// Returns the built-in OUI database, extended with --oui-file if given.
+func ouiDatabase() (*oui.Database, error) {
+	db := oui.Builtin()
+	if ouiFile == "" {
+		return db, nil
+	}
+	extra, err := oui.Load(ouiFile)
+	if err != nil {
+		return nil, err
+	}
+	return db.Merge(extra), nil
+}
+
+// Describes a client's network access for --output table, e.g. "GUEST
+// (authorized)".
+func clientAccess(networkClient *types.Client) string {
+	switch {
+	case networkClient.IsAuthorizedGuest():
+		return "GUEST (authorized)"
+	case networkClient.IsGuest():
+		return "GUEST (unauthorized)"
+	default:
+		return networkClient.Access.Type.String()
+	}
+}
+
+// Prints clients as a table, with vendors looked up from their MAC addresses
+// and uplinks resolved to device names.
+func printClientsTable(c *client.Client, siteID types.SiteID, clients []*types.Client) error {
+	vendors, err := ouiDatabase()
+	if err != nil {
+		return err
+	}
+
+	devices, err := client.AllDevices(c.Network, siteID)
+	if err != nil {
+		return err
+	}
+	deviceNames := map[string]string{}
+	for _, device := range devices {
+		deviceNames[device.ID] = device.Name
+	}
+
+	table := newTableWriter()
+	writeTableRow(table, "NAME", "IP ADDRESS", "MAC ADDRESS", "VENDOR", "TYPE", "ACCESS", "UPLINK", "CONNECTED")
+	for _, networkClient := range clients {
+		vendor, _ := vendors.Lookup(networkClient.MacAddress)
+		uplink, ok := deviceNames[networkClient.UplinkDeviceID]
+		if !ok {
+			uplink = networkClient.UplinkDeviceID
+		}
+		connected := ""
+		if !networkClient.ConnectedAt.IsZero() {
+			connected = networkClient.ConnectedAt.Local().Format(time.DateTime)
+		}
+		writeTableRow(table,
+			networkClient.Name,
+			networkClient.IPAddress,
+			networkClient.MacAddress,
+			vendor,
+			networkClient.Type,
+			clientAccess(networkClient),
+			uplink,
+			connected,
+		)
+	}
+	return table.Flush()
+}
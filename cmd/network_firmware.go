@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/firmware"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Exit codes of firmware report.
+const (
+	firmwareOutOfPolicy = 1
+	firmwareUnknown     = 2
+)
+
+var firmwareOutputFormats = []string{outputJSON, outputTable, outputCSV}
+
+var (
+	firmwareSites      []string
+	firmwarePolicyPath string
+)
+
+func init() {
+	networkCmd.AddCommand(firmwareCmd)
+
+	flags := firmwareReportCmd.Flags()
+	flags.StringSliceVar(&firmwareSites, "site", nil,
+		"Only these sites, by name or ID. Defaults to every site")
+	flags.StringVar(&firmwarePolicyPath, "policy", "",
+		"Policy file of minimum firmware versions by model")
+	flags.AddFlagSet(outputFlagSet)
+	firmwareCmd.AddCommand(firmwareReportCmd)
+}
+
+var firmwareCmd = &cobra.Command{
+	Use:   "firmware",
+	Short: "Report on the firmware of devices",
+}
+
+// Reads a policy file: a YAML, JSON or TOML file whose 'minimum' key maps
+// models to versions.
+func readFirmwarePolicy(path string) (firmware.Policy, error) {
+	config := viper.New()
+	config.SetConfigFile(path)
+	err := config.ReadInConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !config.IsSet("minimum") {
+		return nil, fmt.Errorf("policy '%s' has no 'minimum' versions", path)
+	}
+	return firmware.Policy(config.GetStringMapString("minimum")), nil
+}
+
+// Fetches the firmware of every device of the sites named by --site, or of
+// every site.
+func fetchFirmware(c *client.Client) ([]*firmware.Device, error) {
+	sites, err := selectSites(c, firmwareSites)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := []*firmware.Device{}
+	for _, site := range sites {
+		details, err := client.AllDeviceDetails(c.Network, types.SiteID(site.ID))
+		if err != nil {
+			return nil, fmt.Errorf("site '%s': %w", site.Name, err)
+		}
+		for _, device := range details {
+			devices = append(devices, firmware.NewDevice(site, device))
+		}
+	}
+	return devices, nil
+}
+
+// Describes what stands out about a group: versions below the policy minimum,
+// models running more than one version, and available updates.
+func firmwareGroupStatus(group *firmware.Group, color bool) string {
+	status := []string{}
+	if !group.Compliant {
+		status = append(status, colorize(color, colorRed, "below minimum"))
+	}
+	if !group.Uniform {
+		status = append(status, colorize(color, colorYellow, "mixed versions"))
+	}
+	if group.Updatable > 0 {
+		status = append(status, colorize(color, colorCyan, "updatable"))
+	}
+	if len(status) == 0 {
+		return colorize(color, colorGreen, "ok")
+	}
+	return strings.Join(status, ", ")
+}
+
+// Prints the groups, then the devices out of policy. Only the last column is
+// colored, so colors don't upset the alignment.
+func printFirmwareTable(report *firmware.Report) error {
+	color := useColor()
+	table := newTableWriter()
+	writeTableRow(table, "MODEL", "VERSION", "DEVICES", "UPDATABLE", "MINIMUM", "STATUS")
+	for _, group := range report.Groups {
+		writeTableRow(table, group.Model, group.Version, len(group.Devices), group.Updatable,
+			group.Minimum, firmwareGroupStatus(group, color))
+	}
+	err := table.Flush()
+	if err != nil || report.Compliant() {
+		return err
+	}
+
+	fmt.Printf("\n%d devices out of policy:\n", len(report.OutOfPolicy))
+	table = newTableWriter()
+	writeTableRow(table, "SITE", "DEVICE", "MODEL", "VERSION", "MINIMUM")
+	for _, device := range report.OutOfPolicy {
+		writeTableRow(table, device.Site, device.Name, device.Model, device.Version,
+			colorize(color, colorRed, device.Minimum))
+	}
+	return table.Flush()
+}
+
+// Prints a row for each device.
+func printFirmwareCSV(report *firmware.Report) error {
+	writer := csv.NewWriter(os.Stdout)
+	err := writer.Write([]string{
+		"site_id", "site", "device_id", "device", "model", "version", "updatable",
+		"uniform", "minimum", "compliant",
+	})
+	if err != nil {
+		return err
+	}
+	for _, group := range report.Groups {
+		for _, device := range group.Devices {
+			err = writer.Write([]string{
+				device.SiteID,
+				device.Site,
+				device.ID,
+				device.Name,
+				device.Model,
+				device.Version,
+				strconv.FormatBool(device.Updatable),
+				strconv.FormatBool(group.Uniform),
+				device.Minimum,
+				strconv.FormatBool(device.Compliant),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var firmwareReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report the firmware versions of every device, across sites",
+	Long: `Report the firmware versions of every device of every site, grouped by model
+and version, highlighting devices with updates available and models whose
+devices run different versions. The report is printed as JSON, or with
+--output table or csv.
+
+With --policy, devices are also checked against minimum versions by model. The
+policy is a YAML, JSON or TOML file, e.g.:
+
+  minimum:
+    U6-Pro: 6.6.77
+    USW-24-PoE: 7.1.26
+    "*": 6.0.0
+
+where "*" applies to every model not listed. Models are matched ignoring case.
+
+Exits 1 if any device is out of policy, and 2 if the report couldn't be made.`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		os.Exit(reportFirmware())
+	},
+}
+
+// Makes and prints the report, and returns the exit code.
+func reportFirmware() int {
+	err := validateOutputFormat(firmwareOutputFormats...)
+	if err != nil {
+		log.Error(err.Error())
+		return firmwareUnknown
+	}
+
+	var policy firmware.Policy
+	if firmwarePolicyPath != "" {
+		policy, err = readFirmwarePolicy(firmwarePolicyPath)
+		if err != nil {
+			log.Error(err.Error())
+			return firmwareUnknown
+		}
+	}
+
+	devices, err := fetchFirmware(getClient())
+	if err != nil {
+		log.Error(err.Error())
+		return firmwareUnknown
+	}
+	report := firmware.NewReport(devices, policy)
+
+	switch outputFormat {
+	case outputTable:
+		err = printFirmwareTable(report)
+	case outputJSON:
+		err = marshalAndPrintJSON(report)
+	case outputCSV:
+		err = printFirmwareCSV(report)
+	}
+	if err != nil {
+		log.Error(err.Error())
+		return firmwareUnknown
+	}
+
+	if !report.Compliant() {
+		return firmwareOutOfPolicy
+	}
+	return 0
+}
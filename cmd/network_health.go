@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ClifHouck/unified/health"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Exit codes of a Nagios plugin.
+const (
+	nagiosOK       = 0
+	nagiosCritical = 2
+	nagiosUnknown  = 3
+)
+
+var (
+	healthThresholds = health.DefaultThresholds
+	healthWatch      bool
+	healthInterval   time.Duration
+)
+
+func init() {
+	flags := networkHealthCmd.Flags()
+	flags.Float64Var(&healthThresholds.CPUPct, "cpu", healthThresholds.CPUPct,
+		"Alert when CPU utilization is above this percentage. 0 disables")
+	flags.Float64Var(&healthThresholds.MemoryPct, "memory", healthThresholds.MemoryPct,
+		"Alert when memory utilization is above this percentage. 0 disables")
+	flags.Float64Var(&healthThresholds.LoadAverage, "load", healthThresholds.LoadAverage,
+		"Alert when the 1 minute load average is above this. 0 disables")
+	flags.Float64Var(&healthThresholds.TxRetriesPct, "tx-retries", healthThresholds.TxRetriesPct,
+		"Alert when a radio retries more than this percentage of transmissions. 0 disables")
+	flags.Float64Var(&healthThresholds.UplinkPct, "uplink", healthThresholds.UplinkPct,
+		"Alert when uplink utilization is above this percentage of --uplink-capacity. 0 disables")
+	flags.Float64Var(&healthThresholds.UplinkCapacityMbps, "uplink-capacity", healthThresholds.UplinkCapacityMbps,
+		"Uplink capacity in megabits per second, which the API doesn't report")
+	flags.DurationVar(&healthThresholds.HeartbeatGrace, "heartbeat-grace", healthThresholds.HeartbeatGrace,
+		"Alert when a device's heartbeat is overdue by more than this")
+	flags.Float64Var(&healthThresholds.Hysteresis, "hysteresis", healthThresholds.Hysteresis,
+		"Fraction of a threshold a value must drop below it before its alert resolves")
+	flags.BoolVar(&healthWatch, "watch", false,
+		"Keep checking, printing alerts as they fire and resolve")
+	flags.DurationVar(&healthInterval, "interval", time.Minute,
+		"Time between checks with --watch")
+	networkCmd.AddCommand(networkHealthCmd)
+}
+
+var networkHealthCmd = &cobra.Command{
+	Use:   "health [site ID]",
+	Short: "Check a site's devices against alert thresholds",
+	Long: `Check a site's devices against alert thresholds: devices which aren't
+online, missed heartbeats, high CPU or memory utilization, high load, radios
+retrying too many transmissions and saturated uplinks.
+
+By default the site is checked once, and the result printed and returned as a
+Nagios plugin would: exit code 0 if every device is healthy, 2 if there are
+alerts and 3 if the site couldn't be checked.
+
+With --watch the site is checked every --interval, and each alert is printed as
+a JSON transition when it fires and when it resolves. Numeric alerts only
+resolve once their value drops below the threshold by --hysteresis, so values
+hovering around a threshold don't flap.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		siteID := types.SiteID(args[0])
+		if healthWatch {
+			watchHealth(siteID)
+			return
+		}
+		os.Exit(checkHealth(siteID))
+	},
+}
+
+// Checks the site once, printing the result as a Nagios plugin would, and
+// returns the exit code.
+func checkHealth(siteID types.SiteID) int {
+	c := getClient()
+	samples, err := health.Fetch(c.Network, siteID)
+	if err != nil {
+		fmt.Printf("UNKNOWN - %s\n", err.Error())
+		return nagiosUnknown
+	}
+
+	monitor := health.NewMonitor(healthThresholds)
+	monitor.Update(samples)
+	alerts := monitor.Active()
+	if len(alerts) == 0 {
+		fmt.Printf("OK - %d devices healthy\n", len(samples))
+		return nagiosOK
+	}
+
+	alerted := map[string]bool{}
+	for _, alert := range alerts {
+		alerted[alert.DeviceID] = true
+	}
+	fmt.Printf("CRITICAL - %d alerts on %d of %d devices\n", len(alerts), len(alerted), len(samples))
+	for _, alert := range alerts {
+		fmt.Printf("%s: %s\n", alert.DeviceName, alert.Message)
+	}
+	return nagiosCritical
+}
+
+// Checks the site every --interval, printing transitions, until the context
+// is done.
+func watchHealth(siteID types.SiteID) {
+	c := getClient()
+	monitor := health.NewMonitor(healthThresholds)
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+
+	for {
+		samples, err := health.Fetch(c.Network, siteID)
+		if err != nil {
+			log.Warnf("Couldn't check site: %s", err.Error())
+		} else {
+			for _, transition := range monitor.Update(samples) {
+				err = marshalAndPrintJSON(transition)
+				if err != nil {
+					log.Error(err.Error())
+					return
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
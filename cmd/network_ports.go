@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+var (
+	portFilter        = client.PortFilter{}
+	portSelection     string
+	portStates        []string
+	portFilterFlagSet = pflag.NewFlagSet("port filter", pflag.ExitOnError)
+)
+
+func init() {
+	portFilterFlagSet.StringSliceVar(&portFilter.Devices, "device", nil,
+		"Only ports of these devices, by name or ID")
+	portFilterFlagSet.StringVar(&portSelection, "port", "",
+		"Only these port indexes, e.g. 1,2,5-8")
+	portFilterFlagSet.StringSliceVar(&portStates, "state", nil,
+		"Only ports in these states. One or more of: "+strings.Join(types.EnumStrings(types.AllPortStates), ", "))
+	portFilterFlagSet.StringSliceVar(&portFilter.Connectors, "connector", nil,
+		"Only ports with these connectors, e.g. RJ45 or SFP")
+	portFilterFlagSet.BoolVar(&portFilter.PoE, "poe", false,
+		"Only ports which can supply power")
+	portFilterFlagSet.BoolVar(&portFilter.BelowMaxSpeed, "below-max-speed", false,
+		"Only ports which negotiated a slower speed than they support")
+
+	portsCmd.Flags().AddFlagSet(portFilterFlagSet)
+	portsCmd.Flags().AddFlagSet(outputFlagSet)
+	networkCmd.AddCommand(portsCmd)
+
+	powerCyclePortsCmd.Flags().AddFlagSet(portFilterFlagSet)
+	powerCyclePortsCmd.Flags().AddFlagSet(confirmFlagSet)
+	portsCmd.AddCommand(powerCyclePortsCmd)
+}
+
+// Parses a list of port indexes and ranges, e.g. "1,2,5-8".
+func parsePortSelection(text string) ([]int, error) {
+	ports := []int{}
+	if text == "" {
+		return ports, nil
+	}
+	for _, part := range strings.Split(text, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port '%s'", part)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(last)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid port range '%s'", part)
+			}
+		}
+		for port := start; port <= end; port++ {
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// Completes portFilter from the flags which need parsing.
+func buildPortFilter() (*client.PortFilter, error) {
+	ports, err := parsePortSelection(portSelection)
+	if err != nil {
+		return nil, err
+	}
+	portFilter.Ports = ports
+
+	err = validateChoices("port state", portStates, types.EnumStrings(types.AllPortStates))
+	if err != nil {
+		return nil, err
+	}
+	portFilter.States = []types.PortState{}
+	for _, state := range portStates {
+		portFilter.States = append(portFilter.States, types.PortState(state))
+	}
+	return &portFilter, nil
+}
+
+// Lists the ports of a site's devices which are selected by filter.
+func filteredSitePorts(c *client.Client, siteID types.SiteID, filter *client.PortFilter) ([]*client.SitePort, error) {
+	devices, err := client.AllDeviceDetails(c.Network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	return filter.Filter(client.Ports(devices)), nil
+}
+
+// Formats a link speed, e.g. "100M" or "2.5G".
+func formatSpeed(mbps int) string {
+	switch {
+	case mbps <= 0:
+		return ""
+	case mbps < 1000:
+		return fmt.Sprintf("%dM", mbps)
+	default:
+		return strconv.FormatFloat(float64(mbps)/1000, 'f', -1, 64) + "G"
+	}
+}
+
+// Describes a port's PoE capability, e.g. "802.3at" or "802.3at (off)".
+func formatPoE(port *client.SitePort) string {
+	if !port.IsPoE() {
+		return ""
+	}
+	poe := port.PoE.Standard
+	if poe == "" {
+		poe = "yes"
+	}
+	if !port.PoE.Enabled {
+		poe += " (off)"
+	}
+	return poe
+}
+
+// Lists what's worth attention about a port.
+func portNotes(port *client.SitePort) string {
+	notes := []string{}
+	if port.State == types.PortStateDown {
+		notes = append(notes, "down")
+	}
+	if port.BelowMaxSpeed() {
+		notes = append(notes, "below max speed")
+	}
+	return strings.Join(notes, ", ")
+}
+
+func printPortsTable(ports []*client.SitePort) error {
+	table := newTableWriter()
+	writeTableRow(table, "DEVICE", "PORT", "STATE", "CONNECTOR", "SPEED", "MAX SPEED", "POE", "NOTES")
+	for _, port := range ports {
+		writeTableRow(table,
+			port.DeviceName,
+			port.Idx,
+			port.State,
+			port.Connector,
+			formatSpeed(port.SpeedMbps),
+			formatSpeed(port.MaxSpeedMbps),
+			formatPoE(port),
+			portNotes(port),
+		)
+	}
+	return table.Flush()
+}
+
+var portsCmd = &cobra.Command{
+	Use:   "ports [site ID]",
+	Short: "Report on the ports of every device of a site",
+	Long: `Report on the ports of every device of a site: their state, link speed and
+PoE capability. Ports which are down, or which negotiated a slower speed than
+they support, are noted. Pass --output table for a readable report.
+
+Ports can be selected with the filter flags, e.g. every PoE port of one switch
+which is running below its maximum speed:
+
+  unified network ports default --device "Office Switch" --poe --below-max-speed -o table`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		err := validateOutputFormat()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		filter, err := buildPortFilter()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		ports, err := filteredSitePorts(c, types.SiteID(args[0]), filter)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		if outputFormat == outputJSON {
+			err = marshalAndPrintJSON(ports)
+		} else {
+			err = printPortsTable(ports)
+		}
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+	},
+}
+
+var powerCyclePortsCmd = &cobra.Command{
+	Use:   "power-cycle [site ID]",
+	Short: "Power cycle the PoE devices connected to the selected ports",
+	Long: `Power cycle the PoE devices connected to the ports selected by the filter
+flags. At least one filter must be given. Selected ports which aren't supplying
+power are skipped.
+
+The ports are listed, and you are asked to confirm, unless --yes is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		err := powerCyclePorts(types.SiteID(args[0]))
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+	},
+}
+
+func powerCyclePorts(siteID types.SiteID) error {
+	filter, err := buildPortFilter()
+	if err != nil {
+		return err
+	}
+	if filter.IsEmpty() {
+		return errors.New("refusing to power cycle every port, select ports with the filter flags")
+	}
+
+	c := getClient()
+	ports, err := filteredSitePorts(c, siteID, filter)
+	if err != nil {
+		return err
+	}
+
+	selected := []*client.SitePort{}
+	for _, port := range ports {
+		if !port.IsPoE() || !port.PoE.Enabled {
+			log.Warnf("Skipping port %d of '%s', which isn't supplying power", port.Idx, port.DeviceName)
+			continue
+		}
+		selected = append(selected, port)
+	}
+	if len(selected) == 0 {
+		return errors.New("no PoE ports selected")
+	}
+
+	err = printPortsTable(selected)
+	if err != nil {
+		return err
+	}
+	ok, err := confirm(fmt.Sprintf("Power cycle these %d ports?", len(selected)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Info("Cancelled")
+		return nil
+	}
+
+	failed := 0
+	for _, port := range selected {
+		portLog := log.WithField("device", port.DeviceName).WithField("port", port.Idx)
+		err = c.Network.DevicePortExecuteAction(siteID, types.DeviceID(port.DeviceID),
+			port.PortIdx(), types.NewPortPowerCycleRequest())
+		if err != nil {
+			portLog.Error(err.Error())
+			failed++
+			continue
+		}
+		portLog.Info("Power cycling")
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to power cycle %d of %d ports", failed, len(selected))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ClifHouck/unified/restart"
+	"github.com/ClifHouck/unified/topology"
+	"github.com/ClifHouck/unified/types"
+)
+
+var (
+	rollingSelector       string
+	rollingMaxUnavailable int
+	rollingPollInterval   time.Duration
+	rollingTimeout        time.Duration
+	rollingDryRun         bool
+)
+
+func init() {
+	rollingRestartCmd.Flags().StringVar(&rollingSelector, "selector", "",
+		"Only restart devices matching these key=value terms, e.g. model=U6-LR,name=Lobby*. Keys: "+
+			strings.Join(restart.SelectorKeys(), ", "))
+	rollingRestartCmd.Flags().IntVar(&rollingMaxUnavailable, "max-unavailable", 1,
+		"Most devices to restart at once")
+	rollingRestartCmd.Flags().DurationVar(&rollingPollInterval, "poll-interval", time.Second*10,
+		"How often to check whether restarting devices are back")
+	rollingRestartCmd.Flags().DurationVar(&rollingTimeout, "timeout", time.Minute*10,
+		"How long each batch has to come back online before the restart is aborted")
+	rollingRestartCmd.Flags().BoolVar(&rollingDryRun, "dry-run", false,
+		"Only print which devices would be restarted, in which order")
+	rollingRestartCmd.Flags().AddFlagSet(confirmFlagSet)
+	devicesCmd.AddCommand(rollingRestartCmd)
+}
+
+func printRestartPlan(graph *topology.Graph, batches []restart.Batch) error {
+	table := newTableWriter()
+	writeTableRow(table, "BATCH", "DEVICE", "MODEL", "STATE", "UPLINK")
+	for i, batch := range batches {
+		for _, device := range batch {
+			uplink := ""
+			if node := graph.Nodes[device.ID]; node.Uplink != nil {
+				uplink = node.Uplink.Name()
+			}
+			writeTableRow(table, i+1, device.Name, device.Model, device.State, uplink)
+		}
+	}
+	return table.Flush()
+}
+
+var rollingRestartCmd = &cobra.Command{
+	Use:   "rolling-restart [site ID]",
+	Short: "Restart a site's devices in batches, waiting for each batch to come back",
+	Long: `Restart a site's devices a few at a time, so the site never loses more than
+--max-unavailable devices at once. Each batch must come back online, with its
+uptime reset, before the next batch is restarted. The first device which
+fails to restart, or doesn't come back within --timeout, aborts the restart.
+
+Devices are restarted in uplink order: downstream devices before the devices
+they're connected through, and never together with their uplink. Devices which
+aren't online are skipped.
+
+The plan is printed, and you are asked to confirm, unless --yes is given. For
+example, to restart every U6-LR access point one at a time:
+
+  unified network devices rolling-restart default --selector model=U6-LR`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		err := rollingRestart(types.SiteID(args[0]))
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+	},
+}
+
+func rollingRestart(siteID types.SiteID) error {
+	selector, err := restart.ParseSelector(rollingSelector)
+	if err != nil {
+		return err
+	}
+
+	c := getClient()
+	graph, err := topology.Fetch(c.Network, siteID)
+	if err != nil {
+		return err
+	}
+
+	devices := []*types.Device{}
+	for _, node := range graph.Nodes {
+		devices = append(devices, node.Device)
+	}
+	selected := []*types.Device{}
+	for _, device := range selector.Select(devices) {
+		if device.State != types.DeviceStateOnline {
+			log.Warnf("Skipping '%s', which is %s", device.Name, device.State)
+			continue
+		}
+		selected = append(selected, device)
+	}
+	if len(selected) == 0 {
+		return errors.New("no online devices selected")
+	}
+
+	batches, err := restart.Plan(graph, selected, rollingMaxUnavailable)
+	if err != nil {
+		return err
+	}
+	err = printRestartPlan(graph, batches)
+	if err != nil {
+		return err
+	}
+	if rollingDryRun {
+		return nil
+	}
+	ok, err := confirm(fmt.Sprintf("Restart these %d devices in %d batches?", len(selected), len(batches)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Info("Cancelled")
+		return nil
+	}
+
+	restarter := &restart.Restarter{
+		Network:      c.Network,
+		SiteID:       siteID,
+		PollInterval: rollingPollInterval,
+		Timeout:      rollingTimeout,
+		OnProgress: func(result *restart.Result) {
+			resultLog := log.WithField("device", result.DeviceName).WithField("batch", result.Batch)
+			switch result.Status {
+			case restart.StatusRestarting:
+				resultLog.Info("Restarting")
+			case restart.StatusOnline:
+				resultLog.Infof("Back online after %s", result.Downtime().Round(time.Second))
+			case restart.StatusFailed:
+				resultLog.Error(result.Error)
+			case restart.StatusSkipped, restart.StatusPending:
+				resultLog.Debug(string(result.Status))
+			}
+		},
+	}
+	results, err := restarter.Run(ctx, batches)
+	printErr := marshalAndPrintJSON(results)
+	return errors.Join(err, printErr)
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/stats"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Output format of stats history, besides json, table and csv.
+const outputSparkline = "sparkline"
+
+var historyOutputFormats = []string{outputJSON, outputTable, outputCSV, outputSparkline}
+
+var (
+	statsStorePath    string
+	statsStoreFlagSet = pflag.NewFlagSet("stats store", pflag.ExitOnError)
+
+	recordInterval        time.Duration
+	recordWorkers         int
+	recordCompactInterval time.Duration
+	recordRetention       = stats.DefaultRetention
+
+	historySince   time.Duration
+	historyMetrics []string
+	historyWidth   int
+)
+
+func init() {
+	statsStoreFlagSet.StringVar(&statsStorePath, "store", "",
+		"File samples are stored in. Defaults to unified/stats.jsonl under $XDG_DATA_HOME")
+	statsCmd.PersistentFlags().AddFlagSet(statsStoreFlagSet)
+	networkCmd.AddCommand(statsCmd)
+
+	recordFlags := statsRecordCmd.Flags()
+	recordFlags.DurationVar(&recordInterval, "interval", time.Minute,
+		"Time between samples")
+	recordFlags.IntVar(&recordWorkers, "workers", client.DefaultStatisticsWorkers,
+		"Most statistics requests to make at once")
+	recordFlags.DurationVar(&recordCompactInterval, "compact-interval", time.Hour,
+		"Time between applying retention to the store")
+	recordFlags.DurationVar(&recordRetention.Raw, "raw-retention", recordRetention.Raw,
+		"How long samples are kept as recorded, before being downsampled")
+	recordFlags.DurationVar(&recordRetention.Total, "retention", recordRetention.Total,
+		"How long samples are kept at all")
+	recordFlags.DurationVar(&recordRetention.Bucket, "bucket", recordRetention.Bucket,
+		"Period downsampled samples are averaged over")
+	statsCmd.AddCommand(statsRecordCmd)
+
+	historyFlags := statsHistoryCmd.Flags()
+	historyFlags.DurationVar(&historySince, "since", time.Hour*24,
+		"Only samples from this long ago onwards")
+	historyFlags.AddFlagSet(outputFlagSet)
+	historyFlags.StringSliceVar(&historyMetrics, "metric", types.EnumStrings(stats.AllMetrics),
+		"Metrics to chart, one or more of: "+strings.Join(types.EnumStrings(stats.AllMetrics), ", "))
+	historyFlags.IntVar(&historyWidth, "width", 60,
+		"Most characters in a sparkline")
+	statsCmd.AddCommand(statsHistoryCmd)
+}
+
+// Opens the store named by --store, or the default one.
+func openStatsStore() (*stats.Store, error) {
+	path := statsStorePath
+	if path == "" {
+		var err error
+		path, err = stats.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats.Open(path)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Record and review the history of device statistics",
+	Long: `Record and review the history of device statistics. The API only reports
+the latest statistics of a device, so 'stats record' samples them periodically
+into a local file which 'stats history' reads.`,
+}
+
+// Samples the statistics of every online device of the site.
+func recordSamples(c *client.Client, siteID types.SiteID) ([]*stats.Sample, error) {
+	devices, err := client.AllDevices(c.Network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	online := []*types.DeviceListEntry{}
+	for _, device := range devices {
+		if device.State == types.DeviceStateOnline {
+			online = append(online, device)
+		}
+	}
+
+	now := time.Now()
+	samples := []*stats.Sample{}
+	for _, result := range client.FetchDeviceStatistics(c.Network, siteID, online, recordWorkers) {
+		if result.Err != nil {
+			log.WithField("device", result.Device.Name).Warn(result.Err.Error())
+			continue
+		}
+		samples = append(samples, stats.NewSample(siteID, result.Device, result.Statistics, now))
+	}
+	return samples, nil
+}
+
+var statsRecordCmd = &cobra.Command{
+	Use:   "record [site ID]",
+	Short: "Periodically record the statistics of a site's devices",
+	Long: `Record the statistics of every online device of a site every --interval,
+until interrupted.
+
+Samples are kept as recorded for --raw-retention, then averaged into one sample
+per device per --bucket, and dropped after --retention. Retention is applied
+when recording starts and every --compact-interval.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		store, err := openStatsStore()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		err = store.Compact(recordRetention, time.Now())
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		siteID := types.SiteID(args[0])
+		log.WithField("store", store.Path()).Info("Recording device statistics")
+
+		ticker := time.NewTicker(recordInterval)
+		defer ticker.Stop()
+		lastCompacted := time.Now()
+		for {
+			samples, err := recordSamples(c, siteID)
+			if err != nil {
+				log.Error(err.Error())
+			} else {
+				err = store.Append(samples)
+				if err != nil {
+					log.Error(err.Error())
+					return
+				}
+				log.Debugf("Recorded %d samples", len(samples))
+			}
+
+			if time.Since(lastCompacted) >= recordCompactInterval {
+				err = store.Compact(recordRetention, time.Now())
+				if err != nil {
+					log.Error(err.Error())
+					return
+				}
+				lastCompacted = time.Now()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	},
+}
+
+// Groups samples by device, in order of each device's first sample.
+func groupSamplesByDevice(samples []*stats.Sample) [][]*stats.Sample {
+	index := map[string]int{}
+	groups := [][]*stats.Sample{}
+	for _, sample := range samples {
+		key := sample.SiteID + "/" + sample.DeviceID
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, []*stats.Sample{})
+		}
+		groups[i] = append(groups[i], sample)
+	}
+	return groups
+}
+
+// Formats a metric's value the way devices top does.
+func formatMetric(metric stats.Metric, value float64) string {
+	switch metric {
+	case stats.MetricCPU, stats.MetricMemory:
+		return fmt.Sprintf("%.1f%%", value)
+	case stats.MetricLoad:
+		return fmt.Sprintf("%.2f", value)
+	case stats.MetricTx, stats.MetricRx:
+		return formatRate(int64(value))
+	case stats.MetricUptime:
+		return formatUptime(int64(value))
+	}
+	return fmt.Sprint(value)
+}
+
+func printSparklines(samples []*stats.Sample, metrics []stats.Metric) error {
+	table := newTableWriter()
+	for _, group := range groupSamplesByDevice(samples) {
+		last := group[len(group)-1]
+		fmt.Fprintf(table, "%s (%d samples, %s to %s)\n", last.Device, len(group),
+			group[0].Time.Local().Format(prettyTimeFormat), last.Time.Local().Format(prettyTimeFormat))
+		for _, metric := range metrics {
+			values := metric.Values(group)
+			writeTableRow(table, "  "+metric.String(),
+				stats.Sparkline(values, historyWidth),
+				"min "+formatMetric(metric, slices.Min(values)),
+				"max "+formatMetric(metric, slices.Max(values)),
+				"last "+formatMetric(metric, values[len(values)-1]))
+		}
+	}
+	return table.Flush()
+}
+
+func printHistoryTable(samples []*stats.Sample) error {
+	table := newTableWriter()
+	writeTableRow(table, "TIME", "DEVICE", "CPU", "MEMORY", "LOAD", "UPLINK TX", "UPLINK RX", "UPTIME", "SAMPLES")
+	for _, sample := range samples {
+		writeTableRow(table,
+			sample.Time.Local().Format(prettyTimeFormat),
+			sample.Device,
+			formatMetric(stats.MetricCPU, sample.CPUPct),
+			formatMetric(stats.MetricMemory, sample.MemoryPct),
+			formatMetric(stats.MetricLoad, sample.Load1Min),
+			formatMetric(stats.MetricTx, sample.TxBps),
+			formatMetric(stats.MetricRx, sample.RxBps),
+			formatMetric(stats.MetricUptime, float64(sample.UptimeSec)),
+			sample.Weight(),
+		)
+	}
+	return table.Flush()
+}
+
+func printHistoryCSV(samples []*stats.Sample) error {
+	writer := csv.NewWriter(os.Stdout)
+	err := writer.Write([]string{
+		"time", "site_id", "device_id", "device", "cpu_pct", "memory_pct", "load_1m",
+		"tx_bps", "rx_bps", "uptime_sec", "samples",
+	})
+	if err != nil {
+		return err
+	}
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	for _, sample := range samples {
+		err = writer.Write([]string{
+			sample.Time.Format(time.RFC3339),
+			sample.SiteID,
+			sample.DeviceID,
+			sample.Device,
+			formatFloat(sample.CPUPct),
+			formatFloat(sample.MemoryPct),
+			formatFloat(sample.Load1Min),
+			formatFloat(sample.TxBps),
+			formatFloat(sample.RxBps),
+			strconv.FormatInt(sample.UptimeSec, 10),
+			strconv.Itoa(sample.Weight()),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var statsHistoryCmd = &cobra.Command{
+	Use:   "history [device]",
+	Short: "Show the recorded statistics of devices",
+	Long: `Show the statistics recorded by 'stats record' since --since ago, of the
+device given by name or ID, or of every device if none is.
+
+Samples are printed as JSON, or with --output csv to export them. --output table
+lists every sample, and --output sparkline charts each metric of each device.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		err := validateOutputFormat(historyOutputFormats...)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		err = validateChoices("metric", historyMetrics, types.EnumStrings(stats.AllMetrics))
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		store, err := openStatsStore()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		query := stats.Query{Since: time.Now().Add(-historySince)}
+		if len(args) > 0 {
+			query.Device = args[0]
+		}
+		samples, err := store.Query(query)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if len(samples) == 0 && outputFormat != outputJSON && outputFormat != outputCSV {
+			log.Warnf("No samples in %s since %s", store.Path(),
+				query.Since.Format(prettyTimeFormat))
+			return
+		}
+
+		switch outputFormat {
+		case outputSparkline:
+			metrics := []stats.Metric{}
+			for _, metric := range historyMetrics {
+				metrics = append(metrics, stats.Metric(metric))
+			}
+			err = printSparklines(samples, metrics)
+		case outputTable:
+			err = printHistoryTable(samples)
+		case outputCSV:
+			err = printHistoryCSV(samples)
+		case outputJSON:
+			err = marshalAndPrintJSON(samples)
+		}
+		if err != nil {
+			log.Error(err.Error())
+		}
+	},
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+var (
+	networkEventTypes   []string
+	networkEventOptions = client.NetworkEventOptions{}
+)
+
+func init() {
+	networkSubscribeCmd.Flags().StringSliceVar(&networkEventTypes, "type", nil,
+		"Only stream network events of these types. One or more of: "+
+			strings.Join(types.NetworkEventTypes, ", "))
+	networkSubscribeCmd.Flags().DurationVar(&networkEventOptions.Interval, "interval", time.Second*30,
+		"Time between polls of the site")
+	networkSubscribeCmd.Flags().BoolVar(&networkEventOptions.Statistics, "statistics", false,
+		"Also poll device statistics, to catch restarts shorter than --interval. Costs a request per device per poll")
+	networkSubscribeCmd.Flags().IntVar(&subscribeCount, "count", 0,
+		"Stop after printing this many events. 0 streams forever")
+	networkSubscribeCmd.Flags().DurationVar(&subscribeTimeout, "timeout", 0,
+		"Stop after streaming for this long, e.g. 5m. 0 streams forever")
+	networkCmd.AddCommand(networkSubscribeCmd)
+}
+
+var networkSubscribeCmd = &cobra.Command{
+	Use:   "subscribe [site ID]",
+	Short: "Stream changes to a site's devices, clients and vouchers",
+	Long: `Stream changes to a site's devices, clients and vouchers, such as a device
+going offline or a client connecting. The Network API has no event stream, so
+the site is polled every --interval and each poll is compared with the last.
+Changes which are undone between polls aren't seen.
+
+The event types are listed in docs/events.md.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		err := validateChoices("event type", networkEventTypes, types.NetworkEventTypes)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		streamCtx, cancel := subscribeContext()
+		defer cancel()
+
+		c := getClient()
+		events, err := client.SubscribeNetworkEvents(streamCtx, c.Network, types.SiteID(args[0]),
+			&networkEventOptions)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		log.Info("Streaming network events...")
+		consumeStream(streamCtx, events, func(streamEvent *types.NetworkEvent) (bool, error) {
+			log.WithFields(logrus.Fields{
+				"ID":         streamEvent.EventItem().ID,
+				"event.type": streamEvent.ItemType,
+			}).Debug("Received NetworkEvent")
+
+			if !matchesAny(networkEventTypes, streamEvent.ItemType) {
+				return false, nil
+			}
+			return true, marshalAndPrintJSON(streamEvent.Item)
+		})
+	},
+}
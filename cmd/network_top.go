@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Columns devices top can sort by.
+const (
+	topSortName   = "name"
+	topSortModel  = "model"
+	topSortState  = "state"
+	topSortCPU    = "cpu"
+	topSortMemory = "memory"
+	topSortLoad   = "load"
+	topSortTx     = "tx"
+	topSortRx     = "rx"
+	topSortUptime = "uptime"
+)
+
+var topSortColumns = []string{
+	topSortName, topSortModel, topSortState, topSortCPU, topSortMemory,
+	topSortLoad, topSortTx, topSortRx, topSortUptime,
+}
+
+// ANSI sequence which moves the cursor home and clears the screen.
+const clearScreen = "\033[H\033[2J"
+
+var (
+	topInterval   time.Duration
+	topSort       string
+	topReverse    bool
+	topWorkers    int
+	topIterations int
+)
+
+func init() {
+	devicesTopCmd.Flags().DurationVar(&topInterval, "interval", time.Second*5,
+		"Time between refreshes")
+	devicesTopCmd.Flags().StringVar(&topSort, "sort", topSortCPU,
+		"Column to sort by, one of: "+strings.Join(topSortColumns, ", "))
+	devicesTopCmd.Flags().BoolVar(&topReverse, "reverse", false,
+		"Reverse the sort order")
+	devicesTopCmd.Flags().IntVar(&topWorkers, "workers", client.DefaultStatisticsWorkers,
+		"Most statistics requests to make at once")
+	devicesTopCmd.Flags().IntVar(&topIterations, "iterations", 0,
+		"Stop after this many refreshes. 0 refreshes forever")
+	devicesCmd.AddCommand(devicesTopCmd)
+}
+
+// Formats a bit rate, e.g. "12.5 Mbps".
+func formatRate(bps int64) string {
+	units := []string{"bps", "kbps", "Mbps", "Gbps"}
+	rate := float64(bps)
+	unit := 0
+	for rate >= 1000 && unit < len(units)-1 {
+		rate /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", bps, units[0])
+	}
+	return fmt.Sprintf("%.1f %s", rate, units[unit])
+}
+
+// Formats an uptime to its two largest units, e.g. "3d 4h" or "12m 5s".
+func formatUptime(seconds int64) string {
+	uptime := time.Duration(seconds) * time.Second
+	days := int64(uptime / (time.Hour * 24))
+	hours := int64(uptime/time.Hour) % 24
+	minutes := int64(uptime/time.Minute) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm %ds", minutes, seconds%60)
+	}
+}
+
+// Returns the value of a numeric sort column.
+func topSortValue(stats *types.DeviceStatistics, column string) float64 {
+	switch column {
+	case topSortCPU:
+		return stats.CPUUtilizationPct
+	case topSortMemory:
+		return stats.MemoryUtilizationPct
+	case topSortLoad:
+		return stats.LoadAverage1Min
+	case topSortTx:
+		return float64(stats.Uplink.TxRateBps)
+	case topSortRx:
+		return float64(stats.Uplink.RxRateBps)
+	case topSortUptime:
+		return float64(stats.UptimeSec)
+	}
+	return 0
+}
+
+// Sorts devices by a column: names, models and states ascending, and
+// statistics busiest first. Devices without statistics go last.
+func sortTopRows(rows []*client.DeviceStatisticsResult, column string, reverse bool) {
+	slices.SortStableFunc(rows, func(a, b *client.DeviceStatisticsResult) int {
+		var order int
+		switch column {
+		case topSortName:
+			order = cmp.Compare(a.Device.Name, b.Device.Name)
+		case topSortModel:
+			order = cmp.Compare(a.Device.Model, b.Device.Model)
+		case topSortState:
+			order = cmp.Compare(a.Device.State, b.Device.State)
+		default:
+			aMissing, bMissing := a.Statistics == nil, b.Statistics == nil
+			if aMissing || bMissing {
+				// Not reversed, so devices without statistics stay last.
+				return cmp.Or(cmp.Compare(boolInt(aMissing), boolInt(bMissing)),
+					cmp.Compare(a.Device.Name, b.Device.Name))
+			}
+			order = cmp.Compare(topSortValue(b.Statistics, column), topSortValue(a.Statistics, column))
+		}
+		if reverse {
+			order = -order
+		}
+		return cmp.Or(order, cmp.Compare(a.Device.Name, b.Device.Name))
+	})
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func renderTop(rows []*client.DeviceStatisticsResult) string {
+	buf := &bytes.Buffer{}
+	table := newTableWriterTo(buf)
+	writeTableRow(table, "DEVICE", "MODEL", "STATE", "CPU", "MEMORY", "LOAD", "UPLINK TX", "UPLINK RX", "UPTIME")
+	for _, row := range rows {
+		stats := row.Statistics
+		if stats == nil {
+			writeTableRow(table, row.Device.Name, row.Device.Model, row.Device.State)
+			continue
+		}
+		writeTableRow(table,
+			row.Device.Name,
+			row.Device.Model,
+			row.Device.State,
+			fmt.Sprintf("%.1f%%", stats.CPUUtilizationPct),
+			fmt.Sprintf("%.1f%%", stats.MemoryUtilizationPct),
+			fmt.Sprintf("%.2f %.2f %.2f", stats.LoadAverage1Min, stats.LoadAverage5Min, stats.LoadAverage15Min),
+			formatRate(stats.Uplink.TxRateBps),
+			formatRate(stats.Uplink.RxRateBps),
+			formatUptime(stats.UptimeSec),
+		)
+	}
+	err := table.Flush()
+	if err != nil {
+		log.Error(err.Error())
+	}
+	return buf.String()
+}
+
+// Fetches every device of the site and the statistics of those online.
+func fetchTopRows(c *client.Client, siteID types.SiteID) ([]*client.DeviceStatisticsResult, error) {
+	devices, err := client.AllDevices(c.Network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	online := []*types.DeviceListEntry{}
+	rows := []*client.DeviceStatisticsResult{}
+	for _, device := range devices {
+		if device.State == types.DeviceStateOnline {
+			online = append(online, device)
+		} else {
+			rows = append(rows, &client.DeviceStatisticsResult{Device: device})
+		}
+	}
+	for _, result := range client.FetchDeviceStatistics(c.Network, siteID, online, topWorkers) {
+		if result.Err != nil {
+			log.WithField("device", result.Device.Name).Debug(result.Err.Error())
+		}
+		rows = append(rows, result)
+	}
+	return rows, nil
+}
+
+var devicesTopCmd = &cobra.Command{
+	Use:   "top [site ID]",
+	Short: "Show a live, refreshing view of device statistics",
+	Long: `Show a live view of the statistics of every device of a site: CPU, memory,
+load averages, uplink transmit and receive rates and uptime, refreshed every
+--interval and sorted by --sort.
+
+Statistics are fetched a few devices at a time, at most --workers at once. When
+stdout isn't a terminal, a table is printed for each refresh instead.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		err := validateChoices("sort column", []string{topSort}, topSortColumns)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		siteID := types.SiteID(args[0])
+		live := isTerminal(os.Stdout)
+		ticker := time.NewTicker(topInterval)
+		defer ticker.Stop()
+
+		for iteration := 1; ; iteration++ {
+			rows, err := fetchTopRows(c, siteID)
+			if err != nil {
+				log.Error(err.Error())
+			} else {
+				sortTopRows(rows, topSort, topReverse)
+				header := fmt.Sprintf("%s  %d devices  sorted by %s",
+					time.Now().Format(prettyTimeFormat), len(rows), topSort)
+				if live {
+					fmt.Print(clearScreen + header + "\n\n" + renderTop(rows))
+				} else {
+					fmt.Print(header + "\n" + renderTop(rows) + "\n")
+				}
+			}
+
+			if topIterations > 0 && iteration >= topIterations {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	},
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ClifHouck/unified/topology"
+	"github.com/ClifHouck/unified/types"
+)
+
+var topologyFormat string
+
+func init() {
+	topologyCmd.Flags().StringVar(&topologyFormat, "format", topology.FormatASCII,
+		"Output format, one of: "+strings.Join(topology.Formats, ", "))
+	networkCmd.AddCommand(topologyCmd)
+}
+
+var topologyCmd = &cobra.Command{
+	Use:   "topology [site ID]",
+	Short: "Show how a site's devices are connected to each other",
+	Long: `Show how a site's devices are connected to each other, built from the
+uplink of each device.
+
+Devices whose uplink is not a device of the site, and uplinks which form a
+loop, are reported after the tree and logged as warnings.
+
+--format dot gives a Graphviz graph, e.g.:
+
+  unified network topology default --format dot | dot -Tsvg > topology.svg
+
+--format mermaid gives a Mermaid flowchart, which can be embedded in Markdown.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		err := validateChoices("topology format", []string{topologyFormat}, topology.Formats)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		graph, err := topology.Fetch(c.Network, types.SiteID(args[0]))
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		for _, orphan := range graph.Orphans {
+			log.WithField("uplink", orphan.Device.Uplink.DeviceID).
+				Warnf("Uplink of '%s' is not a device of this site", orphan.Name())
+		}
+		for _, loop := range graph.Loops {
+			log.Warnf("Uplinks of %s form a loop", strings.Join(nodeNames(loop), " -> "))
+		}
+
+		err = graph.Render(os.Stdout, topologyFormat)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+	},
+}
+
+func nodeNames(nodes []*topology.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, "'"+node.Name()+"'")
+	}
+	return names
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ClifHouck/unified/kiosk"
+)
+
+var (
+	kioskListen     string
+	kioskConfigPath string
+	kioskTrustProxy bool
+)
+
+func init() {
+	flags := voucherKioskCmd.Flags()
+	flags.StringVar(&kioskListen, "listen", ":8080", "Address to serve the kiosk on")
+	flags.StringVar(&kioskConfigPath, "kiosk-config", "", "File of the kiosk's staff PINs and voucher templates")
+	flags.BoolVar(&kioskTrustProxy, "trust-proxy", false,
+		"Identify clients by the X-Forwarded-For header of the proxy the kiosk is behind")
+	flags.AddFlagSet(voucherCardFlagSet)
+	vouchersCmd.AddCommand(voucherKioskCmd)
+}
+
+// Reads a kiosk's staff and templates from a file: YAML, JSON or TOML.
+func readKioskConfig(path string, config *kiosk.Config) error {
+	file := viper.New()
+	file.SetConfigFile(path)
+	err := file.ReadInConfig()
+	if err != nil {
+		return err
+	}
+	config.Staff = file.GetStringMapString("staff")
+	err = file.UnmarshalKey("templates", &config.Templates)
+	if err != nil {
+		return fmt.Errorf("kiosk config '%s': %w", path, err)
+	}
+	return nil
+}
+
+var voucherKioskCmd = &cobra.Command{
+	Use:   "kiosk [site ID]",
+	Short: "Serve a web page where staff generate and print vouchers with a PIN",
+	Long: `Serves a small web page, and JSON API, where staff sign in with a PIN,
+choose a voucher template and get a freshly generated voucher, shown as a card
+with a QR code, ready to print. Staff don't need an account on the console, and
+every voucher generated is logged with who generated it.
+
+Staff and templates are read from --kiosk-config. For example:
+
+  staff:
+    alex: "482193"   # PINs are at least 6 characters.
+    sam: "935614"
+  templates:
+    - name: 1 day
+      timeLimit: 1440   # minutes
+      guestLimit: 1
+    - name: Conference
+      timeLimit: 4320
+      guestLimit: 3
+      rxLimit: 10000    # kbps
+      txLimit: 2000
+      # Also dataLimit (MB).
+
+Vouchers are named after their template. --title, --footer, --logo and --qr are
+as for 'vouchers print'.
+
+The API takes the session cookie of a signed in browser, or a PIN as a bearer
+token:
+
+  GET  /api/templates
+  POST /api/vouchers  {"template": "1 day"}
+
+After 5 wrong PINs in a row, a client is locked out for a minute, and each
+lockout in a row lasts twice as long as the last, up to an hour. Wrong PINs are
+forgotten after an hour without another. After 20 wrong PINs from all clients
+together, every sign in is slowed by two seconds until that allowance recovers,
+at one wrong PIN every 30 seconds. A right PIN is never refused because of other
+clients' wrong ones.
+
+The kiosk serves plain HTTP, so put it behind a TLS proxy if it's reachable
+beyond a trusted network, with --trust-proxy. Otherwise every client has the
+proxy's address, and one guessing PINs locks out them all. The proxy must set
+X-Forwarded-For, appending the client's address, and X-Forwarded-Proto. Don't
+use --trust-proxy without a proxy, as clients could then pick their address.
+
+The site may be omitted if there's only one.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if kioskConfigPath == "" {
+			log.Error("A --kiosk-config file is required")
+			return
+		}
+		config := &kiosk.Config{TrustProxy: kioskTrustProxy, Log: log}
+		err := readKioskConfig(kioskConfigPath, config)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		config.Sheet, config.QR, err = newVoucherSheet()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		site := ""
+		if len(args) > 0 {
+			site = args[0]
+		}
+		config.SiteID, err = resolveSiteID(c, site)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		config.Network = c.Network
+		server, err := kiosk.New(config)
+		if err != nil {
+			log.Error(fmt.Sprintf("kiosk config '%s': %s", kioskConfigPath, err.Error()))
+			return
+		}
+
+		httpServer := &http.Server{
+			Addr:              kioskListen,
+			Handler:           server,
+			ReadHeaderTimeout: time.Second * 10,
+		}
+		go func() {
+			<-ctx.Done()
+			_ = httpServer.Close()
+		}()
+		log.WithField("address", kioskListen).Info("Serving voucher kiosk")
+		err = httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err.Error())
+		}
+	},
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+	"github.com/ClifHouck/unified/vouchers"
+)
+
+var (
+	maintainPolicyPath string
+	maintainDaemon     bool
+	maintainInterval   time.Duration
+	maintainDryRun     bool
+)
+
+func init() {
+	flags := voucherMaintainCmd.Flags()
+	flags.StringVar(&maintainPolicyPath, "policy", "", "Policy file of the pools to top up and vouchers to prune")
+	flags.BoolVar(&maintainDaemon, "daemon", false, "Keep maintaining every --interval until interrupted")
+	flags.DurationVar(&maintainInterval, "interval", time.Minute*5, "How often to top up pools with --daemon")
+	flags.BoolVar(&maintainDryRun, "dry-run", false, "Log the changes which would be made, without making them")
+	vouchersCmd.AddCommand(voucherMaintainCmd)
+}
+
+// Reads a voucher policy file: YAML, JSON or TOML.
+func readVoucherPolicy(path string) (*vouchers.Policy, error) {
+	config := viper.New()
+	config.SetConfigFile(path)
+	err := config.ReadInConfig()
+	if err != nil {
+		return nil, err
+	}
+	policy := &vouchers.Policy{}
+	err = config.Unmarshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("policy '%s': %w", path, err)
+	}
+	err = policy.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("policy '%s': %w", path, err)
+	}
+	return policy, nil
+}
+
+func voucherFields(voucher *types.Voucher) logrus.Fields {
+	return logrus.Fields{"id": voucher.ID, "code": voucher.Code, "name": voucher.Name}
+}
+
+// Generates the vouchers each pool is missing. Pools which fail don't stop the
+// others from being topped up.
+func topUpVoucherPools(c *client.Client, siteID types.SiteID, pools []*vouchers.Pool) error {
+	errs := []error{}
+	for _, pool := range pools {
+		status, err := vouchers.CheckPool(c.Network, siteID, pool)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		poolLog := log.WithFields(logrus.Fields{
+			"pool":    pool.Prefix,
+			"unused":  status.Unused,
+			"minimum": pool.Minimum,
+		})
+		if status.Missing == 0 {
+			poolLog.Debug("Pool has enough unused vouchers")
+			continue
+		}
+		if maintainDryRun {
+			poolLog.Infof("Would generate %d vouchers", status.Missing)
+			continue
+		}
+
+		generated, err := vouchers.TopUp(c.Network, siteID, status)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, voucher := range generated {
+			log.WithFields(voucherFields(voucher)).WithField("pool", pool.Prefix).Info("Generated voucher")
+		}
+		poolLog.Infof("Generated %d vouchers", len(generated))
+	}
+	return errors.Join(errs...)
+}
+
+// Deletes the vouchers the policy prunes.
+func pruneVouchers(c *client.Client, siteID types.SiteID, prune *vouchers.Prune) error {
+	candidates, err := vouchers.PruneCandidates(c.Network, siteID, prune)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		log.Debug("No vouchers to prune")
+		return nil
+	}
+
+	for _, voucher := range candidates {
+		voucherLog := log.WithFields(voucherFields(voucher)).WithFields(logrus.Fields{
+			"expired":    voucher.Expired,
+			"guests":     voucher.AuthorizedGuestCount,
+			"guestLimit": voucher.AuthorizedGuestLimit,
+		})
+		if maintainDryRun {
+			voucherLog.Info("Would delete voucher")
+		} else {
+			voucherLog.Info("Deleting voucher")
+		}
+	}
+	if maintainDryRun {
+		return nil
+	}
+	deleted, err := vouchers.Delete(c.Network, siteID, candidates)
+	log.Infof("Deleted %d vouchers", deleted)
+	return err
+}
+
+var voucherMaintainCmd = &cobra.Command{
+	Use:   "maintain [site ID]",
+	Short: "Top up pools of unused vouchers and prune expired or used up ones",
+	Long: `Maintains a site's vouchers as a policy file describes, once, or with
+--daemon every --interval until interrupted. Every voucher generated or deleted
+is logged.
+
+Pools are sets of vouchers whose names start with a prefix. Whenever a pool has
+fewer unused vouchers than its minimum, it's topped up with vouchers of its
+limits. Pruning deletes expired vouchers, and if asked, those which have been
+used by as many guests as they allow. For example:
+
+  pools:
+    - prefix: Event
+      minimum: 50
+      timeLimit: 1440   # minutes
+      guestLimit: 1
+      # Also dataLimit (MB), rxLimit and txLimit (kbps), and name, which
+      # defaults to the prefix.
+  prune:
+    expired: true
+    fullyUsed: false
+    prefixes: [Event]   # Only prune these pools' vouchers. Defaults to all.
+    interval: 24h       # With --daemon, prune daily rather than every pass.
+
+The site may be omitted if there's only one.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if maintainPolicyPath == "" {
+			log.Error("A --policy file is required")
+			return
+		}
+		policy, err := readVoucherPolicy(maintainPolicyPath)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		site := ""
+		if len(args) > 0 {
+			site = args[0]
+		}
+		siteID, err := resolveSiteID(c, site)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		ticker := time.NewTicker(maintainInterval)
+		defer ticker.Stop()
+		var lastPruned time.Time
+		for {
+			err = topUpVoucherPools(c, siteID, policy.Pools)
+			if err != nil {
+				log.Error(err.Error())
+			}
+			if policy.Prune.Enabled() && time.Since(lastPruned) >= policy.Prune.Interval {
+				err = pruneVouchers(c, siteID, &policy.Prune)
+				if err != nil {
+					log.Error(err.Error())
+				}
+				lastPruned = time.Now()
+			}
+			if !maintainDaemon {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	},
+}
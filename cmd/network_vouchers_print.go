@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"html/template"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+	"github.com/ClifHouck/unified/vouchers"
+)
+
+const (
+	voucherFormatPDF  = "pdf"
+	voucherFormatHTML = "html"
+)
+
+var voucherPrintFormats = []string{voucherFormatPDF, voucherFormatHTML}
+
+var (
+	voucherPrintIDs         []string
+	voucherPrintIncludeUsed bool
+	voucherPrintGenerate    int
+	voucherPrintFormat      string
+	voucherPrintOut         string
+	voucherPrintTemplate    string
+	voucherPrintPageSize    string
+)
+
+var (
+	voucherCardTitle  string
+	voucherCardFooter string
+	voucherCardLogo   string
+	voucherCardQR     string
+)
+
+// Built when declared rather than in init, as the init functions of the files
+// which add it to their commands may run before this file's.
+var voucherCardFlagSet = newVoucherCardFlagSet()
+
+// Flags of what's printed on voucher cards.
+func newVoucherCardFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("voucherCard", pflag.ExitOnError)
+	flags.StringVar(&voucherCardTitle, "title", "Guest Wi-Fi", "Title printed on each card")
+	flags.StringVar(&voucherCardFooter, "footer", "",
+		"Text printed at the bottom of each card, e.g. how to connect")
+	flags.StringVar(&voucherCardLogo, "logo", "", "GIF, JPEG or PNG logo printed on each card")
+	flags.StringVar(&voucherCardQR, "qr", "{{.Code}}",
+		"Go template of each card's QR code, executed with the voucher. E.g. a portal URL")
+	return flags
+}
+
+// Returns a sheet without cards of --title, --footer and --logo, and the --qr
+// template to add cards with.
+func newVoucherSheet() (*vouchers.Sheet, *texttemplate.Template, error) {
+	qrTemplate, err := texttemplate.New("qr").Parse(voucherCardQR)
+	if err != nil {
+		return nil, nil, err
+	}
+	sheet := &vouchers.Sheet{Title: voucherCardTitle, Footer: voucherCardFooter}
+	if voucherCardLogo != "" {
+		sheet.Logo, err = vouchers.LoadLogo(voucherCardLogo)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return sheet, qrTemplate, nil
+}
+
+func init() {
+	flags := voucherPrintCmd.Flags()
+	flags.StringSliceVar(&voucherPrintIDs, "id", nil, "Only print these vouchers, by ID")
+	flags.BoolVar(&voucherPrintIncludeUsed, "include-used", false,
+		"Also print vouchers which have been used or have expired")
+	flags.AddFlagSet(filterFlagSet)
+	flags.IntVar(&voucherPrintGenerate, "generate", 0,
+		"Generate this many vouchers, with --name and the limit flags, and print them")
+	flags.AddFlagSet(voucherGenerateFlagSet)
+	flags.StringVar(&voucherPrintFormat, "format", "",
+		"Sheet format, one of: "+strings.Join(voucherPrintFormats, ", ")+". Defaults to --out's extension, or pdf")
+	flags.StringVar(&voucherPrintOut, "out", "",
+		"File to write the sheet to, or - for stdout (default \"vouchers.<format>\")")
+	flags.AddFlagSet(voucherCardFlagSet)
+	flags.StringVar(&voucherPrintTemplate, "template", "",
+		"HTML template to render the sheet with, in place of the default (html format only)")
+	flags.StringVar(&voucherPrintPageSize, "page-size", "a4",
+		"PDF page size, one of: "+strings.Join(slices.Sorted(maps.Keys(vouchers.PageSizes)), ", "))
+	vouchersCmd.AddCommand(voucherPrintCmd)
+}
+
+// Returns the sheet format given by --format, or else by --out's extension.
+func voucherSheetFormat() (string, error) {
+	format := voucherPrintFormat
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(voucherPrintOut)), ".")
+		if !slices.Contains(voucherPrintFormats, format) {
+			format = voucherFormatPDF
+		}
+	}
+	return format, validateChoices("format", []string{format}, voucherPrintFormats)
+}
+
+// Selects the vouchers to print: those named by --id and --name, and unless
+// --include-used, only those which are still unused. Oldest first.
+func selectVouchers(all []*types.Voucher) []*types.Voucher {
+	selected := []*types.Voucher{}
+	for _, voucher := range all {
+		if len(voucherPrintIDs) > 0 && !slices.Contains(voucherPrintIDs, voucher.ID) {
+			continue
+		}
+		if voucherGenerateReq.Name != "" && !strings.EqualFold(voucherGenerateReq.Name, voucher.Name) {
+			continue
+		}
+		if !voucherPrintIncludeUsed && !vouchers.Unused(voucher) {
+			continue
+		}
+		selected = append(selected, voucher)
+	}
+	slices.SortFunc(selected, func(a, b *types.Voucher) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.Code, b.Code))
+	})
+	return selected
+}
+
+// Generates vouchers with --generate, or fetches those selected to print.
+func fetchVouchersToPrint(c *client.Client, siteID types.SiteID) ([]*types.Voucher, error) {
+	if voucherPrintGenerate > 0 {
+		request := *voucherGenerateReq
+		request.Count = voucherPrintGenerate
+		return c.Network.VoucherGenerate(siteID, &request)
+	}
+	all, err := client.AllVouchers(c.Network, siteID, types.Filter(filter))
+	if err != nil {
+		return nil, err
+	}
+	return selectVouchers(all), nil
+}
+
+func renderVoucherSheet(w io.Writer, sheet *vouchers.Sheet, format string) error {
+	if format == voucherFormatPDF {
+		return vouchers.RenderPDF(w, sheet, vouchers.PageSizes[voucherPrintPageSize])
+	}
+	var tmpl *template.Template
+	if voucherPrintTemplate != "" {
+		var err error
+		tmpl, err = vouchers.ParseTemplate(voucherPrintTemplate)
+		if err != nil {
+			return err
+		}
+	}
+	return vouchers.RenderHTML(w, sheet, tmpl)
+}
+
+// Writes the sheet to --out, or to stdout if it's "-".
+func writeVoucherSheet(sheet *vouchers.Sheet, format string) (string, error) {
+	out := cmp.Or(voucherPrintOut, "vouchers."+format)
+	if out == "-" {
+		return "stdout", renderVoucherSheet(os.Stdout, sheet, format)
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		return "", err
+	}
+	err = renderVoucherSheet(file, sheet, format)
+	return out, errors.Join(err, file.Close())
+}
+
+var voucherPrintCmd = &cobra.Command{
+	Use:   "print [site ID]",
+	Short: "Print vouchers as a PDF or HTML sheet of cards with QR codes",
+	Long: `Renders hotspot vouchers as a sheet of cards to cut out and hand to
+guests. Each card shows the voucher's code, its time, data, rate and device
+limits, and a QR code. Everything is rendered locally.
+
+By default every unused voucher of the site is printed. --id, --name and
+--filter narrow the selection, or --generate creates new vouchers to print.
+
+The QR code holds the voucher's code unless --qr gives a Go template, executed
+with the voucher, such as a portal URL:
+
+  --qr 'https://portal.example.com/guest?code={{.Code}}'
+
+HTML sheets can be restyled with --template, an html/template executed with the
+sheet; see vouchers.Sheet and vouchers.Card for the fields available.
+
+The site may be omitted if there's only one.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		format, err := voucherSheetFormat()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		err = validateChoices("page size", []string{voucherPrintPageSize},
+			slices.Sorted(maps.Keys(vouchers.PageSizes)))
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if voucherPrintTemplate != "" && format != voucherFormatHTML {
+			log.Error("--template only applies to the html format")
+			return
+		}
+		sheet, qrTemplate, err := newVoucherSheet()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		site := ""
+		if len(args) > 0 {
+			site = args[0]
+		}
+		siteID, err := resolveSiteID(c, site)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		selected, err := fetchVouchersToPrint(c, siteID)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if len(selected) == 0 {
+			log.Error("There are no vouchers to print")
+			return
+		}
+
+		err = sheet.AddCards(selected, qrTemplate)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		out, err := writeVoucherSheet(sheet, format)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		log.Infof("Printed %d vouchers to %s", len(sheet.Cards), out)
+	},
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+	outputCSV   = "csv"
+)
+
+// The formats most commands support. Commands which support others validate
+// --output against their own list, and name them in their help.
+var outputFormats = []string{outputJSON, outputTable}
+
+var outputFormat = outputJSON
+
+// Built when declared rather than in init, as the init functions of the files
+// which add it to their commands run before this file's.
+var outputFlagSet = newOutputFlagSet()
+
+func newOutputFlagSet() *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet("output", pflag.ExitOnError)
+	flagSet.StringVarP(&outputFormat, "output", "o", outputJSON,
+		"Output format, one of: "+strings.Join(outputFormats, ", ")+". Some commands support others, which their help lists")
+	return flagSet
+}
+
+// Checks --output is one of the formats the command supports.
+func validateOutputFormat(supported ...string) error {
+	if len(supported) == 0 {
+		supported = outputFormats
+	}
+	return validateChoices("output format", []string{outputFormat}, supported)
+}
+
+// Returns a writer which aligns tab separated columns, for --output table.
+// Flush it once every row has been written.
+func newTableWriter() *tabwriter.Writer {
+	return newTableWriterTo(os.Stdout)
+}
+
+// Returns a table writer like newTableWriter's, which writes to w.
+func newTableWriterTo(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+// Writes a row of a table to w.
+func writeTableRow(w *tabwriter.Writer, columns ...any) {
+	cells := make([]string, 0, len(columns))
+	for _, column := range columns {
+		cell := fmt.Sprint(column)
+		if cell == "" {
+			cell = "-"
+		}
+		cells = append(cells, cell)
+	}
+	fmt.Fprintln(w, strings.Join(cells, "\t"))
+}
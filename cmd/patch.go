@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+var (
+	patchSets  []string
+	patchStdin bool
+	patchDiff  bool
+
+	patchValidate bool
+)
+
+var patchFlagSet = pflag.NewFlagSet("patch", pflag.ExitOnError)
+
+func init() {
+	patchFlagSet.StringArrayVar(&patchSets, "set", nil,
+		"Set a field by its JSON path, e.g. --set ledSettings.isEnabled=false. "+
+			"The value is parsed as JSON, or else used as a string. May be repeated")
+	patchFlagSet.BoolVar(&patchStdin, "patch-stdin", false,
+		"Read a JSON merge patch (RFC 7396) from stdin")
+	patchFlagSet.BoolVar(&patchDiff, "diff", false,
+		"Show what the patch would change on the device, without applying it")
+}
+
+// optionalFlag sets a types.Optional field of a patch request from the
+// command line.
+type optionalFlag struct {
+	// Addressable types.Optional value.
+	target reflect.Value
+	// The Optional's type parameter.
+	elem reflect.Type
+}
+
+func (of *optionalFlag) String() string {
+	value := of.target.MethodByName("Get").Call(nil)
+	if !value[1].Bool() {
+		return ""
+	}
+	return fmt.Sprint(value[0].Interface())
+}
+
+func (of *optionalFlag) Set(text string) error {
+	value, err := parseFlagValue(of.elem, text)
+	if err != nil {
+		return err
+	}
+	of.target.Addr().MethodByName("Set").Call([]reflect.Value{value})
+	return nil
+}
+
+func (of *optionalFlag) Type() string {
+	switch of.elem.Kind() {
+	case reflect.Slice:
+		return "strings"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	default:
+		return of.elem.Kind().String()
+	}
+}
+
+// Parses text as a value of type elem, which is one of the types used by
+// patch request fields.
+func parseFlagValue(elem reflect.Type, text string) (reflect.Value, error) {
+	value := reflect.New(elem).Elem()
+	switch elem.Kind() {
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return value, err
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return value, err
+		}
+		value.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return value, err
+		}
+		value.SetFloat(parsed)
+	case reflect.Slice:
+		items := []string{}
+		if text != "" {
+			items = strings.Split(text, ",")
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return value, fmt.Errorf("unsupported flag type '%s'", elem)
+	}
+	return value, nil
+}
+
+// Returns true if t is an instantiation of types.Optional.
+func isOptional(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		t.PkgPath() == "github.com/ClifHouck/unified/types" &&
+		strings.HasPrefix(t.Name(), "Optional[")
+}
+
+// Adds a flag for every field of the patch request struct pointed to by
+// request which has a `flag` tag. A `flag` tag on a nested struct prefixes
+// the flags of its fields, so `flag:"temperature"` and `flag:"high"` give
+// --temperature-high.
+func addPatchFlags(flags *pflag.FlagSet, request any) {
+	addStructFlags(flags, reflect.ValueOf(request).Elem(), "", "")
+	flags.AddFlagSet(patchFlagSet)
+}
+
+func addStructFlags(flags *pflag.FlagSet, value reflect.Value, prefix string, helpPrefix string) {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		name := field.Tag.Get("flag")
+		help := field.Tag.Get("help")
+
+		if !isOptional(field.Type) {
+			if field.Type.Kind() != reflect.Struct {
+				continue
+			}
+			if name != "" {
+				addStructFlags(flags, value.Field(i), prefix+name+"-", help+": ")
+			} else {
+				addStructFlags(flags, value.Field(i), prefix, helpPrefix)
+			}
+			continue
+		}
+		if name == "" {
+			continue
+		}
+
+		get, _ := field.Type.MethodByName("Get")
+		target := &optionalFlag{target: value.Field(i), elem: get.Type.Out(0)}
+		flag := flags.VarPF(target, prefix+name, "", helpPrefix+help)
+		if target.elem.Kind() == reflect.Bool {
+			flag.NoOptDefVal = "true"
+		}
+	}
+}
+
+// Applies a JSON merge patch (RFC 7396) to target.
+func mergePatch(target map[string]any, patch map[string]any) {
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		patchObject, ok := value.(map[string]any)
+		if !ok {
+			target[key] = value
+			continue
+		}
+		targetObject, ok := target[key].(map[string]any)
+		if !ok {
+			targetObject = map[string]any{}
+		}
+		mergePatch(targetObject, patchObject)
+		target[key] = targetObject
+	}
+}
+
+func mergeJSONPatch(target map[string]any, data []byte, source string) error {
+	var patch map[string]any
+	err := json.Unmarshal(data, &patch)
+	if err != nil {
+		return fmt.Errorf("couldn't parse patch from %s: %w", source, err)
+	}
+	mergePatch(target, patch)
+	return nil
+}
+
+// Parses a --set argument, e.g. "ledSettings.isEnabled=false", into a merge
+// patch.
+func parseSetArgument(argument string) (map[string]any, error) {
+	path, text, ok := strings.Cut(argument, "=")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("--set '%s' must be of the form path=value", argument)
+	}
+
+	var value any
+	err := json.Unmarshal([]byte(text), &value)
+	if err != nil {
+		value = text
+	}
+
+	keys := strings.Split(path, ".")
+	patch := map[string]any{keys[len(keys)-1]: value}
+	for i := len(keys) - 2; i >= 0; i-- {
+		patch = map[string]any{keys[i]: patch}
+	}
+	return patch, nil
+}
+
+// Builds a patch request from, in increasing order of precedence: the JSON
+// file named by args[1], --patch-stdin, --set and the typed flags, which have
+// already been set on flagged.
+func buildPatchRequest[Req any](args []string, flagged *Req) (*Req, error) {
+	patch := map[string]any{}
+
+	if len(args) > 1 {
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			return nil, err
+		}
+		err = mergeJSONPatch(patch, data, args[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if patchStdin {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		err = mergeJSONPatch(patch, data, "stdin")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, argument := range patchSets {
+		setPatch, err := parseSetArgument(argument)
+		if err != nil {
+			return nil, err
+		}
+		mergePatch(patch, setPatch)
+	}
+
+	data, err := json.Marshal(flagged)
+	if err != nil {
+		return nil, err
+	}
+	err = mergeJSONPatch(patch, data, "flags")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(patch) == 0 {
+		return nil, errors.New("nothing to patch: pass a JSON file, flags, --set or --patch-stdin")
+	}
+
+	data, err = json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	request := new(Req)
+	err = decoder.Decode(request)
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	return request, nil
+}
+
+// Converts v to a generic JSON value.
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// Describes each field the patch sets, and how it compares to the current
+// value, e.g. "ledSettings.isEnabled: true -> false".
+func diffLines(path string, patch any, current any, color bool) []string {
+	patchObject, ok := patch.(map[string]any)
+	if ok {
+		currentObject, _ := current.(map[string]any)
+		lines := []string{}
+		for _, key := range slices.Sorted(maps.Keys(patchObject)) {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			lines = append(lines, diffLines(childPath, patchObject[key], currentObject[key], color)...)
+		}
+		return lines
+	}
+
+	newValue, _ := json.Marshal(patch)
+	if current == nil {
+		return []string{fmt.Sprintf("%s: %s", path, colorize(color, colorGreen, string(newValue)))}
+	}
+	oldValue, _ := json.Marshal(current)
+	if reflect.DeepEqual(patch, current) {
+		return []string{colorize(color, colorGray, fmt.Sprintf("%s: %s (unchanged)", path, newValue))}
+	}
+	return []string{fmt.Sprintf("%s: %s -> %s", path,
+		colorize(color, colorRed, string(oldValue)), colorize(color, colorGreen, string(newValue)))}
+}
+
+// Runs a patch command: builds the request, validates it against the device
+// if validate is non-nil and --validate is set, then either prints a --diff
+// against the device's current state or applies it and prints the result.
+func runPatchCommand[Req any, Device any](
+	args []string,
+	flagged *Req,
+	details func(*client.Client, string) (*Device, error),
+	validate func(*Device, *Req) error,
+	apply func(*client.Client, string, *Req) (*Device, error),
+) error {
+	request, err := buildPatchRequest(args, flagged)
+	if err != nil {
+		return err
+	}
+
+	c := getClient()
+	if !patchValidate {
+		validate = nil
+	}
+
+	var device *Device
+	if patchDiff || validate != nil {
+		device, err = details(c, args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	var validationErr error
+	if validate != nil {
+		validationErr = logPatchViolations(validate(device, request))
+	}
+
+	if patchDiff {
+		err = printPatchDiff(device, request)
+		if err != nil {
+			return err
+		}
+		return validationErr
+	}
+	if validationErr != nil {
+		return validationErr
+	}
+
+	modified, err := apply(c, args[0], request)
+	if err != nil {
+		return err
+	}
+	return marshalAndPrintJSON(modified)
+}
+
+// Prints each field request sets, and how it differs from device.
+func printPatchDiff(device any, request any) error {
+	current, err := toJSONValue(device)
+	if err != nil {
+		return err
+	}
+	patch, err := toJSONValue(request)
+	if err != nil {
+		return err
+	}
+	for _, line := range diffLines("", patch, current, useColor()) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// Logs each violation of a *types.PatchValidationError on its own line, and
+// returns a summary error. Other errors are returned as is.
+func logPatchViolations(err error) error {
+	var validationErr *types.PatchValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	for _, violation := range validationErr.Violations {
+		log.Error(violation.String())
+	}
+	return fmt.Errorf("patch not supported by '%s', pass --validate=false to send it anyway",
+		validationErr.DeviceID)
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target map[string]any
+		patch  map[string]any
+		want   map[string]any
+	}{
+		{
+			name:   "sets",
+			target: map[string]any{"name": "Porch", "micVolume": 50.0},
+			patch:  map[string]any{"name": "Garage"},
+			want:   map[string]any{"name": "Garage", "micVolume": 50.0},
+		},
+		{
+			name:   "null deletes",
+			target: map[string]any{"name": "Porch", "micVolume": 50.0},
+			patch:  map[string]any{"micVolume": nil},
+			want:   map[string]any{"name": "Porch"},
+		},
+		{
+			name:   "merges nested objects",
+			target: map[string]any{"ledSettings": map[string]any{"isEnabled": true, "blinkRate": 1.0}},
+			patch:  map[string]any{"ledSettings": map[string]any{"isEnabled": false, "blinkRate": nil}},
+			want:   map[string]any{"ledSettings": map[string]any{"isEnabled": false}},
+		},
+		{
+			name:   "creates nested objects",
+			target: map[string]any{"ledSettings": "off"},
+			patch:  map[string]any{"ledSettings": map[string]any{"isEnabled": false}},
+			want:   map[string]any{"ledSettings": map[string]any{"isEnabled": false}},
+		},
+		{
+			name:   "replaces an object with a value",
+			target: map[string]any{"ledSettings": map[string]any{"isEnabled": true}},
+			patch:  map[string]any{"ledSettings": "off"},
+			want:   map[string]any{"ledSettings": "off"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mergePatch(tt.target, tt.patch)
+			assert.Equal(t, tt.want, tt.target)
+		})
+	}
+}
+
+func TestParseSetArgument(t *testing.T) {
+	tests := []struct {
+		argument string
+		want     map[string]any
+		err      bool
+	}{
+		{argument: "micVolume=50", want: map[string]any{"micVolume": 50.0}},
+		{argument: "ledSettings.isEnabled=false",
+			want: map[string]any{"ledSettings": map[string]any{"isEnabled": false}}},
+		{argument: `name="Porch"`, want: map[string]any{"name": "Porch"}},
+		// Values which aren't JSON are strings.
+		{argument: "name=Front door", want: map[string]any{"name": "Front door"}},
+		{argument: "name=", want: map[string]any{"name": ""}},
+		{argument: "name=null", want: map[string]any{"name": nil}},
+		{argument: "cameraIds=[\"a\",\"b\"]", want: map[string]any{"cameraIds": []any{"a", "b"}}},
+		{argument: "a.b=c=d", want: map[string]any{"a": map[string]any{"b": "c=d"}}},
+		{argument: "micVolume", err: true},
+		{argument: "=50", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.argument, func(t *testing.T) {
+			patch, err := parseSetArgument(tt.argument)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, patch)
+		})
+	}
+}
+
+func TestBuildPatchRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		stdin   string
+		sets    []string
+		flagged types.LightPatchRequest
+		want    types.LightPatchRequest
+		err     string
+	}{
+		{
+			name: "file",
+			file: `{"name": "Porch", "lightDeviceSettings": {"pirDuration": 15000}}`,
+			want: types.LightPatchRequest{
+				Name:                types.Some("Porch"),
+				LightDeviceSettings: types.LightDeviceSettingsPatch{PirDuration: types.Some(15000)},
+			},
+		},
+		{
+			name:  "stdin over file",
+			file:  `{"name": "Porch", "lightDeviceSettings": {"pirDuration": 15000}}`,
+			stdin: `{"name": "Garage", "lightDeviceSettings": {"pirSensitivity": 80}}`,
+			want: types.LightPatchRequest{
+				Name: types.Some("Garage"),
+				LightDeviceSettings: types.LightDeviceSettingsPatch{
+					PirDuration:    types.Some(15000),
+					PirSensitivity: types.Some(80),
+				},
+			},
+		},
+		{
+			name:  "set over stdin",
+			stdin: `{"name": "Garage", "isLightForceEnabled": true}`,
+			sets:  []string{"name=Shed", "lightModeSettings.mode=motion"},
+			want: types.LightPatchRequest{
+				Name:                types.Some("Shed"),
+				IsLightForceEnabled: types.Some(true),
+				LightModeSettings:   types.LightModeSettingsPatch{Mode: types.Some(types.LightMode("motion"))},
+			},
+		},
+		{
+			name:    "flags over set",
+			file:    `{"name": "Porch"}`,
+			sets:    []string{"name=Shed", "isLightForceEnabled=true"},
+			flagged: types.LightPatchRequest{Name: types.Some("Barn")},
+			want: types.LightPatchRequest{
+				Name:                types.Some("Barn"),
+				IsLightForceEnabled: types.Some(true),
+			},
+		},
+		{
+			name:  "null deletes",
+			file:  `{"name": "Porch", "lightDeviceSettings": {"pirDuration": 15000, "pirSensitivity": 80}}`,
+			stdin: `{"lightDeviceSettings": {"pirDuration": null}}`,
+			sets:  []string{"name=null"},
+			want: types.LightPatchRequest{
+				LightDeviceSettings: types.LightDeviceSettingsPatch{PirSensitivity: types.Some(80)},
+			},
+		},
+		{
+			name: "unknown field",
+			sets: []string{"ledSettings.isEnabled=false"},
+			err:  "invalid patch",
+		},
+		{
+			name: "wrong type",
+			sets: []string{"lightDeviceSettings.pirDuration=long"},
+			err:  "invalid patch",
+		},
+		{
+			name: "nothing",
+			err:  "nothing to patch",
+		},
+		{
+			name:  "bad stdin",
+			stdin: `{"name": `,
+			err:   "couldn't parse patch from stdin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"light1"}
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "patch.json")
+				require.NoError(t, os.WriteFile(path, []byte(tt.file), 0o600))
+				args = append(args, path)
+			}
+			patchSets = tt.sets
+			patchStdin = tt.stdin != ""
+			t.Cleanup(func() {
+				patchSets = nil
+				patchStdin = false
+			})
+			if patchStdin {
+				setStdin(t, tt.stdin)
+			}
+
+			request, err := buildPatchRequest(args, &tt.flagged)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *request)
+		})
+	}
+}
+
+// Replaces os.Stdin with text for the rest of the test.
+func setStdin(t *testing.T, text string) {
+	path := filepath.Join(t.TempDir(), "stdin")
+	require.NoError(t, os.WriteFile(path, []byte(text), 0o600))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+
+	stdin := os.Stdin
+	os.Stdin = file
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		_ = file.Close()
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/ClifHouck/unified/client"
 	"github.com/ClifHouck/unified/types"
 )
 
@@ -29,6 +30,13 @@ var (
 	snapshotJPEGQuality int
 )
 
+var (
+	cameraPatchReq = &types.CameraPatchRequest{}
+	lightPatchReq  = &types.LightPatchRequest{}
+	chimePatchReq  = &types.ChimePatchRequest{}
+	sensorPatchReq = &types.SensorPatchRequest{}
+)
+
 var qualitiesFlagSet = pflag.NewFlagSet("qualities", pflag.ExitOnError)
 
 func init() { //nolint:funlen
@@ -45,10 +53,6 @@ func init() { //nolint:funlen
 	protectCmd.AddCommand(filesCmd)
 	protectCmd.AddCommand(alarmManagerCmd)
 
-	// Subscriptions
-	subscribeCmd.AddCommand(deviceEventsCmd)
-	subscribeCmd.AddCommand(protectEventsCmd)
-
 	// Viewers
 	viewerListCmd.Flags().AddFlagSet(listingFlagSet)
 	viewersCmd.AddCommand(viewerListCmd)
@@ -67,6 +71,9 @@ func init() { //nolint:funlen
 	cameraListCmd.Flags().AddFlagSet(listingFlagSet)
 	camerasCmd.AddCommand(cameraListCmd)
 	camerasCmd.AddCommand(cameraDetailsCmd)
+	addPatchFlags(cameraPatchCmd.Flags(), cameraPatchReq)
+	cameraPatchCmd.Flags().BoolVar(&patchValidate, "validate", true,
+		"Check the patch against the camera's feature flags before sending it")
 	camerasCmd.AddCommand(cameraPatchCmd)
 
 	cameraGetSnapshotCmd.Flags().BoolVar(&snapshotLowQuality, "low-quality", false, "snapshot low quality")
@@ -97,18 +104,21 @@ func init() { //nolint:funlen
 	lightListCmd.Flags().AddFlagSet(listingFlagSet)
 	lightsCmd.AddCommand(lightListCmd)
 	lightsCmd.AddCommand(lightDetailsCmd)
+	addPatchFlags(lightPatchCmd.Flags(), lightPatchReq)
 	lightsCmd.AddCommand(lightPatchCmd)
 
 	// Chimes
 	chimeListCmd.Flags().AddFlagSet(listingFlagSet)
 	chimesCmd.AddCommand(chimeListCmd)
 	chimesCmd.AddCommand(chimeDetailsCmd)
+	addPatchFlags(chimePatchCmd.Flags(), chimePatchReq)
 	chimesCmd.AddCommand(chimePatchCmd)
 
 	// Sensors
 	sensorListCmd.Flags().AddFlagSet(listingFlagSet)
 	sensorsCmd.AddCommand(sensorListCmd)
 	sensorsCmd.AddCommand(sensorDetailsCmd)
+	addPatchFlags(sensorPatchCmd.Flags(), sensorPatchReq)
 	sensorsCmd.AddCommand(sensorPatchCmd)
 
 	// Device Asset File Management
@@ -205,104 +215,15 @@ var protectInfoCmd = &cobra.Command{
 	},
 }
 
-var deviceEventsCmd = &cobra.Command{
-	Use:   "device-events",
-	Short: "Stream device events from Protect API",
-	Run: func(_ *cobra.Command, _ []string) {
-		c := getClient()
-		events, err := c.Protect.SubscribeDeviceEvents()
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		log.Info("Streaming device events...")
-		for {
-			select {
-			case streamEvent := <-events:
-				if streamEvent == nil {
-					log.Warn("Got nil event. Bailing out!")
-					return
-				}
-
-				var item types.ProtectDeviceEventItem
-				err = json.Unmarshal(streamEvent.RawItem, &item)
-				if err != nil {
-					log.Error("Couldn't parse RawItem!")
-					log.Error(err.Error())
-				}
-
-				log.WithFields(logrus.Fields{
-					"ID":           item.ID,
-					"event.type":   streamEvent.ItemType,
-					"message.type": streamEvent.Type,
-				}).Info("Received ProtectDeviceEvent")
-
-				err = marshalAndPrintJSON(item)
-				if err != nil {
-					log.Error(err.Error())
-					return
-				}
-
-			case <-ctx.Done():
-				log.Warn("Got context.Done!")
-				return
-			}
-		}
-	},
-}
-
-var protectEventsCmd = &cobra.Command{
-	Use:   "protect-events",
-	Short: "Stream protect events from Protect API",
-	Run: func(_ *cobra.Command, _ []string) {
-		c := getClient()
-		events, err := c.Protect.SubscribeProtectEvents()
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		log.Info("Streaming protect events...")
-		for {
-			select {
-			case streamEvent := <-events:
-				if streamEvent == nil {
-					log.Warn("Got nil event. Bailing out!")
-					return
-				}
-
-				var item types.ProtectEventItem
-				err = json.Unmarshal(streamEvent.RawItem, &item)
-				if err != nil {
-					log.Error("Couldn't parse RawItem!")
-					log.Error(err.Error())
-				}
-
-				log.WithFields(logrus.Fields{
-					"ID":           item.ID,
-					"event.type":   streamEvent.ItemType,
-					"message.type": streamEvent.Type,
-				}).Info("Received ProtectEvent")
-
-				err = marshalAndPrintJSON(item)
-				if err != nil {
-					log.Error(err.Error())
-					return
-				}
-
-			case <-ctx.Done():
-				log.Warn("Got context.Done!")
-				return
-			}
-		}
-	},
-}
-
 var cameraListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List adopted Protect cameras",
 	Run: func(_ *cobra.Command, _ []string) {
+		if fleetMode() {
+			listFleet(func(c *client.Client) ([]*types.Camera, error) { return c.Protect.Cameras() },
+				func(item *types.Camera) string { return item.ID })
+			return
+		}
 		c := getClient()
 		cameras, err := c.Protect.Cameras()
 		if err != nil {
@@ -345,30 +266,31 @@ var cameraDetailsCmd = &cobra.Command{
 }
 
 var cameraPatchCmd = &cobra.Command{
-	Use:   "patch [camera ID] [camera JSON filename]",
+	Use:   "patch [camera ID] [JSON filename]",
 	Short: "Patch the configuration of an existing camera",
-	Args:  cobra.ExactArgs(2),
+	Long: `Patch the configuration of an existing camera. Changes are given by flags,
+--set, a JSON merge patch on stdin with --patch-stdin, or a JSON file, with
+flags taking precedence. Only the fields given are changed. Fields set to
+false, 0 or an empty list are sent as given.
+
+Use --diff to preview the changes against the camera's current configuration
+without applying them.
+
+The patch is first checked against the camera's feature flags, so that e.g.
+unsupported video modes or smart detection types are reported before anything
+is sent. Pass --validate=false to skip the check.`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(_ *cobra.Command, args []string) {
-		data, err := os.ReadFile(args[1])
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		var cameraReq types.CameraPatchRequest
-		err = json.Unmarshal(data, &cameraReq)
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		c := getClient()
-		modifiedCamera, err := c.Protect.CameraPatch(types.CameraID(args[0]), &cameraReq)
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-		err = marshalAndPrintJSON(modifiedCamera)
+		err := runPatchCommand(args, cameraPatchReq,
+			func(c *client.Client, id string) (*types.Camera, error) {
+				return c.Protect.CameraDetails(types.CameraID(id))
+			},
+			func(camera *types.Camera, req *types.CameraPatchRequest) error {
+				return req.ValidateFor(camera)
+			},
+			func(c *client.Client, id string, req *types.CameraPatchRequest) (*types.Camera, error) {
+				return c.Protect.CameraPatch(types.CameraID(id), req)
+			})
 		if err != nil {
 			log.Error(err.Error())
 			return
@@ -531,6 +453,11 @@ var viewerListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all viewers",
 	Run: func(_ *cobra.Command, _ []string) {
+		if fleetMode() {
+			listFleet(func(c *client.Client) ([]*types.Viewer, error) { return c.Protect.Viewers() },
+				func(item *types.Viewer) string { return item.ID })
+			return
+		}
 		c := getClient()
 		viewers, err := c.Protect.Viewers()
 		if err != nil {
@@ -595,6 +522,11 @@ var liveViewListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all liveviews",
 	Run: func(_ *cobra.Command, _ []string) {
+		if fleetMode() {
+			listFleet(func(c *client.Client) ([]*types.LiveView, error) { return c.Protect.LiveViews() },
+				func(item *types.LiveView) string { return item.ID })
+			return
+		}
 		c := getClient()
 		liveViews, err := c.Protect.LiveViews()
 		if err != nil {
@@ -704,6 +636,11 @@ var lightListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List adopted Protect lights",
 	Run: func(_ *cobra.Command, _ []string) {
+		if fleetMode() {
+			listFleet(func(c *client.Client) ([]*types.Light, error) { return c.Protect.Lights() },
+				func(item *types.Light) string { return item.ID })
+			return
+		}
 		c := getClient()
 		lights, err := c.Protect.Lights()
 		if err != nil {
@@ -746,30 +683,25 @@ var lightDetailsCmd = &cobra.Command{
 }
 
 var lightPatchCmd = &cobra.Command{
-	Use:   "patch [light ID] [light JSON filename]",
+	Use:   "patch [light ID] [JSON filename]",
 	Short: "Patch the configuration of an existing light",
-	Args:  cobra.ExactArgs(2),
+	Long: `Patch the configuration of an existing light. Changes are given by flags,
+--set, a JSON merge patch on stdin with --patch-stdin, or a JSON file, with
+flags taking precedence. Only the fields given are changed. Fields set to
+false, 0 or an empty list are sent as given.
+
+Use --diff to preview the changes against the light's current configuration
+without applying them.`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(_ *cobra.Command, args []string) {
-		data, err := os.ReadFile(args[1])
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		var lightReq types.LightPatchRequest
-		err = json.Unmarshal(data, &lightReq)
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		c := getClient()
-		modifiedlight, err := c.Protect.LightPatch(types.LightID(args[0]), &lightReq)
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-		err = marshalAndPrintJSON(modifiedlight)
+		err := runPatchCommand(args, lightPatchReq,
+			func(c *client.Client, id string) (*types.Light, error) {
+				return c.Protect.LightDetails(types.LightID(id))
+			},
+			nil,
+			func(c *client.Client, id string, req *types.LightPatchRequest) (*types.Light, error) {
+				return c.Protect.LightPatch(types.LightID(id), req)
+			})
 		if err != nil {
 			log.Error(err.Error())
 			return
@@ -799,6 +731,11 @@ var chimeListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List adopted Protect chimes",
 	Run: func(_ *cobra.Command, _ []string) {
+		if fleetMode() {
+			listFleet(func(c *client.Client) ([]*types.Chime, error) { return c.Protect.Chimes() },
+				func(item *types.Chime) string { return item.ID })
+			return
+		}
 		c := getClient()
 		chimes, err := c.Protect.Chimes()
 		if err != nil {
@@ -841,30 +778,25 @@ var chimeDetailsCmd = &cobra.Command{
 }
 
 var chimePatchCmd = &cobra.Command{
-	Use:   "patch [chime ID] [chime JSON filename]",
+	Use:   "patch [chime ID] [JSON filename]",
 	Short: "Patch the configuration of an existing chime",
-	Args:  cobra.ExactArgs(2),
+	Long: `Patch the configuration of an existing chime. Changes are given by flags,
+--set, a JSON merge patch on stdin with --patch-stdin, or a JSON file, with
+flags taking precedence. Only the fields given are changed. Fields set to
+false, 0 or an empty list are sent as given.
+
+Use --diff to preview the changes against the chime's current configuration
+without applying them.`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(_ *cobra.Command, args []string) {
-		data, err := os.ReadFile(args[1])
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		var chimeReq types.ChimePatchRequest
-		err = json.Unmarshal(data, &chimeReq)
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		c := getClient()
-		modifiedchime, err := c.Protect.ChimePatch(types.ChimeID(args[0]), &chimeReq)
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-		err = marshalAndPrintJSON(modifiedchime)
+		err := runPatchCommand(args, chimePatchReq,
+			func(c *client.Client, id string) (*types.Chime, error) {
+				return c.Protect.ChimeDetails(types.ChimeID(id))
+			},
+			nil,
+			func(c *client.Client, id string, req *types.ChimePatchRequest) (*types.Chime, error) {
+				return c.Protect.ChimePatch(types.ChimeID(id), req)
+			})
 		if err != nil {
 			log.Error(err.Error())
 			return
@@ -876,6 +808,11 @@ var sensorListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List adopted Protect sensors",
 	Run: func(_ *cobra.Command, _ []string) {
+		if fleetMode() {
+			listFleet(func(c *client.Client) ([]*types.Sensor, error) { return c.Protect.Sensors() },
+				func(item *types.Sensor) string { return item.ID })
+			return
+		}
 		c := getClient()
 		sensors, err := c.Protect.Sensors()
 		if err != nil {
@@ -918,30 +855,25 @@ var sensorDetailsCmd = &cobra.Command{
 }
 
 var sensorPatchCmd = &cobra.Command{
-	Use:   "patch [sensor ID] [sensor JSON filename]",
+	Use:   "patch [sensor ID] [JSON filename]",
 	Short: "Patch the configuration of an existing sensor",
-	Args:  cobra.ExactArgs(2),
+	Long: `Patch the configuration of an existing sensor. Changes are given by flags,
+--set, a JSON merge patch on stdin with --patch-stdin, or a JSON file, with
+flags taking precedence. Only the fields given are changed. Fields set to
+false, 0 or an empty list are sent as given.
+
+Use --diff to preview the changes against the sensor's current configuration
+without applying them.`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(_ *cobra.Command, args []string) {
-		data, err := os.ReadFile(args[1])
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		var sensorReq types.SensorPatchRequest
-		err = json.Unmarshal(data, &sensorReq)
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-
-		c := getClient()
-		modifiedsensor, err := c.Protect.SensorPatch(types.SensorID(args[0]), &sensorReq)
-		if err != nil {
-			log.Error(err.Error())
-			return
-		}
-		err = marshalAndPrintJSON(modifiedsensor)
+		err := runPatchCommand(args, sensorPatchReq,
+			func(c *client.Client, id string) (*types.Sensor, error) {
+				return c.Protect.SensorDetails(types.SensorID(id))
+			},
+			nil,
+			func(c *client.Client, id string, req *types.SensorPatchRequest) (*types.Sensor, error) {
+				return c.Protect.SensorPatch(types.SensorID(id), req)
+			})
 		if err != nil {
 			log.Error(err.Error())
 			return
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+var (
+	powerCycleSite string
+	powerCyclePort string
+	powerCycleWait time.Duration
+)
+
+func init() {
+	cameraPowerCycleCmd.Flags().StringVar(&powerCycleSite, "site", "",
+		"ID of the Network site the camera is connected to. Not needed if there is only one site")
+	cameraPowerCycleCmd.Flags().StringVar(&powerCyclePort, "port", "",
+		"Switch port the camera is connected to, e.g. \"Office Switch:5\". Overrides portMap")
+	cameraPowerCycleCmd.Flags().DurationVar(&powerCycleWait, "wait", time.Minute*5,
+		"How long to wait for the camera to come back. 0 doesn't wait")
+	cameraPowerCycleCmd.Flags().AddFlagSet(confirmFlagSet)
+	camerasCmd.AddCommand(cameraPowerCycleCmd)
+}
+
+// Returns a correlator for the site, with the switch ports from the portMap
+// config setting assigned.
+func newCorrelator(c *client.Client, siteID types.SiteID) (*client.Correlator, error) {
+	correlator, err := client.FetchCorrelator(c.Network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range viper.GetStringMapString("portMap") {
+		ref, err := client.ParsePortRef(value)
+		if err != nil {
+			return nil, fmt.Errorf("portMap entry '%s': %w", key, err)
+		}
+		correlator.AssignPort(key, ref)
+	}
+	return correlator, nil
+}
+
+// Returns the camera with the given ID, or name ignoring case.
+func findCamera(c *client.Client, nameOrID string) (*types.Camera, error) {
+	cameras, err := c.Protect.Cameras()
+	if err != nil {
+		return nil, err
+	}
+	for _, camera := range cameras {
+		if camera.ID == nameOrID || strings.EqualFold(camera.Name, nameOrID) {
+			return camera, nil
+		}
+	}
+	return nil, fmt.Errorf("no camera '%s'", nameOrID)
+}
+
+// Explains why a device's switch port couldn't be found.
+func unknownPortError(device *client.ProtectDevice, location *client.NetworkLocation) error {
+	if location.Uplink == nil {
+		return fmt.Errorf("couldn't find '%s' (%s) among the site's network clients", device.Name, device.Mac)
+	}
+	return fmt.Errorf("'%s' is connected to '%s', but the Network API doesn't report which port, "+
+		"pass --port \"%s:<port>\" or add it to portMap in the config file",
+		device.Name, location.Uplink.Name, location.Uplink.Name)
+}
+
+type cameraPowerCycleResult struct {
+	CameraID   string `json:"cameraId"`
+	CameraName string `json:"cameraName"`
+	Switch     string `json:"switch"`
+	Port       int    `json:"port"`
+	// Reconnect is omitted when not waiting for the camera.
+	Reconnect   *client.Reconnect `json:"reconnect,omitempty"`
+	Reconnected bool              `json:"reconnected"`
+	Downtime    string            `json:"downtime,omitempty"`
+}
+
+var cameraPowerCycleCmd = &cobra.Command{
+	Use:   "power-cycle [camera ID or name]",
+	Short: "Power cycle a camera through its PoE switch port, which must be given with --port or portMap",
+	Long: `Power cycle a camera by power cycling the PoE switch port it's connected to,
+then wait for it to come back online and report how long it was down.
+
+The camera is matched to a Network client by its MAC address only, as Protect
+doesn't report cameras' IP addresses. The client gives the switch the camera is
+connected to, but not the port: the Network API doesn't report which port a
+client is on, nor what's connected to a switch's ports. So the port isn't
+derived, and must be given with --port, or in the portMap setting of the config
+file, keyed by camera name, ID or MAC address:
+
+  portMap:
+    front door: "Office Switch:5"
+    "aa:bb:cc:dd:ee:ff": "Garage Switch:2"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		err := powerCycleCamera(args[0])
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+	},
+}
+
+func powerCycleCamera(nameOrID string) error {
+	c := getClient()
+	camera, err := findCamera(c, nameOrID)
+	if err != nil {
+		return err
+	}
+	siteID, err := resolveSiteID(c, powerCycleSite)
+	if err != nil {
+		return err
+	}
+	correlator, err := newCorrelator(c, siteID)
+	if err != nil {
+		return err
+	}
+	if powerCyclePort != "" {
+		ref, err := client.ParsePortRef(powerCyclePort)
+		if err != nil {
+			return err
+		}
+		correlator.AssignPort(camera.ID, ref)
+	}
+
+	device := &client.ProtectDevice{
+		ID:       camera.ID,
+		ModelKey: camera.ModelKey,
+		Name:     camera.Name,
+		State:    camera.State,
+		Mac:      camera.Mac,
+	}
+	location, err := correlator.LocateProtectDevice(device)
+	if err != nil {
+		return err
+	}
+	port := location.Port
+	if port == nil {
+		return unknownPortError(device, location)
+	}
+	if !port.IsPoE() || !port.PoE.Enabled {
+		return fmt.Errorf("port %d of '%s' isn't supplying power", port.Idx, port.DeviceName)
+	}
+
+	ok, err := confirm(fmt.Sprintf("Power cycle '%s' on port %d of '%s'?", camera.Name, port.Idx, port.DeviceName))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Info("Cancelled")
+		return nil
+	}
+
+	// Subscribe first, so the camera going offline isn't missed.
+	var stream <-chan *types.ProtectDeviceEvent
+	if powerCycleWait > 0 {
+		stream, err = c.Protect.SubscribeDeviceEvents()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Network.DevicePortExecuteAction(siteID, types.DeviceID(port.DeviceID), port.PortIdx(),
+		types.NewPortPowerCycleRequest())
+	if err != nil {
+		return err
+	}
+	log.WithField("camera", camera.Name).Info("Power cycling")
+
+	result := &cameraPowerCycleResult{
+		CameraID:   camera.ID,
+		CameraName: camera.Name,
+		Switch:     port.DeviceName,
+		Port:       port.Idx,
+	}
+	var waitErr error
+	if powerCycleWait > 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, powerCycleWait)
+		defer cancel()
+		result.Reconnect, waitErr = client.WaitForReconnect(waitCtx, stream, camera.ID)
+		result.Reconnected = result.Reconnect.Reconnected()
+		if result.Reconnected {
+			result.Downtime = result.Reconnect.Downtime().Round(time.Second).String()
+		}
+		if errors.Is(waitErr, context.DeadlineExceeded) {
+			waitErr = fmt.Errorf("'%s' didn't come back within %s", camera.Name, powerCycleWait)
+		}
+	}
+
+	err = marshalAndPrintJSON(result)
+	if err != nil {
+		return err
+	}
+	return waitErr
+}
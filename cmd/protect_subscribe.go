@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+var (
+	protectEventTypes  []string
+	deviceEventTypes   []string
+	smartDetectTypes   []string
+	subscribeDevices   []string
+	subscribeModelKeys []string
+	messageTypes       []string
+	subscribeCount     int
+	subscribeTimeout   time.Duration
+	prettyOutput       bool
+)
+
+var subscribeFlagSet = pflag.NewFlagSet("subscribe", pflag.ExitOnError)
+
+var (
+	protectEventMessageTypes = []string{"add", "update"}
+	deviceEventMessageTypes  = []string{"add", "update", "remove"}
+)
+
+// Time format used by --pretty output.
+const prettyTimeFormat = "2006-01-02 15:04:05"
+
+func init() {
+	subscribeFlagSet.StringSliceVar(&subscribeDevices, "device", nil,
+		"Only stream events from these devices, by ID or name")
+	subscribeFlagSet.StringSliceVar(&subscribeModelKeys, "model-key", nil,
+		"Only stream events from devices with these model keys, e.g. camera or sensor")
+	subscribeFlagSet.IntVar(&subscribeCount, "count", 0,
+		"Stop after printing this many events. 0 streams forever")
+	subscribeFlagSet.DurationVar(&subscribeTimeout, "timeout", 0,
+		"Stop after streaming for this long, e.g. 5m. 0 streams forever")
+	subscribeFlagSet.BoolVar(&prettyOutput, "pretty", false,
+		"Print one human-readable line per event instead of JSON")
+	subscribeFlagSet.BoolVar(&noColor, "no-color", false,
+		"Don't color --pretty output. Also disabled by setting NO_COLOR, or when not writing to a terminal")
+
+	deviceEventsCmd.Flags().StringSliceVar(&deviceEventTypes, "type", nil,
+		"Only stream device events of these types. One or more of: "+
+			strings.Join(types.ProtectDeviceEventTypes, ", "))
+	deviceEventsCmd.Flags().StringSliceVar(&messageTypes, "message-type", nil,
+		"Only stream events with these message types. One or more of: "+
+			strings.Join(deviceEventMessageTypes, ", "))
+	deviceEventsCmd.Flags().AddFlagSet(subscribeFlagSet)
+	subscribeCmd.AddCommand(deviceEventsCmd)
+
+	protectEventsCmd.Flags().StringSliceVar(&protectEventTypes, "type", nil,
+		"Only stream protect events of these types. One or more of: "+
+			strings.Join(types.ProtectEventTypes, ", "))
+	protectEventsCmd.Flags().StringSliceVar(&messageTypes, "message-type", nil,
+		"Only stream events with these message types. One or more of: "+
+			strings.Join(protectEventMessageTypes, ", "))
+	protectEventsCmd.Flags().StringSliceVar(&smartDetectTypes, "smart-detect", nil,
+		"Only stream smart detections of any of these types, e.g. person or vehicle")
+	protectEventsCmd.Flags().AddFlagSet(subscribeFlagSet)
+	subscribeCmd.AddCommand(protectEventsCmd)
+}
+
+// Checks that every requested value is one of the known choices.
+func validateChoices(what string, requested []string, choices []string) error {
+	for _, value := range requested {
+		if !slices.Contains(choices, value) {
+			return fmt.Errorf("unknown %s '%s', must be one of: %s",
+				what, value, strings.Join(choices, ", "))
+		}
+	}
+	return nil
+}
+
+// Returns true if no values were requested, or if any of values matches any
+// requested value, ignoring case.
+func matchesAny(requested []string, values ...string) bool {
+	if len(requested) == 0 {
+		return true
+	}
+	for _, want := range requested {
+		for _, value := range values {
+			if value != "" && strings.EqualFold(want, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns a context which ends after --timeout, if set.
+func subscribeContext() (context.Context, context.CancelFunc) {
+	if subscribeTimeout > 0 {
+		return context.WithTimeout(ctx, subscribeTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Returns a device directory filled from the Protect list endpoints. Failing
+// to list devices isn't fatal; events will just lack device names.
+func newDeviceDirectory(c *client.Client) *client.ProtectDeviceDirectory {
+	directory := client.NewProtectDeviceDirectory(c.Protect)
+	err := directory.Refresh()
+	if err != nil {
+		log.Warn("Couldn't list every Protect device: " + err.Error())
+	}
+	return directory
+}
+
+// Calls handle for every event on stream until --count events have been
+// printed, the stream closes or the context is done. handle returns whether
+// it printed the event.
+func consumeStream[T any](streamCtx context.Context, stream <-chan *T, handle func(*T) (bool, error)) {
+	printed := 0
+	for {
+		select {
+		case streamEvent := <-stream:
+			if streamEvent == nil {
+				log.Warn("Got nil event. Bailing out!")
+				return
+			}
+			ok, err := handle(streamEvent)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			if ok {
+				printed++
+			}
+			if subscribeCount > 0 && printed >= subscribeCount {
+				return
+			}
+		case <-streamCtx.Done():
+			if errors.Is(streamCtx.Err(), context.DeadlineExceeded) {
+				log.Info("Timeout reached, stopping.")
+			} else {
+				log.Warn("Got context.Done!")
+			}
+			return
+		}
+	}
+}
+
+// Color used for a message type in --pretty output.
+func messageTypeColor(messageType string) string {
+	switch messageType {
+	case "add":
+		return colorGreen
+	case "remove":
+		return colorRed
+	}
+	return colorYellow
+}
+
+// Formats a device for --pretty output, e.g. "Front Door (camera)".
+func prettyDevice(color bool, id string, name string, modelKey string) string {
+	device := name
+	if device == "" {
+		device = id
+	}
+	device = colorize(color, colorBold, device)
+	if modelKey != "" {
+		device += " " + colorize(color, colorGray, "("+modelKey+")")
+	}
+	return device
+}
+
+func printPrettyProtectEvent(enriched *client.EnrichedProtectEvent, streamEvent *types.ProtectEvent) {
+	color := useColor()
+
+	when := enriched.Start
+	if when.IsZero() {
+		when = time.Now()
+	}
+
+	line := fmt.Sprintf("%s  %s  %s  %s",
+		colorize(color, colorGray, when.Format(prettyTimeFormat)),
+		colorize(color, messageTypeColor(enriched.MessageType), fmt.Sprintf("%-6s", enriched.MessageType)),
+		colorize(color, colorCyan, fmt.Sprintf("%-22s", enriched.Type)),
+		prettyDevice(color, enriched.DeviceID, enriched.DeviceName, enriched.DeviceModelKey))
+
+	detected := streamEvent.SmartDetectTypes()
+	if len(detected) > 0 {
+		line += "  " + colorize(color, colorBlue, strings.Join(detected, ", "))
+	}
+	if !enriched.End.IsZero() {
+		line += fmt.Sprintf("  ended after %s", enriched.End.Sub(enriched.Start))
+	}
+	fmt.Println(line)
+}
+
+var protectEventsCmd = &cobra.Command{
+	Use:   "protect-events",
+	Short: "Stream protect events from Protect API",
+	Long: `Stream protect events from Protect API. Each event is printed along with the
+name and model key of the device it came from, its start and end times, and
+its full typed payload, such as smart detection types or sensor values.`,
+	Run: func(_ *cobra.Command, _ []string) {
+		err := errors.Join(
+			validateChoices("event type", protectEventTypes, types.ProtectEventTypes),
+			validateChoices("message type", messageTypes, protectEventMessageTypes))
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		events, err := c.Protect.SubscribeProtectEvents()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		streamCtx, cancel := subscribeContext()
+		defer cancel()
+
+		// Resolve device IDs to names, and keep those names current as
+		// devices are added and renamed.
+		directory := newDeviceDirectory(c)
+		deviceEvents, err := c.Protect.SubscribeDeviceEvents()
+		if err != nil {
+			log.Warn("Couldn't subscribe to device events, device names may go stale: " + err.Error())
+		} else {
+			go directory.ProcessDeviceEvents(streamCtx, deviceEvents)
+		}
+
+		log.Info("Streaming protect events...")
+		consumeStream(streamCtx, events, func(streamEvent *types.ProtectEvent) (bool, error) {
+			enriched := directory.Enrich(streamEvent)
+
+			log.WithFields(logrus.Fields{
+				"ID":           enriched.ID,
+				"event.type":   enriched.Type,
+				"message.type": enriched.MessageType,
+				"device":       enriched.DeviceName,
+			}).Debug("Received ProtectEvent")
+
+			if !matchesAny(protectEventTypes, enriched.Type) ||
+				!matchesAny(messageTypes, enriched.MessageType) ||
+				!matchesAny(subscribeDevices, enriched.DeviceID, enriched.DeviceName) ||
+				!matchesAny(subscribeModelKeys, enriched.DeviceModelKey) ||
+				!matchesAny(smartDetectTypes, streamEvent.SmartDetectTypes()...) {
+				return false, nil
+			}
+
+			if prettyOutput {
+				printPrettyProtectEvent(enriched, streamEvent)
+				return true, nil
+			}
+			return true, marshalAndPrintJSON(enriched)
+		})
+	},
+}
+
+// A device event as printed by `subscribe device-events`.
+type deviceEventOutput struct {
+	Type       string      `json:"type"`
+	ModelKey   string      `json:"modelKey"`
+	DeviceName string      `json:"deviceName,omitempty"`
+	Item       interface{} `json:"item"`
+}
+
+// Formats the fields an update carries, e.g. "state=CONNECTED", so changes
+// are visible in --pretty output.
+func prettyChangedFields(rawItem json.RawMessage) string {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(rawItem, &fields)
+	if err != nil {
+		return ""
+	}
+
+	const maxValueLength = 40
+	changes := []string{}
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if key == "id" || key == "modelKey" {
+			continue
+		}
+		value := []rune(string(fields[key]))
+		if len(value) > maxValueLength {
+			value = append(value[:maxValueLength], '…')
+		}
+		changes = append(changes, key+"="+string(value))
+	}
+	return strings.Join(changes, " ")
+}
+
+func printPrettyDeviceEvent(output *deviceEventOutput, streamEvent *types.ProtectDeviceEvent, id string) {
+	color := useColor()
+	fmt.Printf("%s  %s  %s  %s\n",
+		colorize(color, colorGray, time.Now().Format(prettyTimeFormat)),
+		colorize(color, messageTypeColor(output.Type), fmt.Sprintf("%-6s", output.Type)),
+		prettyDevice(color, id, output.DeviceName, output.ModelKey),
+		prettyChangedFields(streamEvent.RawItem))
+}
+
+var deviceEventsCmd = &cobra.Command{
+	Use:   "device-events",
+	Short: "Stream device events from Protect API",
+	Long: `Stream device events from Protect API. Each event is printed with the full
+typed device it describes. Note that update events only carry the fields which
+changed, so other fields of the device are left at their zero values.`,
+	Run: func(_ *cobra.Command, _ []string) {
+		err := errors.Join(
+			validateChoices("event type", deviceEventTypes, types.ProtectDeviceEventTypes),
+			validateChoices("message type", messageTypes, deviceEventMessageTypes))
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		c := getClient()
+		events, err := c.Protect.SubscribeDeviceEvents()
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		streamCtx, cancel := subscribeContext()
+		defer cancel()
+
+		// Used to name devices in update events, which only carry a name
+		// when the device was renamed.
+		directory := newDeviceDirectory(c)
+
+		log.Info("Streaming device events...")
+		consumeStream(streamCtx, events, func(streamEvent *types.ProtectDeviceEvent) (bool, error) {
+			var item types.ProtectDeviceEventItem
+			err := json.Unmarshal(streamEvent.RawItem, &item)
+			if err != nil {
+				return false, err
+			}
+			directory.Update(streamEvent)
+
+			output := &deviceEventOutput{
+				Type:     streamEvent.Type,
+				ModelKey: streamEvent.ModelKey,
+				Item:     streamEvent.Item,
+			}
+			device, ok := directory.Lookup(item.ID)
+			if ok {
+				output.DeviceName = device.Name
+			} else {
+				output.DeviceName = item.Name
+			}
+
+			log.WithFields(logrus.Fields{
+				"ID":           item.ID,
+				"event.type":   streamEvent.ItemType,
+				"message.type": streamEvent.Type,
+				"device":       output.DeviceName,
+			}).Debug("Received ProtectDeviceEvent")
+
+			if !matchesAny(deviceEventTypes, streamEvent.ItemType) ||
+				!matchesAny(messageTypes, streamEvent.Type) ||
+				!matchesAny(subscribeDevices, item.ID, output.DeviceName) ||
+				!matchesAny(subscribeModelKeys, streamEvent.ModelKey) {
+				return false, nil
+			}
+
+			if prettyOutput {
+				printPrettyDeviceEvent(output, streamEvent, item.ID)
+				return true, nil
+			}
+			return true, marshalAndPrintJSON(output)
+		})
+	},
+}
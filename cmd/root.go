@@ -104,6 +104,9 @@ func getAPIKey() string {
 	} else if viper.IsSet("apiKey") {
 		log.Debug("UniFi API key set from configuration file.")
 		return viper.GetString("apikey")
+	} else if viper.IsSet("profiles") {
+		// Each profile has its own key, for --controllers and --all-profiles.
+		return ""
 	}
 	log.Fatal("Couldn't retrieve API key from configuration.")
 	return ""
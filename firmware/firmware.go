@@ -0,0 +1,184 @@
+// Package firmware reports which firmware versions the devices of a fleet
+// run, and which of them fall short of a policy of minimum versions.
+package firmware
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// CompareVersions compares two firmware versions, e.g. "7.1.26.15869", part
+// by part. Numeric parts compare as numbers, others as text. Returns -1 if a
+// is older than b, 1 if it's newer, and 0 if they're the same.
+func CompareVersions(a, b string) int {
+	aParts, bParts := versionParts(a), versionParts(b)
+	for i := range min(len(aParts), len(bParts)) {
+		aNum, aErr := strconv.ParseUint(aParts[i], 10, 64)
+		bNum, bErr := strconv.ParseUint(bParts[i], 10, 64)
+		var order int
+		switch {
+		case aErr == nil && bErr == nil:
+			order = cmp.Compare(aNum, bNum)
+		case aErr == nil:
+			// Release numbers sort after suffixes such as "beta".
+			order = 1
+		case bErr == nil:
+			order = -1
+		default:
+			order = cmp.Compare(aParts[i], bParts[i])
+		}
+		if order != 0 {
+			return order
+		}
+	}
+	// With the shared parts equal, an extra release number makes a version
+	// newer, e.g. "7.1.26.1", but a suffix makes it older, e.g. "7.1.26-beta".
+	switch {
+	case len(aParts) > len(bParts):
+		return extraPartOrder(aParts[len(bParts)])
+	case len(aParts) < len(bParts):
+		return -extraPartOrder(bParts[len(aParts)])
+	}
+	return 0
+}
+
+// Returns how a version with the extra part compares to one without it.
+func extraPartOrder(part string) int {
+	_, err := strconv.ParseUint(part, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return 1
+}
+
+// Splits a version into its parts, dropping a leading "v".
+func versionParts(version string) []string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if version == "" {
+		return []string{}
+	}
+	return strings.FieldsFunc(version, func(r rune) bool {
+		return r == '.' || r == '-' || r == '+'
+	})
+}
+
+// AnyModel is the Policy key which applies to models without a key of their
+// own.
+const AnyModel = "*"
+
+// Policy maps device models to the minimum firmware version they must run.
+// Models are matched ignoring case.
+type Policy map[string]string
+
+// Minimum returns the minimum version for model, if the policy has one.
+func (p Policy) Minimum(model string) (string, bool) {
+	for key, minimum := range p {
+		if strings.EqualFold(key, model) {
+			return minimum, true
+		}
+	}
+	minimum, ok := p[AnyModel]
+	return minimum, ok
+}
+
+// Device is a device and the firmware it runs.
+type Device struct {
+	SiteID    string `json:"siteId"`
+	Site      string `json:"site"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Model     string `json:"model"`
+	Version   string `json:"version"`
+	Updatable bool   `json:"updatable"`
+	// Minimum is the version the policy requires, if any.
+	Minimum string `json:"minimum,omitempty"`
+	// Compliant is false if the device runs an older version than Minimum.
+	Compliant bool `json:"compliant"`
+}
+
+// NewDevice returns the firmware of a device of site.
+func NewDevice(site *types.Site, device *types.Device) *Device {
+	return &Device{
+		SiteID:    site.ID,
+		Site:      site.Name,
+		ID:        device.ID,
+		Name:      device.Name,
+		Model:     device.Model,
+		Version:   device.FirmwareVersion,
+		Updatable: device.FirmwareUpdatable,
+		Compliant: true,
+	}
+}
+
+// Group is the devices of a model which run the same version.
+type Group struct {
+	Model   string    `json:"model"`
+	Version string    `json:"version"`
+	Devices []*Device `json:"devices"`
+	// Updatable counts the devices with an update available.
+	Updatable int `json:"updatable"`
+	// Uniform is false if other devices of the model run other versions.
+	Uniform   bool   `json:"uniform"`
+	Minimum   string `json:"minimum,omitempty"`
+	Compliant bool   `json:"compliant"`
+}
+
+// Report is the firmware of a fleet of devices.
+type Report struct {
+	// Groups is ordered by model, then newest version first.
+	Groups []*Group `json:"groups"`
+	// OutOfPolicy lists the devices older than their minimum version.
+	OutOfPolicy []*Device `json:"outOfPolicy"`
+}
+
+// NewReport groups devices by model and version, and checks them against
+// policy, which may be nil.
+func NewReport(devices []*Device, policy Policy) *Report {
+	report := &Report{Groups: []*Group{}, OutOfPolicy: []*Device{}}
+	type groupKey struct{ model, version string }
+	groups := map[groupKey]*Group{}
+	versions := map[string]int{}
+	for _, device := range devices {
+		if minimum, ok := policy.Minimum(device.Model); ok {
+			device.Minimum = minimum
+			device.Compliant = device.Version != "" && CompareVersions(device.Version, minimum) >= 0
+		}
+		if !device.Compliant {
+			report.OutOfPolicy = append(report.OutOfPolicy, device)
+		}
+
+		key := groupKey{device.Model, device.Version}
+		group, ok := groups[key]
+		if !ok {
+			group = &Group{Model: device.Model, Version: device.Version, Devices: []*Device{},
+				Minimum: device.Minimum, Compliant: device.Compliant}
+			groups[key] = group
+			report.Groups = append(report.Groups, group)
+			versions[device.Model]++
+		}
+		group.Devices = append(group.Devices, device)
+		if device.Updatable {
+			group.Updatable++
+		}
+	}
+
+	for _, group := range report.Groups {
+		group.Uniform = versions[group.Model] == 1
+	}
+	slices.SortFunc(report.Groups, func(a, b *Group) int {
+		return cmp.Or(cmp.Compare(a.Model, b.Model), CompareVersions(b.Version, a.Version))
+	})
+	slices.SortStableFunc(report.OutOfPolicy, func(a, b *Device) int {
+		return cmp.Or(cmp.Compare(a.Site, b.Site), cmp.Compare(a.Name, b.Name))
+	})
+	return report
+}
+
+// Compliant returns true if every device meets the policy.
+func (r *Report) Compliant() bool {
+	return len(r.OutOfPolicy) == 0
+}
@@ -0,0 +1,78 @@
+package firmware_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/firmware"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"7.1.26", "7.1.26", 0},
+		{"7.1.26", "7.1.9", 1},
+		{"6.6.55", "7.0.0", -1},
+		{"7.1.26.15869", "7.1.26", 1},
+		{"v7.1.26", "7.1.26", 0},
+		{"7.1.26-beta", "7.1.26-rc", -1},
+		{"7.1.26-beta", "7.1.26.1", -1},
+		{"7.1.26-beta", "7.1.26", -1},
+		{"", "1.0.0", -1},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, firmware.CompareVersions(test.a, test.b), "%s vs %s", test.a, test.b)
+		assert.Equal(t, -test.want, firmware.CompareVersions(test.b, test.a), "%s vs %s", test.b, test.a)
+	}
+}
+
+func TestPolicyMinimum(t *testing.T) {
+	policy := firmware.Policy{"u6-pro": "6.6.77", firmware.AnyModel: "6.0.0"}
+
+	minimum, ok := policy.Minimum("U6-Pro")
+	assert.True(t, ok)
+	assert.Equal(t, "6.6.77", minimum)
+
+	minimum, ok = policy.Minimum("USW-24")
+	assert.True(t, ok)
+	assert.Equal(t, "6.0.0", minimum)
+
+	_, ok = firmware.Policy{}.Minimum("USW-24")
+	assert.False(t, ok)
+}
+
+func TestNewReport(t *testing.T) {
+	devices := []*firmware.Device{
+		{Site: "HQ", Name: "AP 1", Model: "U6-Pro", Version: "6.6.77", Compliant: true},
+		{Site: "HQ", Name: "AP 2", Model: "U6-Pro", Version: "6.5.62", Updatable: true, Compliant: true},
+		{Site: "Branch", Name: "AP 3", Model: "U6-Pro", Version: "6.6.77", Compliant: true},
+		{Site: "HQ", Name: "Switch", Model: "USW-24", Version: "7.1.26", Compliant: true},
+	}
+	report := firmware.NewReport(devices, firmware.Policy{"U6-Pro": "6.6.0"})
+
+	require.Len(t, report.Groups, 3)
+	newest, oldest, switches := report.Groups[0], report.Groups[1], report.Groups[2]
+
+	assert.Equal(t, "6.6.77", newest.Version)
+	assert.Len(t, newest.Devices, 2)
+	assert.False(t, newest.Uniform)
+	assert.True(t, newest.Compliant)
+
+	assert.Equal(t, "6.5.62", oldest.Version)
+	assert.Equal(t, 1, oldest.Updatable)
+	assert.False(t, oldest.Compliant)
+	assert.Equal(t, "6.6.0", oldest.Minimum)
+
+	assert.Equal(t, "USW-24", switches.Model)
+	assert.True(t, switches.Uniform)
+	assert.True(t, switches.Compliant)
+	assert.Empty(t, switches.Minimum)
+
+	assert.False(t, report.Compliant())
+	require.Len(t, report.OutOfPolicy, 1)
+	assert.Equal(t, "AP 2", report.OutOfPolicy[0].Name)
+}
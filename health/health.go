@@ -0,0 +1,291 @@
+// Package health checks a Network site's devices against alert thresholds.
+// A Monitor keeps track of which alerts are active between checks, so
+// numeric alerts only resolve once their value has dropped back by a margin,
+// rather than flapping around the threshold.
+package health
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Metric is what an alert is about.
+type Metric string
+
+const (
+	MetricState     Metric = "state"
+	MetricHeartbeat Metric = "heartbeat"
+	MetricCPU       Metric = "cpu"
+	MetricMemory    Metric = "memory"
+	MetricLoad      Metric = "load"
+	MetricTxRetries Metric = "txRetries"
+	MetricUplink    Metric = "uplink"
+)
+
+// Thresholds configure when alerts fire. A zero threshold disables its
+// check.
+type Thresholds struct {
+	// CPUPct and MemoryPct are utilization percentages.
+	CPUPct    float64
+	MemoryPct float64
+	// LoadAverage is the highest 1 minute load average. It is off by default,
+	// as what is too high depends on the device's number of cores.
+	LoadAverage float64
+	// TxRetriesPct is the highest percentage of retried transmissions on any
+	// radio.
+	TxRetriesPct float64
+	// UplinkPct is the highest uplink utilization, as a percentage of
+	// UplinkCapacityMbps in either direction. The API doesn't report uplink
+	// link speeds, so the capacity must be given.
+	UplinkPct          float64
+	UplinkCapacityMbps float64
+	// HeartbeatGrace is how far past its next expected heartbeat a device
+	// may be before it counts as missed. Heartbeats are always checked.
+	HeartbeatGrace time.Duration
+	// Hysteresis is the fraction of its threshold a numeric value must drop
+	// below the threshold before its alert resolves, e.g. 0.1 resolves a 90%
+	// CPU alert at 81%.
+	Hysteresis float64
+}
+
+// DefaultThresholds are the thresholds used unless configured otherwise.
+var DefaultThresholds = Thresholds{
+	CPUPct:             90,
+	MemoryPct:          90,
+	TxRetriesPct:       20,
+	UplinkPct:          90,
+	UplinkCapacityMbps: 1000,
+	HeartbeatGrace:     time.Minute,
+	Hysteresis:         0.1,
+}
+
+// Sample is a device and its statistics at one point in time.
+type Sample struct {
+	Device *types.DeviceListEntry
+	// Statistics is nil if the device isn't online, or they couldn't be
+	// fetched.
+	Statistics *types.DeviceStatistics
+	At         time.Time
+}
+
+// Fetch samples every device of a site. Statistics are only fetched for
+// online devices, a few at a time.
+func Fetch(network types.NetworkV1, siteID types.SiteID) ([]*Sample, error) {
+	devices, err := client.AllDevices(network, siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	online := []*types.DeviceListEntry{}
+	for _, device := range devices {
+		if device.State == types.DeviceStateOnline {
+			online = append(online, device)
+		}
+	}
+	statistics := map[string]*types.DeviceStatistics{}
+	for _, result := range client.FetchDeviceStatistics(network, siteID, online, client.DefaultStatisticsWorkers) {
+		if result.Err != nil {
+			log.WithField("device", result.Device.Name).Warnf("Couldn't fetch statistics: %s", result.Err.Error())
+			continue
+		}
+		statistics[result.Device.ID] = result.Statistics
+	}
+
+	samples := make([]*Sample, 0, len(devices))
+	now := time.Now()
+	for _, device := range devices {
+		samples = append(samples, &Sample{Device: device, Statistics: statistics[device.ID], At: now})
+	}
+	return samples, nil
+}
+
+// Alert is a threshold a device is breaching.
+type Alert struct {
+	DeviceID   string `json:"deviceId"`
+	DeviceName string `json:"deviceName"`
+	Metric     Metric `json:"metric"`
+	// Subject narrows down the metric, e.g. which radio, if needed.
+	Subject   string  `json:"subject,omitempty"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Message   string  `json:"message"`
+	// Since is when the alert fired.
+	Since time.Time `json:"since"`
+}
+
+func (a *Alert) key() string {
+	return a.DeviceID + "/" + string(a.Metric) + "/" + a.Subject
+}
+
+// TransitionType is whether an alert fired or resolved.
+type TransitionType string
+
+const (
+	TransitionAlert   TransitionType = "alert"
+	TransitionResolve TransitionType = "resolve"
+)
+
+// Transition is an alert firing or resolving.
+type Transition struct {
+	Type  TransitionType `json:"type"`
+	At    time.Time      `json:"at"`
+	Alert *Alert         `json:"alert"`
+}
+
+// Monitor evaluates samples against thresholds, remembering which alerts are
+// active.
+type Monitor struct {
+	thresholds Thresholds
+	active     map[string]*Alert
+}
+
+// NewMonitor returns a Monitor with no active alerts.
+func NewMonitor(thresholds Thresholds) *Monitor {
+	return &Monitor{thresholds: thresholds, active: map[string]*Alert{}}
+}
+
+// A single evaluation of a metric.
+type reading struct {
+	alert  *Alert
+	firing bool
+}
+
+// Checks a numeric value against a threshold. An active alert only resolves
+// once the value drops below the threshold by the hysteresis margin.
+func (m *Monitor) checkValue(alert *Alert) *reading {
+	limit := alert.Threshold
+	if _, active := m.active[alert.key()]; active {
+		limit -= alert.Threshold * m.thresholds.Hysteresis
+	}
+	return &reading{alert: alert, firing: alert.Value > limit}
+}
+
+func (m *Monitor) evaluate(sample *Sample) []*reading {
+	th := m.thresholds
+	device := sample.Device
+	newAlert := func(metric Metric, subject string, value, threshold float64, format string) *Alert {
+		return &Alert{
+			DeviceID:   device.ID,
+			DeviceName: device.Name,
+			Metric:     metric,
+			Subject:    subject,
+			Value:      value,
+			Threshold:  threshold,
+			Message:    fmt.Sprintf(format, value, threshold),
+			Since:      sample.At,
+		}
+	}
+
+	readings := []*reading{{
+		alert: &Alert{
+			DeviceID:   device.ID,
+			DeviceName: device.Name,
+			Metric:     MetricState,
+			Message:    fmt.Sprintf("device is %s", device.State),
+			Since:      sample.At,
+		},
+		firing: device.State != types.DeviceStateOnline,
+	}}
+
+	stats := sample.Statistics
+	if stats == nil {
+		return readings
+	}
+
+	if !stats.NextHeartbeatAt.IsZero() {
+		overdue := sample.At.Sub(stats.NextHeartbeatAt)
+		readings = append(readings, &reading{
+			alert: newAlert(MetricHeartbeat, "", overdue.Round(time.Second).Seconds(), th.HeartbeatGrace.Seconds(),
+				"heartbeat overdue by %.0fs, allowed %.0fs"),
+			firing: overdue > th.HeartbeatGrace,
+		})
+	}
+	if th.CPUPct > 0 {
+		readings = append(readings, m.checkValue(
+			newAlert(MetricCPU, "", stats.CPUUtilizationPct, th.CPUPct, "CPU at %.1f%%, above %.0f%%")))
+	}
+	if th.MemoryPct > 0 {
+		readings = append(readings, m.checkValue(
+			newAlert(MetricMemory, "", stats.MemoryUtilizationPct, th.MemoryPct, "memory at %.1f%%, above %.0f%%")))
+	}
+	if th.LoadAverage > 0 {
+		readings = append(readings, m.checkValue(
+			newAlert(MetricLoad, "", stats.LoadAverage1Min, th.LoadAverage, "1 minute load average %.2f, above %.2f")))
+	}
+	if th.TxRetriesPct > 0 {
+		for _, radio := range stats.Interfaces.Radios {
+			subject := fmt.Sprintf("%gGHz", radio.FrequencyGHz)
+			readings = append(readings, m.checkValue(newAlert(MetricTxRetries, subject, radio.TxRetriesPct,
+				th.TxRetriesPct, subject+" radio retrying %.1f%% of transmissions, above %.0f%%")))
+		}
+	}
+	if th.UplinkPct > 0 && th.UplinkCapacityMbps > 0 {
+		busiest := float64(max(stats.Uplink.TxRateBps, stats.Uplink.RxRateBps))
+		utilization := busiest / (th.UplinkCapacityMbps * 1e6) * 100
+		readings = append(readings, m.checkValue(
+			newAlert(MetricUplink, "", utilization, th.UplinkPct, "uplink at %.1f%% of capacity, above %.0f%%")))
+	}
+	return readings
+}
+
+// Update evaluates a new set of samples and returns the alerts which fired or
+// resolved since the last update. Alerts of devices which are no longer
+// sampled are resolved. Alerts of numeric metrics which couldn't be read
+// this time, e.g. because statistics couldn't be fetched, are left as they
+// are.
+func (m *Monitor) Update(samples []*Sample) []*Transition {
+	transitions := []*Transition{}
+	seenDevices := map[string]bool{}
+	now := time.Now()
+
+	for _, sample := range samples {
+		seenDevices[sample.Device.ID] = true
+		if !sample.At.IsZero() {
+			now = sample.At
+		}
+		for _, reading := range m.evaluate(sample) {
+			key := reading.alert.key()
+			active, isActive := m.active[key]
+			switch {
+			case reading.firing && !isActive:
+				m.active[key] = reading.alert
+				transitions = append(transitions, &Transition{Type: TransitionAlert, At: sample.At, Alert: reading.alert})
+			case reading.firing && isActive:
+				active.Value = reading.alert.Value
+				active.Message = reading.alert.Message
+			case !reading.firing && isActive:
+				delete(m.active, key)
+				transitions = append(transitions, &Transition{Type: TransitionResolve, At: sample.At, Alert: active})
+			}
+		}
+	}
+
+	for key, alert := range m.active {
+		if !seenDevices[alert.DeviceID] {
+			delete(m.active, key)
+			transitions = append(transitions, &Transition{Type: TransitionResolve, At: now, Alert: alert})
+		}
+	}
+	return transitions
+}
+
+// Active returns the active alerts, ordered by device name, metric and
+// subject.
+func (m *Monitor) Active() []*Alert {
+	alerts := make([]*Alert, 0, len(m.active))
+	for _, alert := range m.active {
+		alerts = append(alerts, alert)
+	}
+	slices.SortFunc(alerts, func(a, b *Alert) int {
+		return cmp.Or(cmp.Compare(a.DeviceName, b.DeviceName), cmp.Compare(a.DeviceID, b.DeviceID),
+			cmp.Compare(a.Metric, b.Metric), cmp.Compare(a.Subject, b.Subject))
+	})
+	return alerts
+}
@@ -0,0 +1,84 @@
+package health_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/health"
+	"github.com/ClifHouck/unified/types"
+)
+
+var now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func sample(id string, state types.DeviceState, stats *types.DeviceStatistics) *health.Sample {
+	return &health.Sample{
+		Device:     &types.DeviceListEntry{ID: id, Name: "Device " + id, State: state},
+		Statistics: stats,
+		At:         now,
+	}
+}
+
+func cpuStats(pct float64) *types.DeviceStatistics {
+	return &types.DeviceStatistics{CPUUtilizationPct: pct, NextHeartbeatAt: now.Add(time.Second * 10)}
+}
+
+func summarize(transitions []*health.Transition) []string {
+	summary := []string{}
+	for _, transition := range transitions {
+		summary = append(summary,
+			string(transition.Type)+" "+transition.Alert.DeviceID+" "+string(transition.Alert.Metric))
+	}
+	return summary
+}
+
+func TestMonitorHysteresis(t *testing.T) {
+	monitor := health.NewMonitor(health.DefaultThresholds)
+
+	assert.Empty(t, monitor.Update([]*health.Sample{sample("ap", types.DeviceStateOnline, cpuStats(50))}))
+	assert.Equal(t, []string{"alert ap cpu"},
+		summarize(monitor.Update([]*health.Sample{sample("ap", types.DeviceStateOnline, cpuStats(95))})))
+
+	// Dropping just below the threshold doesn't resolve the alert.
+	assert.Empty(t, monitor.Update([]*health.Sample{sample("ap", types.DeviceStateOnline, cpuStats(85))}))
+	active := monitor.Active()
+	require.Len(t, active, 1)
+	assert.InDelta(t, 85, active[0].Value, 0.001)
+
+	assert.Equal(t, []string{"resolve ap cpu"},
+		summarize(monitor.Update([]*health.Sample{sample("ap", types.DeviceStateOnline, cpuStats(80))})))
+	assert.Empty(t, monitor.Active())
+}
+
+func TestMonitorChecks(t *testing.T) {
+	monitor := health.NewMonitor(health.DefaultThresholds)
+
+	stats := cpuStats(10)
+	stats.NextHeartbeatAt = now.Add(-time.Minute * 5)
+	stats.MemoryUtilizationPct = 99
+	stats.Uplink.TxRateBps = 950_000_000
+	stats.Interfaces.Radios = append(stats.Interfaces.Radios, struct {
+		FrequencyGHz float64 `json:"frequencyGHz"`
+		TxRetriesPct float64 `json:"txRetriesPct"`
+	}{FrequencyGHz: 5, TxRetriesPct: 35})
+
+	transitions := monitor.Update([]*health.Sample{
+		sample("sw", types.DeviceStateOnline, stats),
+		sample("ap", types.DeviceStateOffline, nil),
+	})
+	assert.ElementsMatch(t, []string{
+		"alert sw heartbeat", "alert sw memory", "alert sw uplink", "alert sw txRetries", "alert ap state",
+	}, summarize(transitions))
+
+	for _, alert := range monitor.Active() {
+		if alert.Metric == health.MetricTxRetries {
+			assert.Equal(t, "5GHz", alert.Subject)
+		}
+	}
+
+	// Devices which disappear have their alerts resolved.
+	transitions = monitor.Update([]*health.Sample{sample("sw", types.DeviceStateOnline, stats)})
+	assert.Equal(t, []string{"resolve ap state"}, summarize(transitions))
+}
@@ -0,0 +1,274 @@
+package kiosk
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ClifHouck/unified/types"
+	"github.com/ClifHouck/unified/vouchers"
+)
+
+const sessionCookie = "kiosk_session"
+
+//go:embed kiosk.html
+var pagesTemplate string
+
+// The kiosk's pages, which show vouchers as cards of a sheet.
+var pages = template.Must(vouchers.NewTemplate().Parse(pagesTemplate))
+
+// What the kiosk's pages are executed with.
+type page struct {
+	Title string
+	// The staff signed in, if any.
+	Staff     string
+	Error     string
+	Templates []*Template
+	// A sheet of the voucher generated.
+	Sheet *vouchers.Sheet
+}
+
+// VoucherResponse is the API's response to a voucher being generated.
+type VoucherResponse struct {
+	Voucher *types.Voucher `json:"voucher"`
+	// The code, formatted as it's printed.
+	Code string `json:"code"`
+	// An SVG image of the voucher's QR code.
+	QRSVG string `json:"qrSvg"`
+}
+
+type generateRequest struct {
+	Template string `json:"template"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) routes() {
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("POST /login", s.handleLogin)
+	s.mux.HandleFunc("POST /logout", s.handleLogout)
+	s.mux.HandleFunc("POST /vouchers", s.handleGenerate)
+	s.mux.HandleFunc("GET /vouchers/{id}", s.handleVoucher)
+	s.mux.HandleFunc("GET /api/templates", s.handleAPITemplates)
+	s.mux.HandleFunc("POST /api/vouchers", s.handleAPIGenerate)
+}
+
+// Returns the address of the client, without its port. Behind a trusted proxy,
+// that's the address the proxy added to X-Forwarded-For last, as clients can
+// send any addresses before it.
+func (s *Server) clientAddress(r *http.Request) string {
+	if s.config.TrustProxy {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			addresses := strings.Split(forwarded[len(forwarded)-1], ",")
+			address := strings.TrimSpace(addresses[len(addresses)-1])
+			if address != "" {
+				return address
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Returns true if the client connected with TLS, to the kiosk or its proxy.
+func (s *Server) secure(r *http.Request) bool {
+	return r.TLS != nil || (s.config.TrustProxy && r.Header.Get("X-Forwarded-Proto") == "https")
+}
+
+// Returns the staff signed in with the request's session cookie, or "".
+func (s *Server) signedIn(r *http.Request) string {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+	return s.sessionStaff(cookie.Value)
+}
+
+func (s *Server) render(w http.ResponseWriter, status int, name string, data *page) {
+	data.Title = s.config.Sheet.Title
+	if data.Title == "" {
+		data.Title = "Voucher Kiosk"
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	err := pages.ExecuteTemplate(w, name, data)
+	if err != nil {
+		s.config.Log.Errorf("Rendering kiosk page %s: %s", name, err.Error())
+	}
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	staff := s.signedIn(r)
+	if staff == "" {
+		s.render(w, http.StatusOK, "login", &page{})
+		return
+	}
+	s.render(w, http.StatusOK, "templates", &page{Staff: staff, Templates: s.config.Templates})
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	staff, err := s.authenticate(s.clientAddress(r), r.PostFormValue("pin"))
+	switch {
+	case errors.Is(err, errLocked):
+		s.render(w, http.StatusTooManyRequests, "login", &page{Error: err.Error()})
+		return
+	case err != nil:
+		s.render(w, http.StatusUnauthorized, "login", &page{Error: err.Error()})
+		return
+	}
+
+	id, err := s.startSession(staff)
+	if err != nil {
+		s.render(w, http.StatusInternalServerError, "login", &page{Error: err.Error()})
+		return
+	}
+	s.config.Log.WithField("staff", staff).Info("Staff signed in to the kiosk")
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(SessionLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   s.secure(r),
+		// Other sites can't submit the kiosk's forms for staff.
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err == nil {
+		s.endSession(cookie.Value)
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	staff := s.signedIn(r)
+	if staff == "" {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	voucherTemplate := s.template(r.PostFormValue("template"))
+	if voucherTemplate == nil {
+		s.render(w, http.StatusBadRequest, "templates",
+			&page{Staff: staff, Templates: s.config.Templates, Error: "Unknown voucher template"})
+		return
+	}
+	voucher, err := s.generate(staff, voucherTemplate)
+	if err != nil {
+		s.config.Log.WithField("staff", staff).Error(err.Error())
+		s.render(w, http.StatusBadGateway, "templates",
+			&page{Staff: staff, Templates: s.config.Templates, Error: "The voucher couldn't be generated: " + err.Error()})
+		return
+	}
+	// Redirect so reloading the page shows the voucher, rather than
+	// generating another.
+	http.Redirect(w, r, "/vouchers/"+voucher.ID, http.StatusSeeOther)
+}
+
+func (s *Server) handleVoucher(w http.ResponseWriter, r *http.Request) {
+	staff := s.signedIn(r)
+	if staff == "" {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	voucher, err := s.config.Network.VoucherDetails(s.config.SiteID, types.VoucherID(r.PathValue("id")))
+	if err != nil {
+		s.render(w, http.StatusBadGateway, "templates",
+			&page{Staff: staff, Templates: s.config.Templates, Error: "The voucher couldn't be found: " + err.Error()})
+		return
+	}
+	sheet, err := s.sheet(voucher)
+	if err != nil {
+		s.render(w, http.StatusInternalServerError, "templates",
+			&page{Staff: staff, Templates: s.config.Templates, Error: err.Error()})
+		return
+	}
+	s.render(w, http.StatusOK, "voucher", &page{Staff: staff, Sheet: sheet})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Returns the staff the API request is from, signed in with a session cookie
+// or with their PIN as a bearer token. Otherwise responds with an error and
+// returns "".
+func (s *Server) apiStaff(w http.ResponseWriter, r *http.Request) string {
+	staff := s.signedIn(r)
+	if staff != "" {
+		return staff
+	}
+	pin, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, &errorResponse{Error: "sign in, or send a PIN as a bearer token"})
+		return ""
+	}
+	staff, err := s.authenticate(s.clientAddress(r), pin)
+	switch {
+	case errors.Is(err, errLocked):
+		writeJSON(w, http.StatusTooManyRequests, &errorResponse{Error: err.Error()})
+	case err != nil:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, &errorResponse{Error: err.Error()})
+	}
+	return staff
+}
+
+func (s *Server) handleAPITemplates(w http.ResponseWriter, r *http.Request) {
+	if s.apiStaff(w, r) == "" {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.config.Templates)
+}
+
+func (s *Server) handleAPIGenerate(w http.ResponseWriter, r *http.Request) {
+	staff := s.apiStaff(w, r)
+	if staff == "" {
+		return
+	}
+	request := &generateRequest{}
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: "invalid request: " + err.Error()})
+		return
+	}
+	voucherTemplate := s.template(request.Template)
+	if voucherTemplate == nil {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: "unknown template '" + request.Template + "'"})
+		return
+	}
+
+	voucher, err := s.generate(staff, voucherTemplate)
+	if err != nil {
+		s.config.Log.WithField("staff", staff).Error(err.Error())
+		writeJSON(w, http.StatusBadGateway, &errorResponse{Error: err.Error()})
+		return
+	}
+	sheet, err := s.sheet(voucher)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, &errorResponse{Error: err.Error()})
+		return
+	}
+	card := sheet.Cards[0]
+	writeJSON(w, http.StatusCreated, &VoucherResponse{Voucher: voucher, Code: card.Code, QRSVG: card.QR.SVG()})
+}
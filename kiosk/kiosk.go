@@ -0,0 +1,330 @@
+// Package kiosk serves a web page and JSON API where staff sign in with a PIN,
+// generate hotspot vouchers from a set of templates, and print them, without
+// an account on the UniFi console.
+package kiosk
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/types"
+	"github.com/ClifHouck/unified/vouchers"
+)
+
+const (
+	// SessionLifetime is how long staff stay signed in.
+	SessionLifetime = time.Hour * 12
+	// After this many wrong PINs in a row, a client is locked out. Each
+	// lockout in a row lasts twice as long as the last, up to maxLockout.
+	maxFailures = 5
+	lockout     = time.Minute
+	maxLockout  = time.Hour
+	// Wrong PINs from all clients together are limited by a bucket of this
+	// many, which refills one every globalRefill. While it's empty, every
+	// attempt is slowed by throttleDelay, so guessing from many addresses
+	// doesn't help, but none are refused.
+	maxGlobalFailures = 20
+	globalRefill      = time.Second * 30
+	throttleDelay     = time.Second * 2
+	// Wrong PINs are forgotten after this long without another, once any
+	// lockout is over.
+	failureMemory = time.Hour
+	minPINLen     = 6
+)
+
+var (
+	errWrongPIN = errors.New("wrong PIN")
+	errLocked   = errors.New("too many wrong PINs")
+)
+
+// Template is a kind of voucher staff can generate, e.g. "1 day, 1 device".
+// Vouchers are named after their template.
+type Template struct {
+	Name            string `mapstructure:"name" json:"name"`
+	vouchers.Limits `mapstructure:",squash"`
+}
+
+// Config is what a kiosk generates vouchers with, and who may.
+type Config struct {
+	Network types.NetworkV1
+	SiteID  types.SiteID
+	// PINs by staff name. Vouchers are logged with who generated them.
+	Staff     map[string]string
+	Templates []*Template
+	// The title, footer and logo of the cards vouchers are shown on.
+	Sheet *vouchers.Sheet
+	// Executed with each voucher for its QR code.
+	QR  *texttemplate.Template
+	Log *logrus.Logger
+	// Whether the kiosk is behind a proxy, whose X-Forwarded-For and
+	// X-Forwarded-Proto headers are trusted. Otherwise every client would
+	// share the proxy's address, and be locked out together.
+	TrustProxy bool
+	// Times sessions and lockouts. Defaults to time.Now.
+	Now func() time.Time
+	// Waits while sign ins are throttled. Defaults to time.Sleep.
+	Sleep func(time.Duration)
+}
+
+type session struct {
+	staff   string
+	expires time.Time
+}
+
+type failures struct {
+	// Wrong PINs since the last lockout.
+	count int
+	// Lockouts in a row.
+	lockouts int
+	until    time.Time
+	last     time.Time
+}
+
+// Records a wrong PIN, returning how long it locks out for, if it does.
+func (f *failures) fail(now time.Time, limit int) time.Duration {
+	f.last = now
+	f.count++
+	if f.count < limit {
+		return 0
+	}
+	duration := min(lockout<<min(f.lockouts, 6), maxLockout)
+	f.count = 0
+	f.lockouts++
+	f.until = now.Add(duration)
+	return duration
+}
+
+// Returns how much longer the lockout lasts, if there is one.
+func (f *failures) lockedFor(now time.Time) time.Duration {
+	if f == nil {
+		return 0
+	}
+	return max(f.until.Sub(now), 0)
+}
+
+// Returns true once the failures can be forgotten.
+func (f *failures) stale(now time.Time) bool {
+	return !now.Before(f.until) && now.Sub(f.last) > failureMemory
+}
+
+// Server is a kiosk's HTTP handler.
+type Server struct {
+	config *Config
+	mux    *http.ServeMux
+	now    func() time.Time
+	sleep  func(time.Duration)
+
+	mutex    sync.Mutex
+	sessions map[string]*session
+	// Wrong PINs, by client address.
+	failures map[string]*failures
+	// The bucket of wrong PINs from every client, as of tokensAt.
+	tokens   float64
+	tokensAt time.Time
+}
+
+// New returns a kiosk, or an error describing the first problem with config.
+func New(config *Config) (*Server, error) {
+	if len(config.Staff) == 0 {
+		return nil, errors.New("kiosk has no staff to sign in")
+	}
+	pins := map[string]string{}
+	for staff, pin := range config.Staff {
+		if len(pin) < minPINLen {
+			return nil, fmt.Errorf("staff '%s' needs a PIN of at least %d characters", staff, minPINLen)
+		}
+		if other, ok := pins[pin]; ok {
+			return nil, fmt.Errorf("staff '%s' and '%s' share a PIN", other, staff)
+		}
+		pins[pin] = staff
+	}
+	if len(config.Templates) == 0 {
+		return nil, errors.New("kiosk has no voucher templates")
+	}
+	names := map[string]bool{}
+	for i, template := range config.Templates {
+		if template.Name == "" {
+			return nil, fmt.Errorf("template %d has no name", i+1)
+		}
+		if names[template.Name] {
+			return nil, fmt.Errorf("there's more than one template named '%s'", template.Name)
+		}
+		names[template.Name] = true
+	}
+
+	now := config.Now
+	if now == nil {
+		now = time.Now
+	}
+	sleep := config.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	server := &Server{
+		config:   config,
+		now:      now,
+		sleep:    sleep,
+		sessions: map[string]*session{},
+		failures: map[string]*failures{},
+		tokens:   maxGlobalFailures,
+		tokensAt: now(),
+	}
+	server.routes()
+	return server, nil
+}
+
+// ServeHTTP serves the kiosk's pages and API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Returns the staff whose PIN it is. Clients are locked out after too many
+// wrong PINs, and everyone is slowed down after too many from all clients.
+func (s *Server) authenticate(client, pin string) (string, error) {
+	s.mutex.Lock()
+	throttled := s.refill(s.now()) < 1
+	s.mutex.Unlock()
+	if throttled {
+		s.sleep(throttleDelay)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.forgetFailures(now)
+	wait := s.failures[client].lockedFor(now)
+	if wait > 0 {
+		return "", fmt.Errorf("%w, try again in %s", errLocked, wait.Round(time.Second))
+	}
+
+	for staff, staffPIN := range s.config.Staff {
+		if subtle.ConstantTimeCompare([]byte(pin), []byte(staffPIN)) == 1 {
+			delete(s.failures, client)
+			return staff, nil
+		}
+	}
+
+	failed := s.failures[client]
+	if failed == nil {
+		failed = &failures{}
+		s.failures[client] = failed
+	}
+	duration := failed.fail(now, maxFailures)
+	if duration > 0 {
+		s.config.Log.WithFields(logrus.Fields{"client": client, "duration": duration}).
+			Warn("Kiosk client locked out after too many wrong PINs")
+	}
+	if s.refill(now) >= 1 {
+		s.tokens--
+		if s.tokens < 1 {
+			s.config.Log.Warn("Kiosk slowing down sign ins after too many wrong PINs")
+		}
+	}
+	return "", errWrongPIN
+}
+
+// Refills the bucket of wrong PINs from every client as of now, and returns
+// how many it holds.
+func (s *Server) refill(now time.Time) float64 {
+	if now.After(s.tokensAt) {
+		s.tokens = min(s.tokens+float64(now.Sub(s.tokensAt))/float64(globalRefill), maxGlobalFailures)
+		s.tokensAt = now
+	}
+	return s.tokens
+}
+
+// Drops the failures which can be forgotten.
+func (s *Server) forgetFailures(now time.Time) {
+	for client, failed := range s.failures {
+		if failed.stale(now) {
+			delete(s.failures, client)
+		}
+	}
+}
+
+// Starts a session for the staff, returning its token.
+func (s *Server) startSession(staff string) (string, error) {
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	for id, session := range s.sessions {
+		if now.After(session.expires) {
+			delete(s.sessions, id)
+		}
+	}
+	id := hex.EncodeToString(token)
+	s.sessions[id] = &session{staff: staff, expires: now.Add(SessionLifetime)}
+	return id, nil
+}
+
+func (s *Server) endSession(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.sessions, id)
+}
+
+// Returns the staff signed in to the session, or "".
+func (s *Server) sessionStaff(id string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	session, ok := s.sessions[id]
+	if !ok || s.now().After(session.expires) {
+		return ""
+	}
+	return session.staff
+}
+
+func (s *Server) template(name string) *Template {
+	for _, template := range s.config.Templates {
+		if template.Name == name {
+			return template
+		}
+	}
+	return nil
+}
+
+// Generates a voucher of the template, logging who for.
+func (s *Server) generate(staff string, template *Template) (*types.Voucher, error) {
+	generated, err := s.config.Network.VoucherGenerate(s.config.SiteID, template.Request(template.Name, 1))
+	if err != nil {
+		return nil, err
+	}
+	if len(generated) == 0 {
+		return nil, errors.New("no voucher was generated")
+	}
+	voucher := generated[0]
+	s.config.Log.WithFields(logrus.Fields{
+		"staff":    staff,
+		"template": template.Name,
+		"id":       voucher.ID,
+		"code":     voucher.Code,
+	}).Info("Generated voucher")
+	return voucher, nil
+}
+
+// Returns a sheet of a single card, of the voucher.
+func (s *Server) sheet(voucher *types.Voucher) (*vouchers.Sheet, error) {
+	sheet := *s.config.Sheet
+	sheet.Cards = nil
+	err := sheet.AddCards([]*types.Voucher{voucher}, s.config.QR)
+	if err != nil {
+		return nil, err
+	}
+	return &sheet, nil
+}
@@ -0,0 +1,337 @@
+package kiosk_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/kiosk"
+	"github.com/ClifHouck/unified/types"
+	"github.com/ClifHouck/unified/vouchers"
+)
+
+// Generates vouchers with sequential codes, and records the requests.
+type kioskNetwork struct {
+	types.NetworkV1
+
+	vouchers  map[types.VoucherID]*types.Voucher
+	generated []*types.VoucherGenerateRequest
+	err       error
+}
+
+func (kn *kioskNetwork) VoucherGenerate(
+	_ types.SiteID, request *types.VoucherGenerateRequest,
+) ([]*types.Voucher, error) {
+	if kn.err != nil {
+		return nil, kn.err
+	}
+	kn.generated = append(kn.generated, request)
+	voucher := &types.Voucher{
+		ID:                   fmt.Sprintf("voucher-%d", len(kn.generated)),
+		Code:                 fmt.Sprintf("12345%05d", len(kn.generated)),
+		Name:                 request.Name,
+		AuthorizedGuestLimit: request.AuthorizedGuestLimit,
+		TimeLimitMinutes:     request.TimeLimitMinutes,
+	}
+	kn.vouchers[types.VoucherID(voucher.ID)] = voucher
+	return []*types.Voucher{voucher}, nil
+}
+
+func (kn *kioskNetwork) VoucherDetails(_ types.SiteID, id types.VoucherID) (*types.Voucher, error) {
+	voucher, ok := kn.vouchers[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return voucher, nil
+}
+
+type testKiosk struct {
+	network *kioskNetwork
+	server  *kiosk.Server
+	now     time.Time
+	slept   []time.Duration
+}
+
+func newConfig() *kiosk.Config {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return &kiosk.Config{
+		SiteID: "site",
+		Staff:  map[string]string{"alex": "482193", "sam": "935614"},
+		Templates: []*kiosk.Template{
+			{Name: "1 day", Limits: vouchers.Limits{TimeLimitMinutes: 1440, AuthorizedGuestLimit: 1}},
+			{Name: "Conference", Limits: vouchers.Limits{TimeLimitMinutes: 4320, RxRateLimitKbps: 10000}},
+		},
+		Sheet: &vouchers.Sheet{Title: "Guest Wi-Fi"},
+		QR:    template.Must(template.New("qr").Parse("{{.Code}}")),
+		Log:   log,
+	}
+}
+
+func newTestKiosk(t *testing.T, modify ...func(*kiosk.Config)) *testKiosk {
+	tk := &testKiosk{
+		network: &kioskNetwork{vouchers: map[types.VoucherID]*types.Voucher{}},
+		now:     time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	config := newConfig()
+	config.Network = tk.network
+	config.Now = func() time.Time { return tk.now }
+	config.Sleep = func(duration time.Duration) { tk.slept = append(tk.slept, duration) }
+	for _, m := range modify {
+		m(config)
+	}
+	server, err := kiosk.New(config)
+	require.NoError(t, err)
+	tk.server = server
+	return tk
+}
+
+func (tk *testKiosk) do(request *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	tk.server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (tk *testKiosk) post(path string, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	return tk.do(request)
+}
+
+func (tk *testKiosk) get(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	return tk.do(request)
+}
+
+func (tk *testKiosk) login(t *testing.T, pin string) *http.Cookie {
+	response := tk.post("/login", url.Values{"pin": {pin}})
+	require.Equal(t, http.StatusSeeOther, response.Code)
+	cookies := response.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+	return cookies[0]
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		modify func(*kiosk.Config)
+		err    string
+	}{
+		{func(c *kiosk.Config) { c.Staff = nil }, "kiosk has no staff to sign in"},
+		{func(c *kiosk.Config) { c.Staff["alex"] = "1234" }, "staff 'alex' needs a PIN of at least 6 characters"},
+		{func(c *kiosk.Config) { c.Staff = map[string]string{"alex": "123456"}; c.Templates = nil },
+			"kiosk has no voucher templates"},
+		{func(c *kiosk.Config) { c.Templates[1].Name = "" }, "template 2 has no name"},
+		{func(c *kiosk.Config) { c.Templates[1].Name = "1 day" }, "there's more than one template named '1 day'"},
+	}
+	for _, test := range tests {
+		config := newConfig()
+		test.modify(config)
+		_, err := kiosk.New(config)
+		require.EqualError(t, err, test.err)
+	}
+
+	config := newConfig()
+	config.Staff["sam"] = "482193"
+	_, err := kiosk.New(config)
+	require.ErrorContains(t, err, "share a PIN")
+}
+
+func TestGenerateVoucher(t *testing.T) {
+	tk := newTestKiosk(t)
+
+	response := tk.get("/")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `action="/login"`)
+
+	cookie := tk.login(t, "935614")
+	response = tk.get("/", cookie)
+	assert.Contains(t, response.Body.String(), "Conference")
+	assert.Contains(t, response.Body.String(), "10 Mbps down")
+
+	response = tk.post("/vouchers", url.Values{"template": {"1 day"}}, cookie)
+	require.Equal(t, http.StatusSeeOther, response.Code)
+	assert.Equal(t, "/vouchers/voucher-1", response.Header().Get("Location"))
+	assert.Equal(t, []*types.VoucherGenerateRequest{
+		{Count: 1, Name: "1 day", AuthorizedGuestLimit: 1, TimeLimitMinutes: 1440},
+	}, tk.network.generated)
+
+	response = tk.get("/vouchers/voucher-1", cookie)
+	require.Equal(t, http.StatusOK, response.Code)
+	body := response.Body.String()
+	assert.Contains(t, body, "12345-00001")
+	assert.Contains(t, body, "<svg")
+	assert.Contains(t, body, "window.print()")
+
+	response = tk.post("/vouchers", url.Values{"template": {"1 week"}}, cookie)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Len(t, tk.network.generated, 1)
+
+	// Signed out, vouchers can't be generated or seen.
+	response = tk.post("/logout", nil, cookie)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+	response = tk.post("/vouchers", url.Values{"template": {"1 day"}}, cookie)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+	assert.Equal(t, "/", response.Header().Get("Location"))
+	response = tk.get("/vouchers/voucher-1", cookie)
+	assert.Equal(t, "/", response.Header().Get("Location"))
+	assert.Len(t, tk.network.generated, 1)
+}
+
+func TestSessionExpires(t *testing.T) {
+	tk := newTestKiosk(t)
+	cookie := tk.login(t, "482193")
+	assert.Contains(t, tk.get("/", cookie).Body.String(), "alex")
+
+	tk.now = tk.now.Add(kiosk.SessionLifetime + time.Second)
+	assert.Contains(t, tk.get("/", cookie).Body.String(), `action="/login"`)
+}
+
+// Signs in from the address, with any headers a proxy added.
+func (tk *testKiosk) loginFrom(address, pin string, header http.Header) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{"pin": {pin}}.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	request.RemoteAddr = address + ":40000"
+	return tk.do(request)
+}
+
+func TestLockout(t *testing.T) {
+	tk := newTestKiosk(t)
+	for range 5 {
+		response := tk.post("/login", url.Values{"pin": {"000000"}})
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+		assert.Contains(t, response.Body.String(), "wrong PIN")
+	}
+
+	// Even the right PIN is refused while locked out.
+	response := tk.post("/login", url.Values{"pin": {"482193"}})
+	assert.Equal(t, http.StatusTooManyRequests, response.Code)
+	assert.Contains(t, response.Body.String(), "try again in 1m0s")
+
+	// Another client isn't locked out.
+	assert.Equal(t, http.StatusSeeOther, tk.loginFrom("192.0.2.2", "482193", nil).Code)
+
+	// Each lockout in a row lasts twice as long.
+	tk.now = tk.now.Add(time.Minute)
+	for range 5 {
+		assert.Equal(t, http.StatusUnauthorized, tk.post("/login", url.Values{"pin": {"000000"}}).Code)
+	}
+	tk.now = tk.now.Add(time.Minute)
+	assert.Equal(t, http.StatusTooManyRequests, tk.post("/login", url.Values{"pin": {"482193"}}).Code)
+	tk.now = tk.now.Add(time.Minute)
+	tk.login(t, "482193")
+
+	// Wrong PINs are forgotten after a while.
+	for range 4 {
+		assert.Equal(t, http.StatusUnauthorized, tk.post("/login", url.Values{"pin": {"000000"}}).Code)
+	}
+	tk.now = tk.now.Add(time.Hour * 2)
+	for range 4 {
+		assert.Equal(t, http.StatusUnauthorized, tk.post("/login", url.Values{"pin": {"000000"}}).Code)
+	}
+	tk.login(t, "482193")
+}
+
+func TestGlobalThrottle(t *testing.T) {
+	tk := newTestKiosk(t)
+	for i := range 20 {
+		address := fmt.Sprintf("198.51.100.%d", i)
+		assert.Equal(t, http.StatusUnauthorized, tk.loginFrom(address, "000000", nil).Code)
+	}
+	assert.Empty(t, tk.slept)
+
+	// Guessing from many addresses slows down every client, but doesn't
+	// refuse the right PIN.
+	assert.Equal(t, http.StatusUnauthorized, tk.loginFrom("198.51.100.20", "000000", nil).Code)
+	assert.Equal(t, http.StatusSeeOther, tk.loginFrom("192.0.2.2", "482193", nil).Code)
+	assert.Equal(t, []time.Duration{time.Second * 2, time.Second * 2}, tk.slept)
+
+	// The bucket refills over time.
+	tk.now = tk.now.Add(time.Second * 30)
+	assert.Equal(t, http.StatusSeeOther, tk.loginFrom("192.0.2.2", "482193", nil).Code)
+	assert.Len(t, tk.slept, 2)
+}
+
+func TestTrustProxy(t *testing.T) {
+	tk := newTestKiosk(t, func(config *kiosk.Config) { config.TrustProxy = true })
+	// A client can forge addresses before the one the proxy adds.
+	forged := http.Header{"X-Forwarded-For": {"192.0.2.9, 198.51.100.1"}}
+	for range 5 {
+		assert.Equal(t, http.StatusUnauthorized, tk.loginFrom("127.0.0.1", "000000", forged).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, tk.loginFrom("127.0.0.1", "482193", forged).Code)
+
+	// Other clients of the proxy aren't locked out.
+	response := tk.loginFrom("127.0.0.1", "482193",
+		http.Header{"X-Forwarded-For": {"198.51.100.2"}, "X-Forwarded-Proto": {"https"}})
+	require.Equal(t, http.StatusSeeOther, response.Code)
+	cookies := response.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].Secure)
+}
+
+func TestAPI(t *testing.T) {
+	tk := newTestKiosk(t)
+
+	generate := func(body, pin string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/vouchers", strings.NewReader(body))
+		if pin != "" {
+			request.Header.Set("Authorization", "Bearer "+pin)
+		}
+		return tk.do(request)
+	}
+
+	response := generate(`{"template": "1 day"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Equal(t, "Bearer", response.Header().Get("WWW-Authenticate"))
+	response = generate(`{"template": "1 day"}`, "000000")
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Empty(t, tk.network.generated)
+
+	response = generate(`{"template": "Conference"}`, "482193")
+	require.Equal(t, http.StatusCreated, response.Code)
+	voucherResponse := &kiosk.VoucherResponse{}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), voucherResponse))
+	assert.Equal(t, "voucher-1", voucherResponse.Voucher.ID)
+	assert.Equal(t, "12345-00001", voucherResponse.Code)
+	assert.True(t, strings.HasPrefix(voucherResponse.QRSVG, "<svg"))
+	assert.Equal(t, 10000, tk.network.generated[0].RxRateLimitKbps)
+
+	response = generate(`{"template": "1 week"}`, "482193")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "unknown template '1 week'")
+	response = generate(`{`, "482193")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	tk.network.err = errors.New("controller unreachable")
+	response = generate(`{"template": "1 day"}`, "482193")
+	assert.Equal(t, http.StatusBadGateway, response.Code)
+
+	cookie := tk.login(t, "482193")
+	response = tk.get("/api/templates", cookie)
+	require.Equal(t, http.StatusOK, response.Code)
+	templates := []*kiosk.Template{}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &templates))
+	assert.Equal(t, newConfig().Templates, templates)
+}
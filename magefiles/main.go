@@ -22,6 +22,7 @@ func BuildGenerators() error {
 	dest := GENERATE_STREAM_HANDLERS_BINARY
 	sources := []string{
 		"./tools/generators/generate_stream_handlers.go",
+		"./types/event_registry.go",
 		"./types/events.go",
 	}
 
@@ -44,7 +45,8 @@ func BuildGenerators() error {
 	return nil
 }
 
-// Runs the program which generates the client stream handlers.
+// Runs the program which generates the client stream handlers, event
+// unmarshalling and event docs from types.EventRegistry.
 func GenerateStreamHandlers() error {
 	mg.Deps(BuildGenerators)
 
@@ -52,11 +54,14 @@ func GenerateStreamHandlers() error {
 	destFiles := []string{
 		"./client/protect_device_update_stream_handler.go",
 		"./client/protect_event_stream_handler.go",
+		"./client/network_event_stream_handler.go",
+		"./types/events_generated.go",
+		"./docs/events.md",
 	}
 
 	logger := log.WithFields(log.Fields{
 		"source":      source,
-		"destination": "./client/*_stream_handler.go"})
+		"destination": destFiles})
 
 	var outOfDate bool
 	for _, dest := range destFiles {
@@ -101,8 +106,19 @@ func Build() error {
 	})
 
 	outOfDate, err := target.Glob(dest,
+		"./audit/*.go",
+		"./cep/*.go",
 		"./client/*.go",
+		"./firmware/*.go",
+		"./health/*.go",
+		"./kiosk/*",
+		"./oui/*",
+		"./qr/*.go",
+		"./restart/*.go",
+		"./stats/*.go",
+		"./topology/*.go",
 		"./types/*.go",
+		"./vouchers/*",
 	)
 	if err != nil {
 		return err
@@ -219,6 +235,7 @@ func Clean() error {
 		// TODO: Should these be checked-in or should they always be re-generated?
 		"./client/protect_device_update_stream_handler.go",
 		"./client/protect_event_stream_handler.go",
+		"./client/network_event_stream_handler.go",
 	}
 	for _, file := range files {
 		err := os.Remove(file)
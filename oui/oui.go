@@ -0,0 +1,147 @@
+// Package oui looks up the manufacturer of a network interface from the
+// Organizationally Unique Identifier (OUI) in the first three bytes of its MAC
+// address. Lookups are done offline, against a small built-in table of common
+// vendors, which can be extended with a copy of the IEEE registry.
+package oui
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+//go:embed vendors.txt
+var builtinVendors string
+
+// RandomizedVendor is returned by Lookup for locally administered MAC
+// addresses, which most phones and laptops use for privacy on Wi-Fi. These
+// have no manufacturer.
+const RandomizedVendor = "(randomized)"
+
+// Database maps OUIs to vendor names.
+type Database struct {
+	vendors map[[3]byte]string
+}
+
+var (
+	builtin     *Database
+	builtinOnce sync.Once
+)
+
+// Builtin returns the database of common vendors compiled into unified.
+func Builtin() *Database {
+	builtinOnce.Do(func() {
+		var err error
+		builtin, err = Parse(strings.NewReader(builtinVendors))
+		if err != nil {
+			panic(fmt.Sprintf("oui: invalid built-in vendor table: %s", err))
+		}
+	})
+	return builtin
+}
+
+// Parse reads a vendor table. Each line is an OUI followed by a vendor name,
+// and blank lines and lines starting with '#' are ignored. The formats of the
+// IEEE's oui.txt and Wireshark's manuf file are both understood, e.g.:
+//
+//	00-00-0C   (hex)		Cisco Systems, Inc
+//	00:00:0C	Cisco	Cisco Systems, Inc
+//	00000C Cisco Systems, Inc
+//
+// Lines which don't start with a 24 bit OUI are skipped, which includes
+// Wireshark's MA-M and MA-S ranges.
+func Parse(r io.Reader) (*Database, error) {
+	db := &Database{vendors: map[[3]byte]string{}}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, vendor, ok := strings.Cut(line, " ")
+		if tab, tabVendor, tabOk := strings.Cut(line, "\t"); tabOk && (!ok || len(tab) < len(prefix)) {
+			prefix, vendor, ok = tab, tabVendor, true
+		}
+		if !ok {
+			continue
+		}
+		oui, valid := parseOUI(prefix)
+		if !valid {
+			continue
+		}
+		vendor = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(vendor), "(hex)"))
+		// Wireshark's manuf has a short and a long name. Prefer the long one.
+		if i := strings.LastIndex(vendor, "\t"); i >= 0 {
+			vendor = strings.TrimSpace(vendor[i+1:])
+		}
+		if vendor != "" {
+			db.vendors[oui] = vendor
+		}
+	}
+	return db, scanner.Err()
+}
+
+// Load reads a vendor table from a file. See Parse for its format.
+func Load(path string) (*Database, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Merge returns a database with the vendors of both db and other. Where
+// both have an OUI, other's vendor is used.
+func (db *Database) Merge(other *Database) *Database {
+	merged := &Database{vendors: make(map[[3]byte]string, len(db.vendors)+len(other.vendors))}
+	for oui, vendor := range db.vendors {
+		merged.vendors[oui] = vendor
+	}
+	for oui, vendor := range other.vendors {
+		merged.vendors[oui] = vendor
+	}
+	return merged
+}
+
+// Len returns the number of vendors in the database.
+func (db *Database) Len() int {
+	return len(db.vendors)
+}
+
+// Lookup returns the manufacturer of the interface with the given MAC
+// address, which may be separated by colons, dashes or dots, or not at all.
+// Locally administered addresses give RandomizedVendor.
+func (db *Database) Lookup(mac string) (string, bool) {
+	oui, ok := parseOUI(mac)
+	if !ok {
+		return "", false
+	}
+	if IsLocallyAdministered(oui) {
+		return RandomizedVendor, true
+	}
+	vendor, ok := db.vendors[oui]
+	return vendor, ok
+}
+
+// IsLocallyAdministered returns true if the OUI has the locally administered
+// bit set, meaning it was made up rather than assigned to a manufacturer.
+func IsLocallyAdministered(oui [3]byte) bool {
+	return oui[0]&0x02 != 0
+}
+
+// Parses the OUI from the start of a MAC address or OUI prefix.
+func parseOUI(text string) ([3]byte, bool) {
+	var oui [3]byte
+	digits := strings.NewReplacer(":", "", "-", "", ".", "").Replace(text)
+	if len(digits) < 6 || (len(digits) > 6 && len(digits) != 12) {
+		return oui, false
+	}
+	_, err := hex.Decode(oui[:], []byte(digits[:6]))
+	return oui, err == nil
+}
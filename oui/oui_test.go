@@ -0,0 +1,60 @@
+package oui_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/oui"
+)
+
+func TestBuiltinLookup(t *testing.T) {
+	db := oui.Builtin()
+
+	for _, mac := range []string{"b8:27:eb:12:34:56", "B8-27-EB-12-34-56", "b827.eb12.3456", "b827eb123456"} {
+		vendor, ok := db.Lookup(mac)
+		require.True(t, ok, mac)
+		assert.Equal(t, "Raspberry Pi Foundation", vendor)
+	}
+
+	vendor, ok := db.Lookup("da:a1:19:00:00:01")
+	require.True(t, ok)
+	assert.Equal(t, oui.RandomizedVendor, vendor)
+
+	_, ok = db.Lookup("00:00:01:00:00:00")
+	assert.False(t, ok)
+	_, ok = db.Lookup("not a mac")
+	assert.False(t, ok)
+}
+
+func TestParseFormats(t *testing.T) {
+	db, err := oui.Parse(strings.NewReader(`
+# IEEE oui.txt
+00-00-0C   (hex)		Cisco Systems, Inc
+00000C     (base 16)		Cisco Systems, Inc
+				170 West Tasman Drive
+
+# Wireshark manuf
+00:00:0E	Fujitsu	Fujitsu Limited
+00:1B:C5:00:00:00/36	Convergi	Converging Systems Inc.
+
+# Plain
+00000F NEXT, INC.
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 3, db.Len())
+
+	vendor, _ := db.Lookup("00:00:0c:01:02:03")
+	assert.Equal(t, "Cisco Systems, Inc", vendor)
+	vendor, _ = db.Lookup("00:00:0e:01:02:03")
+	assert.Equal(t, "Fujitsu Limited", vendor)
+	vendor, _ = db.Lookup("00:00:0f:01:02:03")
+	assert.Equal(t, "NEXT, INC.", vendor)
+
+	merged := oui.Builtin().Merge(db)
+	assert.Equal(t, oui.Builtin().Len()+3-1, merged.Len())
+	vendor, _ = merged.Lookup("00:00:0c:01:02:03")
+	assert.Equal(t, "Cisco Systems, Inc", vendor)
+}
@@ -0,0 +1,85 @@
+package qr
+
+// Weights of the mask penalty rules.
+const (
+	penaltyRun        = 3
+	penaltyBlock      = 3
+	penaltyFinderLike = 40
+	penaltyBalance    = 10
+)
+
+// Returns the penalty of the code's current masking: higher for long runs
+// and blocks of one color, patterns which look like finder patterns, and an
+// imbalance of dark and light modules.
+func (c *Code) penalty() int {
+	result := 0
+	for i := range c.Size {
+		result += c.linePenalty(func(j int) bool { return c.modules[i][j] })
+		result += c.linePenalty(func(j int) bool { return c.modules[j][i] })
+	}
+
+	dark := 0
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					result += penaltyBlock
+				}
+			}
+		}
+	}
+
+	// Each 5% away from half dark adds a penalty.
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + max(k, 0)*penaltyBalance
+}
+
+// Returns the penalty of the runs and finder-like patterns in a row or
+// column, whose modules module returns.
+func (c *Code) linePenalty(module func(int) bool) int {
+	result := 0
+	runLength := 0
+	for j := range c.Size {
+		if j > 0 && module(j) == module(j-1) {
+			runLength++
+		} else {
+			runLength = 1
+		}
+		if runLength == 5 {
+			result += penaltyRun
+		} else if runLength > 5 {
+			result++
+		}
+	}
+
+	// Dark-light-dark-dark-dark-light-dark, with four light modules on either
+	// side. Modules beyond the code count as light.
+	finderLike := []bool{true, false, true, true, true, false, true}
+	at := func(j int) bool { return j >= 0 && j < c.Size && module(j) }
+	for j := -4; j < c.Size+4-len(finderLike)+1; j++ {
+		matches := true
+		for k, dark := range finderLike {
+			if at(j+k) != dark {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+		lightBefore, lightAfter := true, true
+		for k := 1; k <= 4; k++ {
+			lightBefore = lightBefore && !at(j-k)
+			lightAfter = lightAfter && !at(j+len(finderLike)-1+k)
+		}
+		if lightBefore || lightAfter {
+			result += penaltyFinderLike
+		}
+	}
+	return result
+}
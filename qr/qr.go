@@ -0,0 +1,442 @@
+// Package qr encodes data as QR codes, in byte mode, following ISO/IEC
+// 18004. It is just enough of an encoder to print voucher cards without
+// calling out to an external service.
+package qr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Level is an error correction level. Higher levels survive more damage,
+// at the cost of a larger code.
+type Level int
+
+const (
+	// L recovers about 7% of the code.
+	L Level = iota
+	// M recovers about 15% of the code.
+	M
+	// Q recovers about 25% of the code.
+	Q
+	// H recovers about 30% of the code.
+	H
+)
+
+// Bits of each level in the format information.
+var levelFormatBits = [...]int{L: 1, M: 0, Q: 3, H: 2}
+
+// Error correction codewords per block, by level and version.
+var eccCodewordsPerBlock = [4][41]int{
+	{
+		-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28,
+		28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+	},
+	{
+		-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26,
+		26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+	},
+	{
+		-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30,
+		28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+	},
+	{
+		-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28,
+		30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+	},
+}
+
+// Error correction blocks, by level and version.
+var eccBlocks = [4][41]int{
+	{
+		-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8,
+		8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25,
+	},
+	{
+		-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+		17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49,
+	},
+	{
+		-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20,
+		23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68,
+	},
+	{
+		-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25,
+		25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81,
+	},
+}
+
+const (
+	minVersion = 1
+	maxVersion = 40
+)
+
+// ErrTooLong is returned when data doesn't fit in the largest QR code.
+var ErrTooLong = errors.New("data is too long for a QR code")
+
+// Code is an encoded QR code: a square of dark and light modules, not
+// including the quiet zone of 4 light modules renderers should surround it
+// with.
+type Code struct {
+	// Size is the number of modules along each side.
+	Size    int
+	Version int
+	Level   Level
+	// Mask is the mask pattern applied, 0 to 7.
+	Mask int
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Dark returns true if the module at column x and row y is dark. Modules
+// outside the code are light.
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.modules[y][x]
+}
+
+// Encode encodes data in the smallest QR code with error correction level.
+func Encode(data []byte, level Level) (*Code, error) {
+	for version := minVersion; version <= maxVersion; version++ {
+		if len(data) <= capacity(version, level) {
+			return encode(data, version, level, -1), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d bytes", ErrTooLong, len(data))
+}
+
+// EncodeString encodes text, as UTF-8, like Encode.
+func EncodeString(text string, level Level) (*Code, error) {
+	return Encode([]byte(text), level)
+}
+
+// Returns the bits of the character count in byte mode.
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// Returns the number of modules available for data and error correction.
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// Returns the number of data codewords of a version and level.
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*eccBlocks[level][version]
+}
+
+// Returns how many bytes a version and level can hold.
+func capacity(version int, level Level) int {
+	return (numDataCodewords(version, level)*8 - 4 - charCountBits(version)) / 8
+}
+
+// Encodes data in a code of version. A negative mask picks the mask with the
+// lowest penalty.
+func encode(data []byte, version int, level Level, mask int) *Code {
+	code := &Code{Size: version*4 + 17, Version: version, Level: level}
+	code.modules = make([][]bool, code.Size)
+	code.isFunction = make([][]bool, code.Size)
+	for y := range code.Size {
+		code.modules[y] = make([]bool, code.Size)
+		code.isFunction[y] = make([]bool, code.Size)
+	}
+
+	code.drawFunctionPatterns()
+	code.drawCodewords(addErrorCorrection(dataCodewords(data, version, level), version, level))
+
+	if mask < 0 {
+		lowest := 0
+		for candidate := range 8 {
+			code.applyMask(candidate)
+			code.drawFormatBits(candidate)
+			penalty := code.penalty()
+			if candidate == 0 || penalty < lowest {
+				mask, lowest = candidate, penalty
+			}
+			// Masks are their own inverse.
+			code.applyMask(candidate)
+		}
+	}
+	code.Mask = mask
+	code.applyMask(mask)
+	code.drawFormatBits(mask)
+	return code
+}
+
+// Appends the bits of data, in byte mode, terminated and padded to the data
+// capacity of the version and level.
+func dataCodewords(data []byte, version int, level Level) []byte {
+	bits := &bitBuffer{}
+	bits.append(0b0100, 4) // Byte mode.
+	bits.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacityBits := numDataCodewords(version, level) * 8
+	bits.append(0, min(4, capacityBits-bits.len()))
+	bits.append(0, (8-bits.len()%8)%8)
+	for pad := 0xEC; bits.len() < capacityBits; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// Splits data into blocks, appends error correction codewords to each, and
+// interleaves the blocks.
+func addErrorCorrection(data []byte, version int, level Level) []byte {
+	numBlocks := eccBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(eccLen)
+	blocks := make([][]byte, 0, numBlocks)
+	offset := 0
+	for i := range numBlocks {
+		dataLen := shortBlockLen - eccLen
+		if i >= numShortBlocks {
+			dataLen++
+		}
+		block := append([]byte{}, data[offset:offset+dataLen]...)
+		offset += dataLen
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			// Pad short blocks to the length of long ones, to interleave.
+			block = append(block, 0)
+		}
+		blocks = append(blocks, append(block, ecc...))
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range shortBlockLen + 1 {
+		for j, block := range blocks {
+			if i != shortBlockLen-eccLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// Draws the finder, timing and alignment patterns, and reserves the format
+// and version information areas.
+func (c *Code) drawFunctionPatterns() {
+	for i := range c.Size {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.Size-4, 3)
+	c.drawFinderPattern(3, c.Size-4)
+
+	positions := alignmentPatternPositions(c.Version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the corners taken by finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// Reserve the format bits, which depend on the mask.
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// Draws a finder pattern and its separator, centered on x and y.
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			distance := max(abs(dx), abs(dy))
+			if x+dx >= 0 && x+dx < c.Size && y+dy >= 0 && y+dy < c.Size {
+				c.setFunction(x+dx, y+dy, distance != 2 && distance != 4)
+			}
+		}
+	}
+}
+
+// Draws an alignment pattern centered on x and y.
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// Returns the centers of the alignment patterns, along either axis.
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return []int{}
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	positions := make([]int, numAlign)
+	positions[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// Draws the two copies of the format information: the level and mask, with
+// error correction bits.
+func (c *Code) drawFormatBits(mask int) {
+	data := levelFormatBits[c.Level]<<3 | mask
+	rem := data
+	for range 10 {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	// Around the top left finder pattern.
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	// Beside the other two finder patterns.
+	for i := range 8 {
+		c.setFunction(c.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// Draws the two copies of the version information, of versions 7 and up.
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for range 12 {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := c.Version<<12 | rem
+	for i := range 18 {
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// Draws the codewords in the zigzag pattern, up and down pairs of columns
+// from the right, skipping function modules.
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// Skip the vertical timing pattern.
+			right = 5
+		}
+		for vertical := range c.Size {
+			for j := range 2 {
+				x := right - j
+				y := vertical
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vertical
+				}
+				if !c.isFunction[y][x] && i < len(codewords)*8 {
+					c.modules[y][x] = bit(int(codewords[i>>3]), 7-i&7)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// XORs the data modules with a mask pattern.
+func (c *Code) applyMask(mask int) {
+	for y := range c.Size {
+		for x := range c.Size {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.isFunction[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+// Returns true if bit i of x is set.
+func bit(x, i int) bool {
+	return (x>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Collects bits, most significant first.
+type bitBuffer struct {
+	bits []bool
+}
+
+func (bb *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		bb.bits = append(bb.bits, bit(value, i))
+	}
+}
+
+func (bb *bitBuffer) len() int {
+	return len(bb.bits)
+}
+
+func (bb *bitBuffer) bytes() []byte {
+	result := make([]byte, (len(bb.bits)+7)/8)
+	for i, set := range bb.bits {
+		if set {
+			result[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+	return result
+}
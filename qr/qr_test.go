@@ -0,0 +1,112 @@
+package qr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/qr"
+)
+
+// Renders a code as rows of '#' for dark modules and '.' for light ones.
+func render(code *qr.Code) []string {
+	rows := []string{}
+	for y := range code.Size {
+		row := strings.Builder{}
+		for x := range code.Size {
+			if code.Dark(x, y) {
+				row.WriteByte('#')
+			} else {
+				row.WriteByte('.')
+			}
+		}
+		rows = append(rows, row.String())
+	}
+	return rows
+}
+
+func TestEncode(t *testing.T) {
+	code, err := qr.EncodeString("12345-67890", qr.M)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, code.Version)
+	assert.Equal(t, 2, code.Mask)
+	assert.Equal(t, []string{
+		"#######...###.#######",
+		"#.....#.......#.....#",
+		"#.###.#.###...#.###.#",
+		"#.###.#.#..#..#.###.#",
+		"#.###.#.#.#.#.#.###.#",
+		"#.....#.####..#.....#",
+		"#######.#.#.#.#######",
+		"........###..........",
+		"#.#####..###..#####..",
+		".#.#...#.#.###..#.###",
+		"..##.###.##.####.....",
+		"#..#...#.####..##.##.",
+		"...####...#.###..#...",
+		"........#.#.#.#.#.###",
+		"#######...##...#.#...",
+		"#.....#.##........###",
+		"#.###.#.####.##.##..#",
+		"#.###.#.#.#.#.#####..",
+		"#.###.#.##..##.#..#..",
+		"#.....#..#..#.....#..",
+		"#######.#.#.###..#.#.",
+	}, render(code))
+	assert.False(t, code.Dark(-1, 0))
+	assert.False(t, code.Dark(0, code.Size))
+}
+
+func TestEncodePicksSmallestVersion(t *testing.T) {
+	tests := []struct {
+		length  int
+		level   qr.Level
+		version int
+	}{
+		{14, qr.M, 1},
+		{15, qr.M, 2},
+		{17, qr.L, 1},
+		{7, qr.H, 1},
+		{2331, qr.M, 40},
+	}
+	for _, test := range tests {
+		code, err := qr.Encode([]byte(strings.Repeat("a", test.length)), test.level)
+		require.NoError(t, err)
+		assert.Equal(t, test.version, code.Version, "%d bytes", test.length)
+		assert.Equal(t, test.version*4+17, code.Size)
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	_, err := qr.Encode(make([]byte, 2332), qr.M)
+	require.ErrorIs(t, err, qr.ErrTooLong)
+}
+
+func TestEncodeVersionInformation(t *testing.T) {
+	// Version 7 is the first to carry version information, 000111 110010
+	// 010100, beside the top right and bottom left finder patterns.
+	code, err := qr.Encode(make([]byte, 110), qr.M)
+	require.NoError(t, err)
+	require.Equal(t, 7, code.Version)
+
+	bits := 0b000111110010010100
+	for i := range 18 {
+		a, b := code.Size-11+i%3, i/3
+		want := (bits>>i)&1 == 1
+		assert.Equal(t, want, code.Dark(a, b), "bit %d", i)
+		assert.Equal(t, want, code.Dark(b, a), "bit %d", i)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	code, err := qr.EncodeString("12345-67890", qr.M)
+	require.NoError(t, err)
+
+	svg := code.SVG()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 29 29"`))
+	// The top row of the top left finder pattern, offset by the quiet zone.
+	assert.Contains(t, svg, `<path d="M4 4h7v1h-7z`)
+}
@@ -0,0 +1,33 @@
+package qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuietZone is the number of light modules a code should be surrounded by.
+const QuietZone = 4
+
+// SVG returns the code as an SVG image, quiet zone included, which scales to
+// the size of its container.
+func (c *Code) SVG() string {
+	size := c.Size + QuietZone*2
+	path := strings.Builder{}
+	for y := range c.Size {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			// One rectangle per run of dark modules.
+			run := 1
+			for c.Dark(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", x+QuietZone, y+QuietZone, run, run)
+			x += run - 1
+		}
+	}
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		size, size, size, size, path.String())
+}
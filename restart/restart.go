@@ -0,0 +1,248 @@
+// Package restart restarts Network devices in rolling batches, so a site
+// never loses more than a few devices at once.
+package restart
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/topology"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Batch is a group of devices which are restarted together.
+type Batch []*types.Device
+
+// Plan orders devices into batches of at most maxUnavailable devices. Devices
+// deepest in the uplink graph go first, so downstream devices are restarted
+// before the devices they are connected through. A batch only holds devices
+// at the same depth, so a device is never restarted along with its uplink.
+func Plan(graph *topology.Graph, devices []*types.Device, maxUnavailable int) ([]Batch, error) {
+	if maxUnavailable < 1 {
+		return nil, errors.New("at least one device must be allowed to be unavailable")
+	}
+
+	type planned struct {
+		node  *topology.Node
+		depth int
+	}
+	nodes := make([]planned, 0, len(devices))
+	for _, device := range devices {
+		node, ok := graph.Nodes[device.ID]
+		if !ok {
+			return nil, fmt.Errorf("device '%s' isn't in the uplink graph", device.Name)
+		}
+		nodes = append(nodes, planned{node: node, depth: node.Depth()})
+	}
+	slices.SortFunc(nodes, func(a, b planned) int {
+		return cmp.Or(cmp.Compare(b.depth, a.depth),
+			cmp.Compare(a.node.Name(), b.node.Name()),
+			cmp.Compare(a.node.Device.ID, b.node.Device.ID))
+	})
+
+	batches := []Batch{}
+	for i, node := range nodes {
+		if i == 0 || node.depth != nodes[i-1].depth || len(batches[len(batches)-1]) == maxUnavailable {
+			batches = append(batches, Batch{})
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], node.node.Device)
+	}
+	return batches, nil
+}
+
+// Status is how far a device's restart got.
+type Status string
+
+const (
+	// StatusPending devices haven't been restarted yet.
+	StatusPending Status = "pending"
+	// StatusRestarting devices were told to restart, and haven't come back.
+	StatusRestarting Status = "restarting"
+	// StatusOnline devices restarted and came back online.
+	StatusOnline Status = "online"
+	// StatusFailed devices couldn't be restarted, or didn't come back in
+	// time.
+	StatusFailed Status = "failed"
+	// StatusSkipped devices weren't restarted because the run was aborted.
+	StatusSkipped Status = "skipped"
+)
+
+// Result is the outcome of restarting a device.
+type Result struct {
+	DeviceID   string `json:"deviceId"`
+	DeviceName string `json:"deviceName"`
+	// Batch is the device's batch, counting from 1.
+	Batch       int       `json:"batch"`
+	Status      Status    `json:"status"`
+	RestartedAt time.Time `json:"restartedAt,omitzero"`
+	OnlineAt    time.Time `json:"onlineAt,omitzero"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Downtime returns how long the device took to come back, or zero if it
+// hasn't.
+func (r *Result) Downtime() time.Duration {
+	if r.Status != StatusOnline {
+		return 0
+	}
+	return r.OnlineAt.Sub(r.RestartedAt)
+}
+
+// Restarter restarts batches of a site's devices.
+type Restarter struct {
+	Network types.NetworkV1
+	SiteID  types.SiteID
+	// PollInterval is how often restarting devices are checked on.
+	PollInterval time.Duration
+	// Timeout is how long a batch has to come back online.
+	Timeout time.Duration
+	// OnProgress, if set, is called whenever a device's status changes.
+	OnProgress func(*Result)
+}
+
+// Run restarts the batches in order, waiting for every device of a batch to
+// come back online before starting on the next. A device is back once its
+// state is ONLINE and its uptime shows it restarted. The first failure aborts
+// the run, leaving the remaining batches alone. Results are returned for
+// every device, in batch order.
+func (r *Restarter) Run(ctx context.Context, batches []Batch) ([]*Result, error) {
+	results := []*Result{}
+	batchResults := make([][]*Result, 0, len(batches))
+	for i, batch := range batches {
+		thisBatch := make([]*Result, 0, len(batch))
+		for _, device := range batch {
+			result := &Result{DeviceID: device.ID, DeviceName: device.Name, Batch: i + 1, Status: StatusPending}
+			thisBatch = append(thisBatch, result)
+		}
+		batchResults = append(batchResults, thisBatch)
+		results = append(results, thisBatch...)
+	}
+
+	for i, thisBatch := range batchResults {
+		err := r.runBatch(ctx, thisBatch)
+		if err != nil {
+			for _, skipped := range batchResults[i+1:] {
+				for _, result := range skipped {
+					r.setStatus(result, StatusSkipped, nil)
+				}
+			}
+			return results, fmt.Errorf("batch %d: %w", i+1, err)
+		}
+	}
+	return results, nil
+}
+
+func (r *Restarter) setStatus(result *Result, status Status, err error) {
+	result.Status = status
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if r.OnProgress != nil {
+		r.OnProgress(result)
+	}
+}
+
+// Restarts every device of a batch, then waits for them all to come back.
+// Once one device fails no more are restarted, but those already restarting
+// are still waited for.
+func (r *Restarter) runBatch(ctx context.Context, batch []*Result) error {
+	var errs []error
+	restarting := []*Result{}
+	for _, result := range batch {
+		if len(errs) > 0 {
+			r.setStatus(result, StatusSkipped, nil)
+			continue
+		}
+		err := r.restart(result)
+		if err != nil {
+			r.setStatus(result, StatusFailed, err)
+			errs = append(errs, fmt.Errorf("restarting '%s': %w", result.DeviceName, err))
+			continue
+		}
+		restarting = append(restarting, result)
+		r.setStatus(result, StatusRestarting, nil)
+	}
+
+	errs = append(errs, r.waitForOnline(ctx, restarting))
+	return errors.Join(errs...)
+}
+
+func (r *Restarter) restart(result *Result) error {
+	deviceID := types.DeviceID(result.DeviceID)
+	device, err := r.Network.DeviceDetails(r.SiteID, deviceID)
+	if err != nil {
+		return err
+	}
+	if device.State != types.DeviceStateOnline {
+		return fmt.Errorf("device is %s, not %s", device.State, types.DeviceStateOnline)
+	}
+
+	err = r.Network.DeviceExecuteAction(r.SiteID, deviceID, types.NewDeviceRestartRequest())
+	if err != nil {
+		return err
+	}
+	result.RestartedAt = time.Now()
+	return nil
+}
+
+// Polls the restarting devices until they're all back online, or the batch
+// times out.
+func (r *Restarter) waitForOnline(ctx context.Context, restarting []*Result) error {
+	waitCtx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for len(restarting) > 0 {
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			var errs []error
+			for _, result := range restarting {
+				err := fmt.Errorf("didn't come back online within %s", r.Timeout)
+				r.setStatus(result, StatusFailed, err)
+				errs = append(errs, fmt.Errorf("'%s' %w", result.DeviceName, err))
+			}
+			return errors.Join(errs...)
+		}
+
+		restarting = slices.DeleteFunc(restarting, func(result *Result) bool {
+			if !r.isBack(result) {
+				return false
+			}
+			result.OnlineAt = time.Now()
+			r.setStatus(result, StatusOnline, nil)
+			return true
+		})
+	}
+	return nil
+}
+
+// Returns true if a device is online and has been up no longer than since it
+// was told to restart. Errors are expected while a device restarts, so they
+// only mean it isn't back yet.
+func (r *Restarter) isBack(result *Result) bool {
+	deviceLog := log.WithField("device", result.DeviceName)
+	deviceID := types.DeviceID(result.DeviceID)
+	device, err := r.Network.DeviceDetails(r.SiteID, deviceID)
+	if err != nil {
+		deviceLog.Debug(err.Error())
+		return false
+	}
+	if device.State != types.DeviceStateOnline {
+		return false
+	}
+	stats, err := r.Network.DeviceStatistics(r.SiteID, deviceID)
+	if err != nil {
+		deviceLog.Debug(err.Error())
+		return false
+	}
+	sinceRestart := int64(time.Since(result.RestartedAt).Seconds())
+	return stats.UptimeSec <= sinceRestart
+}
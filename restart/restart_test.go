@@ -0,0 +1,195 @@
+package restart_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/restart"
+	"github.com/ClifHouck/unified/topology"
+	"github.com/ClifHouck/unified/types"
+)
+
+func device(id, name, model, uplinkID string) *types.Device {
+	d := &types.Device{ID: id, Name: name, Model: model, State: types.DeviceStateOnline}
+	d.Uplink.DeviceID = uplinkID
+	return d
+}
+
+// A gateway, a switch and three access points behind the switch.
+func siteDevices() []*types.Device {
+	return []*types.Device{
+		device("gw", "Gateway", "UCG-Ultra", ""),
+		device("sw", "Switch", "USW-Lite-8-PoE", "gw"),
+		device("ap1", "AP 1", "U6-LR", "sw"),
+		device("ap2", "AP 2", "U6-LR", "sw"),
+		device("ap3", "AP 3", "U6-Lite", "sw"),
+	}
+}
+
+func batchNames(batches []restart.Batch) [][]string {
+	names := [][]string{}
+	for _, batch := range batches {
+		batchNames := []string{}
+		for _, d := range batch {
+			batchNames = append(batchNames, d.Name)
+		}
+		names = append(names, batchNames)
+	}
+	return names
+}
+
+func TestSelector(t *testing.T) {
+	devices := siteDevices()
+
+	selector, err := restart.ParseSelector("model=u6-*, name=AP*")
+	require.NoError(t, err)
+	assert.Len(t, selector.Select(devices), 3)
+
+	selector, err = restart.ParseSelector("model=U6-LR")
+	require.NoError(t, err)
+	assert.Len(t, selector.Select(devices), 2)
+
+	selector, err = restart.ParseSelector("")
+	require.NoError(t, err)
+	assert.True(t, selector.IsEmpty())
+	assert.Len(t, selector.Select(devices), 5)
+
+	for _, text := range []string{"model", "colour=red", "name=[a"} {
+		_, err = restart.ParseSelector(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestPlanRestartsDownstreamFirst(t *testing.T) {
+	devices := siteDevices()
+	graph := topology.Build(devices)
+
+	batches, err := restart.Plan(graph, devices, 2)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"AP 1", "AP 2"}, {"AP 3"}, {"Switch"}, {"Gateway"}}, batchNames(batches))
+
+	batches, err = restart.Plan(graph, devices[1:2], 5)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Switch"}}, batchNames(batches))
+
+	_, err = restart.Plan(graph, devices, 0)
+	require.Error(t, err)
+}
+
+type fakeDevice struct {
+	restarted bool
+	polls     int
+	failStart bool
+	neverBack bool
+}
+
+// Devices go offline for one poll after restarting, then come back with
+// their uptime reset.
+type fakeNetwork struct {
+	types.NetworkV1
+
+	devices  map[types.DeviceID]*fakeDevice
+	restarts []types.DeviceID
+}
+
+func newFakeNetwork(devices []*types.Device) *fakeNetwork {
+	network := &fakeNetwork{devices: map[types.DeviceID]*fakeDevice{}}
+	for _, d := range devices {
+		network.devices[types.DeviceID(d.ID)] = &fakeDevice{}
+	}
+	return network
+}
+
+func (fn *fakeNetwork) DeviceDetails(_ types.SiteID, id types.DeviceID) (*types.Device, error) {
+	d := fn.devices[id]
+	state := types.DeviceStateOnline
+	if d.restarted {
+		d.polls++
+		if d.polls < 2 || d.neverBack {
+			state = types.DeviceStateOffline
+		}
+	}
+	return &types.Device{ID: string(id), State: state}, nil
+}
+
+func (fn *fakeNetwork) DeviceStatistics(_ types.SiteID, id types.DeviceID) (*types.DeviceStatistics, error) {
+	if fn.devices[id].restarted {
+		return &types.DeviceStatistics{UptimeSec: 0}, nil
+	}
+	return &types.DeviceStatistics{UptimeSec: 86400}, nil
+}
+
+func (fn *fakeNetwork) DeviceExecuteAction(_ types.SiteID, id types.DeviceID, _ *types.DeviceActionRequest) error {
+	if fn.devices[id].failStart {
+		return errors.New("restart refused")
+	}
+	fn.devices[id].restarted = true
+	fn.restarts = append(fn.restarts, id)
+	return nil
+}
+
+func newRestarter(network types.NetworkV1) *restart.Restarter {
+	return &restart.Restarter{
+		Network:      network,
+		SiteID:       "default",
+		PollInterval: time.Millisecond,
+		Timeout:      time.Millisecond * 50,
+	}
+}
+
+func TestRunRestartsEveryBatch(t *testing.T) {
+	devices := siteDevices()
+	batches, err := restart.Plan(topology.Build(devices), devices, 2)
+	require.NoError(t, err)
+
+	network := newFakeNetwork(devices)
+	results, err := newRestarter(network).Run(context.Background(), batches)
+	require.NoError(t, err)
+	assert.Equal(t, []types.DeviceID{"ap1", "ap2", "ap3", "sw", "gw"}, network.restarts)
+	require.Len(t, results, 5)
+	for _, result := range results {
+		assert.Equal(t, restart.StatusOnline, result.Status, result.DeviceName)
+		assert.False(t, result.OnlineAt.IsZero())
+	}
+	assert.Equal(t, 4, results[4].Batch)
+}
+
+func TestRunAbortsOnFailure(t *testing.T) {
+	devices := siteDevices()
+	batches, err := restart.Plan(topology.Build(devices), devices, 2)
+	require.NoError(t, err)
+
+	network := newFakeNetwork(devices)
+	network.devices["ap2"].neverBack = true
+	results, err := newRestarter(network).Run(context.Background(), batches)
+	require.ErrorContains(t, err, "'AP 2' didn't come back online")
+	assert.Equal(t, []types.DeviceID{"ap1", "ap2"}, network.restarts)
+
+	statuses := []restart.Status{}
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []restart.Status{
+		restart.StatusOnline, restart.StatusFailed,
+		restart.StatusSkipped, restart.StatusSkipped, restart.StatusSkipped,
+	}, statuses)
+}
+
+func TestRunStopsRestartingAfterRefusal(t *testing.T) {
+	devices := siteDevices()
+	batches, err := restart.Plan(topology.Build(devices), devices, 3)
+	require.NoError(t, err)
+
+	network := newFakeNetwork(devices)
+	network.devices["ap1"].failStart = true
+	results, err := newRestarter(network).Run(context.Background(), batches)
+	require.ErrorContains(t, err, "restart refused")
+	assert.Empty(t, network.restarts)
+	assert.Equal(t, restart.StatusFailed, results[0].Status)
+	assert.Equal(t, restart.StatusSkipped, results[1].Status)
+}
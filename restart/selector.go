@@ -0,0 +1,98 @@
+package restart
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// The device fields a selector can match, by key.
+var selectorFields = map[string]func(*types.Device) string{
+	"id":       func(d *types.Device) string { return d.ID },
+	"name":     func(d *types.Device) string { return d.Name },
+	"model":    func(d *types.Device) string { return d.Model },
+	"mac":      func(d *types.Device) string { return d.MacAddress },
+	"ip":       func(d *types.Device) string { return d.IPAddress },
+	"firmware": func(d *types.Device) string { return d.FirmwareVersion },
+	"state":    func(d *types.Device) string { return d.State.String() },
+}
+
+// SelectorKeys are the keys a selector can match on.
+func SelectorKeys() []string {
+	keys := make([]string, 0, len(selectorFields))
+	for key := range selectorFields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+type selectorTerm struct {
+	key     string
+	pattern string
+}
+
+// Selector selects devices by comma separated key=value terms, e.g.
+// "model=U6-LR,name=Lobby*". A device must match every term. Values are
+// matched ignoring case, and may use the wildcards of path.Match. The empty
+// selector matches every device.
+type Selector struct {
+	terms []selectorTerm
+}
+
+// ParseSelector parses a selector.
+func ParseSelector(text string) (*Selector, error) {
+	selector := &Selector{}
+	if strings.TrimSpace(text) == "" {
+		return selector, nil
+	}
+	for _, term := range strings.Split(text, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(term), "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid selector term '%s', expected key=value", term)
+		}
+		if _, known := selectorFields[key]; !known {
+			return nil, fmt.Errorf("unknown selector key '%s', expected one of: %s",
+				key, strings.Join(SelectorKeys(), ", "))
+		}
+		pattern := strings.ToLower(strings.TrimSpace(value))
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector pattern '%s': %w", value, err)
+		}
+		selector.terms = append(selector.terms, selectorTerm{key: key, pattern: pattern})
+	}
+	return selector, nil
+}
+
+// Matches returns true if device is selected.
+func (s *Selector) Matches(device *types.Device) bool {
+	for _, term := range s.terms {
+		value := strings.ToLower(selectorFields[term.key](device))
+		matched, _ := path.Match(term.pattern, value)
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the selected devices.
+func (s *Selector) Select(devices []*types.Device) []*types.Device {
+	selected := []*types.Device{}
+	for _, device := range devices {
+		if s.Matches(device) {
+			selected = append(selected, device)
+		}
+	}
+	return selected
+}
+
+// IsEmpty returns true if the selector matches every device.
+func (s *Selector) IsEmpty() bool {
+	return len(s.terms) == 0
+}
@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"slices"
+	"strings"
+)
+
+// Metric is one of the statistics a sample records.
+type Metric string
+
+const (
+	MetricCPU    Metric = "cpu"
+	MetricMemory Metric = "memory"
+	MetricLoad   Metric = "load"
+	MetricTx     Metric = "tx"
+	MetricRx     Metric = "rx"
+	MetricUptime Metric = "uptime"
+)
+
+var AllMetrics = []Metric{MetricCPU, MetricMemory, MetricLoad, MetricTx, MetricRx, MetricUptime}
+
+func (m Metric) Valid() bool    { return slices.Contains(AllMetrics, m) }
+func (m Metric) String() string { return string(m) }
+
+// Value returns the metric's value in sample.
+func (m Metric) Value(sample *Sample) float64 {
+	switch m {
+	case MetricCPU:
+		return sample.CPUPct
+	case MetricMemory:
+		return sample.MemoryPct
+	case MetricLoad:
+		return sample.Load1Min
+	case MetricTx:
+		return sample.TxBps
+	case MetricRx:
+		return sample.RxBps
+	case MetricUptime:
+		return float64(sample.UptimeSec)
+	}
+	return 0
+}
+
+// Values returns the metric's value in each sample.
+func (m Metric) Values(samples []*Sample) []float64 {
+	values := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		values = append(values, m.Value(sample))
+	}
+	return values
+}
+
+// Characters of a sparkline, lowest first.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline charts values as a line of at most width block characters,
+// scaled between their minimum and maximum. When there are more values than
+// width, neighbouring values are averaged.
+func Sparkline(values []float64, width int) string {
+	if len(values) == 0 || width < 1 {
+		return ""
+	}
+	if len(values) > width {
+		values = resample(values, width)
+	}
+
+	lowest, highest := slices.Min(values), slices.Max(values)
+	line := strings.Builder{}
+	for _, value := range values {
+		spark := 0
+		if highest > lowest {
+			spark = int((value - lowest) / (highest - lowest) * float64(len(sparks)-1))
+		}
+		line.WriteRune(sparks[spark])
+	}
+	return line.String()
+}
+
+// Averages values down to width values.
+func resample(values []float64, width int) []float64 {
+	resampled := make([]float64, 0, width)
+	for i := range width {
+		start := i * len(values) / width
+		end := (i + 1) * len(values) / width
+		sum := 0.0
+		for _, value := range values[start:end] {
+			sum += value
+		}
+		resampled = append(resampled, sum/float64(end-start))
+	}
+	return resampled
+}
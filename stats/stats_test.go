@@ -0,0 +1,121 @@
+package stats_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/stats"
+)
+
+var now = time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+
+func sample(device string, age time.Duration, cpu float64) *stats.Sample {
+	return &stats.Sample{Time: now.Add(-age), SiteID: "default", DeviceID: device, Device: "Device " + device, CPUPct: cpu}
+}
+
+func TestStoreAppendAndQuery(t *testing.T) {
+	store, err := stats.Open(filepath.Join(t.TempDir(), "unified", "stats.jsonl"))
+	require.NoError(t, err)
+
+	samples, err := store.Query(stats.Query{})
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+
+	require.NoError(t, store.Append([]*stats.Sample{sample("ap", time.Hour*2, 10), sample("sw", time.Hour, 20)}))
+	require.NoError(t, store.Append([]*stats.Sample{sample("ap", time.Minute, 30)}))
+
+	samples, err = store.Query(stats.Query{Device: "device AP"})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{10, 30}, stats.MetricCPU.Values(samples))
+
+	samples, err = store.Query(stats.Query{Since: now.Add(-time.Hour * 90 / 60)})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{20, 30}, stats.MetricCPU.Values(samples))
+}
+
+func TestDownsample(t *testing.T) {
+	retention := stats.Retention{Raw: time.Hour * 24, Total: time.Hour * 24 * 7, Bucket: time.Hour}
+	samples := []*stats.Sample{
+		sample("ap", time.Hour*24*8, 99),              // Past retention.
+		sample("ap", time.Hour*48+time.Minute*10, 10), // Same bucket as the next.
+		sample("ap", time.Hour*48+time.Minute*20, 20),
+		sample("sw", time.Hour*48+time.Minute*20, 50),
+		sample("ap", time.Hour, 70), // Recent, kept raw.
+	}
+
+	downsampled := stats.Downsample(samples, retention, now)
+	require.Len(t, downsampled, 3)
+	assert.Equal(t, []float64{15, 50, 70}, stats.MetricCPU.Values(downsampled))
+	assert.Equal(t, 2, downsampled[0].Count)
+	assert.Equal(t, now.Add(-time.Hour*49), downsampled[0].Time)
+
+	// Downsampling again, with another sample for the same bucket, keeps the
+	// average weighted by how many samples went into it.
+	again := stats.Downsample(append(downsampled, sample("ap", time.Hour*48+time.Minute*30, 60)), retention, now)
+	assert.Equal(t, []float64{30, 50, 70}, stats.MetricCPU.Values(again))
+	assert.Equal(t, 3, again[0].Count)
+}
+
+func TestStoreCompact(t *testing.T) {
+	store, err := stats.Open(filepath.Join(t.TempDir(), "stats.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, store.Append([]*stats.Sample{
+		sample("ap", time.Hour*24*40, 10),
+		sample("ap", time.Hour*30, 20),
+		sample("ap", time.Minute, 30),
+	}))
+
+	require.NoError(t, store.Compact(stats.DefaultRetention, now))
+	samples, err := store.Query(stats.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{20, 30}, stats.MetricCPU.Values(samples))
+}
+
+func TestStoreSkipsSampleCutShort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	store, err := stats.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Append([]*stats.Sample{sample("ap", time.Hour, 10)}))
+
+	// As if recording was interrupted part way through writing a sample.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"t":"2025-06-10T11:30:00Z","s":"def`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	samples, err := store.Query(stats.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{10}, stats.MetricCPU.Values(samples))
+
+	require.NoError(t, store.Append([]*stats.Sample{sample("ap", time.Minute, 30)}))
+	samples, err = store.Query(stats.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{10, 30}, stats.MetricCPU.Values(samples))
+
+	require.NoError(t, store.Compact(stats.DefaultRetention, now))
+	samples, err = store.Query(stats.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{10, 30}, stats.MetricCPU.Values(samples))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+
+	// Other malformed samples are still errors.
+	require.NoError(t, os.WriteFile(path, []byte("{\"t\": 5}\n"), 0o600))
+	_, err = store.Query(stats.Query{})
+	require.Error(t, err)
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", stats.Sparkline([]float64{0, 50, 100}, 10))
+	assert.Equal(t, "▁▁▁", stats.Sparkline([]float64{5, 5, 5}, 10))
+	assert.Equal(t, "▁█", stats.Sparkline([]float64{0, 0, 10, 10}, 2))
+	assert.Empty(t, stats.Sparkline(nil, 10))
+}
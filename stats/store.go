@@ -0,0 +1,301 @@
+// Package stats keeps a local history of device statistics, which the
+// Network API only reports the latest values of. Samples are appended to a
+// JSON lines file, and compacted by downsampling old samples and dropping
+// those past retention.
+package stats
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// Sample is a device's statistics at one point in time. Field names are kept
+// short, as there are a lot of samples.
+type Sample struct {
+	Time      time.Time `json:"t"`
+	SiteID    string    `json:"s"`
+	DeviceID  string    `json:"d"`
+	Device    string    `json:"n"`
+	CPUPct    float64   `json:"cpu"`
+	MemoryPct float64   `json:"mem"`
+	Load1Min  float64   `json:"load"`
+	TxBps     float64   `json:"tx"`
+	RxBps     float64   `json:"rx"`
+	UptimeSec int64     `json:"up"`
+	// Count is how many samples were averaged into this one when it was
+	// downsampled. Raw samples leave it unset.
+	Count int `json:"c,omitempty"`
+}
+
+// NewSample returns a sample of a device's statistics.
+func NewSample(siteID types.SiteID, device *types.DeviceListEntry, stats *types.DeviceStatistics,
+	at time.Time) *Sample {
+	return &Sample{
+		Time:      at.UTC(),
+		SiteID:    string(siteID),
+		DeviceID:  device.ID,
+		Device:    device.Name,
+		CPUPct:    stats.CPUUtilizationPct,
+		MemoryPct: stats.MemoryUtilizationPct,
+		Load1Min:  stats.LoadAverage1Min,
+		TxBps:     float64(stats.Uplink.TxRateBps),
+		RxBps:     float64(stats.Uplink.RxRateBps),
+		UptimeSec: stats.UptimeSec,
+	}
+}
+
+// Weight returns how many samples this one stands for.
+func (s *Sample) Weight() int {
+	return max(s.Count, 1)
+}
+
+// Store is a file of samples.
+type Store struct {
+	path string
+}
+
+// DefaultPath returns where samples are stored unless told otherwise:
+// unified/stats.jsonl under $XDG_DATA_HOME, or ~/.local/share.
+func DefaultPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "unified", "stats.jsonl"), nil
+}
+
+// Open returns the store at path, creating its directory if needed. The file
+// itself is created by the first Append.
+func Open(path string) (*Store, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: path}, nil
+}
+
+// Path returns the path of the store's file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Returns true if the file at path ends part way through a line, as it does
+// when an Append is interrupted.
+func endsMidLine(path string) (bool, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close() //nolint:errcheck // Only read from.
+
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	last := make([]byte, 1)
+	_, err = file.ReadAt(last, info.Size()-1)
+	if err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// Returns true if err is from unmarshalling a sample which was cut short.
+func truncated(line []byte, err error) bool {
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr) && syntaxErr.Offset == int64(len(line))
+}
+
+// Append adds samples to the store.
+func (s *Store) Append(samples []*Sample) error {
+	midLine, err := endsMidLine(s.path)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	if midLine {
+		// Leave the sample cut short on a line of its own, for Query to skip.
+		_ = writer.WriteByte('\n')
+	}
+	encoder := json.NewEncoder(writer)
+	for _, sample := range samples {
+		err = encoder.Encode(sample)
+		if err != nil {
+			return errors.Join(err, file.Close())
+		}
+	}
+	return errors.Join(writer.Flush(), file.Close())
+}
+
+// Query selects samples.
+type Query struct {
+	// Device matches a device by ID, or name ignoring case. Empty matches
+	// every device.
+	Device string
+	// Since excludes samples before it, if set.
+	Since time.Time
+}
+
+func (q *Query) matches(sample *Sample) bool {
+	if q.Device != "" && q.Device != sample.DeviceID && !strings.EqualFold(q.Device, sample.Device) {
+		return false
+	}
+	return q.Since.IsZero() || !sample.Time.Before(q.Since)
+}
+
+// Query returns the samples selected by q, oldest first. An empty store has
+// no samples. Samples cut short by an interrupted Append are logged and
+// skipped, and dropped by the next Compact.
+func (s *Store) Query(q Query) ([]*Sample, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Sample{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // Only read from.
+
+	samples := []*Sample{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var sample Sample
+		err = json.Unmarshal(scanner.Bytes(), &sample)
+		if truncated(scanner.Bytes(), err) {
+			log.WithField("path", s.path).Warn("Skipping a sample which was cut short while being recorded")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if q.matches(&sample) {
+			samples = append(samples, &sample)
+		}
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+	sortSamples(samples)
+	return samples, nil
+}
+
+// Sorts samples by time, then site and device.
+func sortSamples(samples []*Sample) {
+	slices.SortStableFunc(samples, func(a, b *Sample) int {
+		return cmp.Or(a.Time.Compare(b.Time), cmp.Compare(a.SiteID, b.SiteID), cmp.Compare(a.DeviceID, b.DeviceID))
+	})
+}
+
+// Retention configures how long samples are kept.
+type Retention struct {
+	// Raw is how long samples are kept as they were recorded.
+	Raw time.Duration
+	// Total is how long samples are kept at all. Samples older than Raw, but
+	// younger than Total, are downsampled.
+	Total time.Duration
+	// Bucket is the period downsampled samples are averaged over.
+	Bucket time.Duration
+}
+
+// DefaultRetention keeps a day of raw samples and a month of hourly ones.
+var DefaultRetention = Retention{
+	Raw:    time.Hour * 24,
+	Total:  time.Hour * 24 * 30,
+	Bucket: time.Hour,
+}
+
+// Downsample applies retention to samples as of now: samples older than
+// Total are dropped, and samples older than Raw are averaged into one sample
+// per device per Bucket. Averages are weighted by Count, so downsampling is
+// idempotent. Returns the samples oldest first.
+func Downsample(samples []*Sample, retention Retention, now time.Time) []*Sample {
+	rawSince := now.Add(-retention.Raw)
+	keepSince := now.Add(-retention.Total)
+
+	type bucketKey struct {
+		start    time.Time
+		siteID   string
+		deviceID string
+	}
+	buckets := map[bucketKey]*Sample{}
+	kept := []*Sample{}
+	for _, sample := range samples {
+		switch {
+		case sample.Time.Before(keepSince):
+			continue
+		case !sample.Time.Before(rawSince) || retention.Bucket <= 0:
+			kept = append(kept, sample)
+			continue
+		}
+
+		key := bucketKey{sample.Time.Truncate(retention.Bucket), sample.SiteID, sample.DeviceID}
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &Sample{Time: key.start, SiteID: sample.SiteID, DeviceID: sample.DeviceID}
+			buckets[key] = bucket
+			kept = append(kept, bucket)
+		}
+		addToBucket(bucket, sample)
+	}
+	sortSamples(kept)
+	return kept
+}
+
+// Folds sample into the running weighted average of bucket.
+func addToBucket(bucket *Sample, sample *Sample) {
+	total := float64(bucket.Count + sample.Weight())
+	weight := float64(sample.Weight()) / total
+	average := func(current, value float64) float64 {
+		return current + (value-current)*weight
+	}
+	bucket.CPUPct = average(bucket.CPUPct, sample.CPUPct)
+	bucket.MemoryPct = average(bucket.MemoryPct, sample.MemoryPct)
+	bucket.Load1Min = average(bucket.Load1Min, sample.Load1Min)
+	bucket.TxBps = average(bucket.TxBps, sample.TxBps)
+	bucket.RxBps = average(bucket.RxBps, sample.RxBps)
+	bucket.UptimeSec = max(bucket.UptimeSec, sample.UptimeSec)
+	bucket.Device = sample.Device
+	bucket.Count += sample.Weight()
+}
+
+// Compact rewrites the store with retention applied as of now.
+func (s *Store) Compact(retention Retention, now time.Time) error {
+	samples, err := s.Query(Query{})
+	if err != nil {
+		return err
+	}
+	compacted := Downsample(samples, retention, now)
+
+	tmp := &Store{path: s.path + ".tmp"}
+	err = os.Remove(tmp.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err = tmp.Append(compacted)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.path, s.path)
+}
@@ -0,0 +1,20 @@
+#!/bin/bash
+
+mage generateStreamHandlers
+
+mkdir -p /tmp/unified/
+
+git diff client/protect_*_stream_handler.go client/network_event_stream_handler.go types/events_generated.go docs/events.md > /tmp/unified/generated.diff
+
+diff_size=$(wc -c /tmp/unified/generated.diff | awk '{print $1}')
+
+if [ $diff_size == 0 ]; then   
+    echo "OK - No difference"; 
+    exit 0
+else
+    echo "ERROR - 'mage generateStreamHandlers' caused a diff to appear! "
+    echo "Please check in changes to client/protect_*_stream_handler.go, client/network_event_stream_handler.go, types/events_generated.go and docs/events.md"
+    exit 1
+fi
+
+rm /tmp/unified/generated.diff
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"go/format"
 	"os"
 	"reflect"
 	"strings"
@@ -225,27 +227,178 @@ func renderStreamHandlerToFile(args *StreamHandlerArguments) error {
 	return nil
 }
 
+const eventsFilePackage = "package types\n"
+
+const eventsFileStream = `
+// All{{.StreamType}}s lists the zero value of every {{.StreamType}} item type.
+var All{{.StreamType}}s = []interface{}{
+{{- range .Registrations}}
+	{{goType .Event}}{},
+{{- end}}
+}
+
+// {{.StreamType}}Types lists every recognized {{.StreamType}} item key.
+var {{.StreamType}}Types = []string{
+{{- range .Registrations}}
+	"{{.Key}}",
+{{- end}}
+}
+
+func new{{.StreamType}}Item(key string) interface{} {
+	switch key {
+{{- range .Registrations}}
+	case "{{.Key}}":
+		return &{{goType .Event}}{}
+{{- end}}
+	default:
+		return nil
+	}
+}
+`
+
+const eventsDocHeader = `<!-- (!) DO NOT EDIT (!) Generated by generate_stream_handlers -->
+
+# Events
+
+Every event type unified decodes, as declared in
+[types.EventRegistry](/types/event_registry.go). The key is the value matched
+by the ` + "`--type`" + ` flag of ` + "`unified protect subscribe`" + ` commands and of
+` + "`unified network subscribe`" + `.
+`
+
+const eventsDocStream = `
+## {{.StreamType}}
+
+| Key | Go Type | Description |
+| --- | ------- | ----------- |
+{{- range .Registrations}}
+| ` + "`{{.Key}}`" + ` | ` + "`types.{{goType .Event}}`" + ` | {{.Description}} |
+{{- end}}
+`
+
+type StreamRegistrations struct {
+	StreamType    string
+	Registrations []types.EventRegistration
+}
+
+var templateFuncs = template.FuncMap{
+	"goType": func(event interface{}) string {
+		return reflect.TypeOf(event).Name()
+	},
+}
+
+func renderEventsFile(filename string, streams []*StreamRegistrations) error {
+	streamTemplate := template.Must(template.New("eventsFileStream").
+		Funcs(templateFuncs).Parse(eventsFileStream))
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(eventsFilePackage)
+	buf.WriteString(topOfFileComment)
+	for _, stream := range streams {
+		err := streamTemplate.Execute(buf, stream)
+		if err != nil {
+			return err
+		}
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filename, source, 0600)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"filename": filename,
+	}).Info("Event registry successfully rendered to file.")
+	return nil
+}
+
+func renderEventsDoc(filename string, streams []*StreamRegistrations) error {
+	streamTemplate := template.Must(template.New("eventsDocStream").
+		Funcs(templateFuncs).Parse(eventsDocStream))
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(eventsDocHeader)
+	for _, stream := range streams {
+		err := streamTemplate.Execute(buf, stream)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := os.WriteFile(filename, buf.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"filename": filename,
+	}).Info("Event documentation successfully rendered to file.")
+	return nil
+}
+
+// Groups types.EventRegistry by stream, preserving registry order.
+func registrationsByStream() []*StreamRegistrations {
+	streams := []*StreamRegistrations{}
+	byType := map[types.EventStream]*StreamRegistrations{}
+	for _, registration := range types.EventRegistry {
+		stream, ok := byType[registration.Stream]
+		if !ok {
+			stream = &StreamRegistrations{StreamType: string(registration.Stream)}
+			byType[registration.Stream] = stream
+			streams = append(streams, stream)
+		}
+		stream.Registrations = append(stream.Registrations, registration)
+	}
+	return streams
+}
+
 func main() {
-	streamArgs := []*StreamHandlerArguments{
-		{
-			Filename:      "client/protect_device_update_stream_handler.go",
-			PackageName:   "client",
-			StreamType:    "ProtectDeviceEvent",
-			AllEventTypes: types.AllProtectDeviceEvents,
-		},
-		{
-			Filename:      "client/protect_event_stream_handler.go",
-			PackageName:   "client",
-			StreamType:    "ProtectEvent",
-			AllEventTypes: types.AllProtectEvents,
-		},
+	streams := registrationsByStream()
+
+	handlerFilenames := map[string]string{
+		"ProtectDeviceEvent": "client/protect_device_update_stream_handler.go",
+		"ProtectEvent":       "client/protect_event_stream_handler.go",
+		"NetworkEvent":       "client/network_event_stream_handler.go",
 	}
 
-	for _, streamArg := range streamArgs {
-		err := renderStreamHandlerToFile(streamArg)
+	for _, stream := range streams {
+		filename, ok := handlerFilenames[stream.StreamType]
+		if !ok {
+			log.Errorf("No stream handler filename for stream '%s'", stream.StreamType)
+			return
+		}
+
+		allEventTypes := []interface{}{}
+		for _, registration := range stream.Registrations {
+			allEventTypes = append(allEventTypes, registration.Event)
+		}
+
+		err := renderStreamHandlerToFile(&StreamHandlerArguments{
+			Filename:      filename,
+			PackageName:   "client",
+			StreamType:    stream.StreamType,
+			AllEventTypes: allEventTypes,
+		})
 		if err != nil {
 			log.Error(err.Error())
 			return
 		}
 	}
+
+	err := renderEventsFile("types/events_generated.go", streams)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	err = renderEventsDoc("docs/events.md", streams)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
 }
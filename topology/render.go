@@ -0,0 +1,194 @@
+package topology
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+// Formats Render supports.
+const (
+	FormatASCII   = "ascii"
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+var Formats = []string{FormatASCII, FormatDOT, FormatMermaid}
+
+// Render writes the graph to w in one of Formats.
+func (g *Graph) Render(w io.Writer, format string) error {
+	switch format {
+	case FormatASCII:
+		return g.RenderASCII(w)
+	case FormatDOT:
+		return g.RenderDOT(w)
+	case FormatMermaid:
+		return g.RenderMermaid(w)
+	default:
+		return fmt.Errorf("unknown topology format '%s', must be one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Label describes a device, e.g. "Office Switch (USW-24, 192.168.1.2)", with
+// its state if it isn't online.
+func Label(node *Node) string {
+	details := []string{}
+	if node.Device.Model != "" {
+		details = append(details, node.Device.Model)
+	}
+	if node.Device.IPAddress != "" {
+		details = append(details, node.Device.IPAddress)
+	}
+	label := node.Name()
+	if len(details) > 0 {
+		label += " (" + strings.Join(details, ", ") + ")"
+	}
+	if isOffline(node) {
+		label += " [" + node.Device.State.String() + "]"
+	}
+	return label
+}
+
+// RenderASCII writes the graph as a tree per root, followed by any orphans
+// and loops.
+func (g *Graph) RenderASCII(w io.Writer) error {
+	var builder strings.Builder
+	loopBack := map[*Node]*Node{}
+	for _, loop := range g.Loops {
+		loopBack[loop[len(loop)-1]] = loop[0]
+	}
+
+	for _, root := range g.Roots {
+		writeASCIITree(&builder, root, "", loopBack)
+	}
+
+	if len(g.Orphans) > 0 {
+		builder.WriteString("\nOrphans, whose uplink is not a device of this site:\n")
+		for _, orphan := range g.Orphans {
+			builder.WriteString("? unknown uplink " + orphan.Device.Uplink.DeviceID + "\n")
+			writeASCIIBranch(&builder, orphan, "", true, loopBack)
+		}
+	}
+
+	if len(g.Loops) > 0 {
+		builder.WriteString("\nLoops, which are not connected to a gateway:\n")
+		for _, loop := range g.Loops {
+			writeASCIITree(&builder, loop[0], "", loopBack)
+		}
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+func writeASCIITree(builder *strings.Builder, node *Node, prefix string, loopBack map[*Node]*Node) {
+	builder.WriteString(Label(node) + "\n")
+	back, closesLoop := loopBack[node]
+	for i, downlink := range node.Downlinks {
+		writeASCIIBranch(builder, downlink, prefix, i == len(node.Downlinks)-1 && !closesLoop, loopBack)
+	}
+	if closesLoop {
+		builder.WriteString(prefix + "└── ↺ " + back.Name() + " (loop)\n")
+	}
+}
+
+func writeASCIIBranch(builder *strings.Builder, node *Node, prefix string, last bool, loopBack map[*Node]*Node) {
+	branch, indent := "├── ", "│   "
+	if last {
+		branch, indent = "└── ", "    "
+	}
+	builder.WriteString(prefix + branch)
+	writeASCIITree(builder, node, prefix+indent, loopBack)
+}
+
+// RenderDOT writes the graph in Graphviz DOT format. Edges which close a loop
+// are red, and orphans hang off a dashed placeholder for their missing uplink.
+func (g *Graph) RenderDOT(w io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString("digraph topology {\n")
+	builder.WriteString("  node [shape=box];\n")
+
+	for _, node := range g.sortedNodes() {
+		attributes := "label=" + strconv.Quote(Label(node))
+		if isOffline(node) {
+			attributes += ", color=gray"
+		}
+		fmt.Fprintf(&builder, "  %s [%s];\n", strconv.Quote(node.Device.ID), attributes)
+	}
+
+	for _, orphan := range g.Orphans {
+		missing := "missing:" + orphan.Device.Uplink.DeviceID
+		fmt.Fprintf(&builder, "  %s [label=%s, style=dashed];\n",
+			strconv.Quote(missing), strconv.Quote("unknown uplink\n"+orphan.Device.Uplink.DeviceID))
+		fmt.Fprintf(&builder, "  %s -> %s [style=dashed];\n", strconv.Quote(missing), strconv.Quote(orphan.Device.ID))
+	}
+
+	for _, node := range g.sortedNodes() {
+		if node.Uplink == nil {
+			continue
+		}
+		attributes := ""
+		if g.closesLoop(node) {
+			attributes = ` [color=red, label="loop"]`
+		}
+		fmt.Fprintf(&builder, "  %s -> %s%s;\n",
+			strconv.Quote(node.Uplink.Device.ID), strconv.Quote(node.Device.ID), attributes)
+	}
+
+	builder.WriteString("}\n")
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// RenderMermaid writes the graph as a Mermaid flowchart, e.g. for embedding
+// in Markdown. Edges which close a loop are dotted and labelled, and orphans
+// hang off a placeholder for their missing uplink.
+func (g *Graph) RenderMermaid(w io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString("graph TD\n")
+
+	ids := map[*Node]string{}
+	for i, node := range g.sortedNodes() {
+		ids[node] = fmt.Sprintf("n%d", i)
+		class := ""
+		if isOffline(node) {
+			class = ":::offline"
+		}
+		fmt.Fprintf(&builder, "  %s[\"%s\"]%s\n", ids[node], mermaidEscape(Label(node)), class)
+	}
+
+	for i, orphan := range g.Orphans {
+		missing := fmt.Sprintf("missing%d", i)
+		fmt.Fprintf(&builder, "  %s[\"unknown uplink %s\"]:::missing\n",
+			missing, mermaidEscape(orphan.Device.Uplink.DeviceID))
+		fmt.Fprintf(&builder, "  %s -.-> %s\n", missing, ids[orphan])
+	}
+
+	for _, node := range g.sortedNodes() {
+		if node.Uplink == nil {
+			continue
+		}
+		arrow := "-->"
+		if g.closesLoop(node) {
+			arrow = "-. loop .->"
+		}
+		fmt.Fprintf(&builder, "  %s %s %s\n", ids[node.Uplink], arrow, ids[node])
+	}
+
+	builder.WriteString("  classDef offline stroke-dasharray: 5 5, color: gray\n")
+	builder.WriteString("  classDef missing stroke-dasharray: 5 5, color: red\n")
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// Returns true if the device is known not to be online.
+func isOffline(node *Node) bool {
+	return node.Device.State != "" && node.Device.State != types.DeviceStateOnline
+}
+
+func mermaidEscape(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
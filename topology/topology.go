@@ -0,0 +1,159 @@
+// Package topology reconstructs the physical layout of a Network site from
+// the uplink of each device, and renders it as a tree or graph.
+package topology
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Node is a device in the uplink graph.
+type Node struct {
+	Device *types.Device
+	// Uplink is the device this one is connected to, or nil for a gateway
+	// or orphan.
+	Uplink *Node
+	// Downlinks are the devices connected to this one, sorted by name. The
+	// uplink which closes a loop is left out, so every node appears once
+	// when walking Downlinks from Roots, Orphans and Loops.
+	Downlinks []*Node
+}
+
+// Name returns the device's name, or its ID if it has none.
+func (n *Node) Name() string {
+	if n.Device.Name != "" {
+		return n.Device.Name
+	}
+	return n.Device.ID
+}
+
+// Depth returns the number of uplinks between the device and its root. For a
+// device in or below a loop, the walk stops when it comes back around.
+func (n *Node) Depth() int {
+	seen := map[*Node]bool{n: true}
+	depth := 0
+	for uplink := n.Uplink; uplink != nil && !seen[uplink]; uplink = uplink.Uplink {
+		seen[uplink] = true
+		depth++
+	}
+	return depth
+}
+
+// Graph is the uplink graph of a site's devices.
+type Graph struct {
+	// Nodes by device ID.
+	Nodes map[string]*Node
+	// Roots are devices without an uplink, normally the gateway.
+	Roots []*Node
+	// Orphans are devices whose uplink isn't a device of the site, e.g.
+	// because it was removed.
+	Orphans []*Node
+	// Loops are cycles of uplinks, in downlink order. Each starts from the
+	// device it is rendered from, whose uplink closes the loop.
+	Loops [][]*Node
+}
+
+// Build returns the uplink graph of devices.
+func Build(devices []*types.Device) *Graph {
+	graph := &Graph{Nodes: map[string]*Node{}}
+	for _, device := range devices {
+		graph.Nodes[device.ID] = &Node{Device: device}
+	}
+
+	for _, node := range graph.sortedNodes() {
+		uplinkID := node.Device.Uplink.DeviceID
+		switch uplink, ok := graph.Nodes[uplinkID]; {
+		case uplinkID == "":
+			graph.Roots = append(graph.Roots, node)
+		case !ok:
+			graph.Orphans = append(graph.Orphans, node)
+		default:
+			node.Uplink = uplink
+		}
+	}
+
+	graph.findLoops()
+
+	for _, node := range graph.sortedNodes() {
+		if node.Uplink != nil && !graph.closesLoop(node) {
+			node.Uplink.Downlinks = append(node.Uplink.Downlinks, node)
+		}
+	}
+	return graph
+}
+
+// Fetch lists every device of a site with its details, and returns their
+// uplink graph.
+func Fetch(network types.NetworkV1, siteID types.SiteID) (*Graph, error) {
+	devices, err := client.AllDeviceDetails(network, siteID)
+	if err != nil {
+		return nil, err
+	}
+	return Build(devices), nil
+}
+
+// Returns the nodes sorted by name, then ID, so output is stable.
+func (g *Graph) sortedNodes() []*Node {
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes = append(nodes, node)
+	}
+	slices.SortFunc(nodes, compareNodes)
+	return nodes
+}
+
+func compareNodes(a, b *Node) int {
+	return cmp.Or(cmp.Compare(a.Name(), b.Name()), cmp.Compare(a.Device.ID, b.Device.ID))
+}
+
+// Finds every cycle of uplinks. Nodes are visited in sorted order, so each
+// loop starts from the first of its nodes to be visited.
+func (g *Graph) findLoops() {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[*Node]int{}
+
+	for _, start := range g.sortedNodes() {
+		path := []*Node{}
+		node := start
+		for node != nil && state[node] == unvisited {
+			state[node] = visiting
+			path = append(path, node)
+			node = node.Uplink
+		}
+		if node != nil && state[node] == visiting {
+			// The path ran into itself. The loop is the part of the path from
+			// node onwards, which is walked in reverse to follow downlinks.
+			i := slices.Index(path, node)
+			loop := slices.Clone(path[i:])
+			slices.Reverse(loop)
+			loop = append([]*Node{loop[len(loop)-1]}, loop[:len(loop)-1]...)
+			g.Loops = append(g.Loops, loop)
+		}
+		for _, visited := range path {
+			state[visited] = done
+		}
+	}
+}
+
+// Returns true if node's uplink closes one of the loops, i.e. node is the first
+// in a loop.
+func (g *Graph) closesLoop(node *Node) bool {
+	for _, loop := range g.Loops {
+		if loop[0] == node {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHealthy returns true if every device is reachable from a root.
+func (g *Graph) IsHealthy() bool {
+	return len(g.Orphans) == 0 && len(g.Loops) == 0
+}
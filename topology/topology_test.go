@@ -0,0 +1,119 @@
+package topology_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/topology"
+	"github.com/ClifHouck/unified/types"
+)
+
+func device(id, name, uplink string) *types.Device {
+	d := &types.Device{ID: id, Name: name, Model: "USW", State: types.DeviceStateOnline}
+	d.Uplink.DeviceID = uplink
+	return d
+}
+
+func siteDevices() []*types.Device {
+	offline := device("ap2", "Garage AP", "sw1")
+	offline.State = types.DeviceStateOffline
+	return []*types.Device{
+		device("gw", "Gateway", ""),
+		device("sw1", "Core Switch", "gw"),
+		device("ap1", "Attic AP", "sw1"),
+		offline,
+		device("ap3", "Shed AP", "removed"),
+		device("loopA", "Loop A", "loopB"),
+		device("loopB", "Loop B", "loopA"),
+		device("self", "Self", "self"),
+	}
+}
+
+func names(nodes []*topology.Node) []string {
+	result := []string{}
+	for _, node := range nodes {
+		result = append(result, node.Name())
+	}
+	return result
+}
+
+func TestBuild(t *testing.T) {
+	graph := topology.Build(siteDevices())
+
+	assert.Equal(t, []string{"Gateway"}, names(graph.Roots))
+	assert.Equal(t, []string{"Core Switch"}, names(graph.Nodes["gw"].Downlinks))
+	assert.Equal(t, []string{"Attic AP", "Garage AP"}, names(graph.Nodes["sw1"].Downlinks))
+	assert.Equal(t, []string{"Shed AP"}, names(graph.Orphans))
+
+	require.Len(t, graph.Loops, 2)
+	assert.Equal(t, []string{"Loop A", "Loop B"}, names(graph.Loops[0]))
+	assert.Equal(t, []string{"Self"}, names(graph.Loops[1]))
+	assert.False(t, graph.IsHealthy())
+
+	assert.True(t, topology.Build(siteDevices()[:4]).IsHealthy())
+}
+
+func TestDepth(t *testing.T) {
+	graph := topology.Build(siteDevices())
+
+	assert.Equal(t, 0, graph.Nodes["gw"].Depth())
+	assert.Equal(t, 2, graph.Nodes["ap1"].Depth())
+	assert.Equal(t, 0, graph.Nodes["ap3"].Depth())
+	assert.Equal(t, 1, graph.Nodes["loopA"].Depth())
+	assert.Equal(t, 0, graph.Nodes["self"].Depth())
+}
+
+func TestRenderASCII(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, topology.Build(siteDevices()).Render(&out, topology.FormatASCII))
+
+	assert.Equal(t, `Gateway (USW)
+└── Core Switch (USW)
+    ├── Attic AP (USW)
+    └── Garage AP (USW) [OFFLINE]
+
+Orphans, whose uplink is not a device of this site:
+? unknown uplink removed
+└── Shed AP (USW)
+
+Loops, which are not connected to a gateway:
+Loop A (USW)
+└── Loop B (USW)
+    └── ↺ Loop A (loop)
+Self (USW)
+└── ↺ Self (loop)
+`, out.String())
+}
+
+func TestRenderMermaid(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, topology.Build(siteDevices()[:4]).Render(&out, topology.FormatMermaid))
+
+	assert.Equal(t, `graph TD
+  n0["Attic AP (USW)"]
+  n1["Core Switch (USW)"]
+  n2["Garage AP (USW) [OFFLINE]"]:::offline
+  n3["Gateway (USW)"]
+  n1 --> n0
+  n3 --> n1
+  n1 --> n2
+  classDef offline stroke-dasharray: 5 5, color: gray
+  classDef missing stroke-dasharray: 5 5, color: red
+`, out.String())
+}
+
+func TestRenderDOTMarksLoopsAndOrphans(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, topology.Build(siteDevices()).Render(&out, topology.FormatDOT))
+
+	dot := out.String()
+	assert.Contains(t, dot, `"missing:removed" -> "ap3" [style=dashed];`)
+	assert.Contains(t, dot, `"loopB" -> "loopA" [color=red, label="loop"];`)
+	assert.Contains(t, dot, `"loopA" -> "loopB";`)
+	assert.Contains(t, dot, `"gw" -> "sw1";`)
+
+	assert.Error(t, topology.Build(nil).Render(&out, "svg"))
+}
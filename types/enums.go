@@ -0,0 +1,232 @@
+package types
+
+import (
+	"slices"
+)
+
+// Enumerated string values used by the Network and Protect APIs.
+//
+// Each enum is a named string type, so values unified doesn't know about yet
+// still unmarshal, and marshal back unchanged. Valid reports whether a value
+// is one of the known constants, which are listed in All<Type>.
+
+// ModelKey identifies the kind of a Protect object.
+type ModelKey string
+
+const (
+	ModelKeyCamera      ModelKey = "camera"
+	ModelKeyNVR         ModelKey = "nvr"
+	ModelKeyChime       ModelKey = "chime"
+	ModelKeyLight       ModelKey = "light"
+	ModelKeyViewer      ModelKey = "viewer"
+	ModelKeySpeaker     ModelKey = "speaker"
+	ModelKeyBridge      ModelKey = "bridge"
+	ModelKeyDoorlock    ModelKey = "doorlock"
+	ModelKeySensor      ModelKey = "sensor"
+	ModelKeyAIProcessor ModelKey = "aiProcessor"
+	ModelKeyAIPort      ModelKey = "aiPort"
+	ModelKeyLinkStation ModelKey = "linkStation"
+	ModelKeyLiveView    ModelKey = "liveview"
+	ModelKeyEvent       ModelKey = "event"
+)
+
+var AllModelKeys = []ModelKey{
+	ModelKeyCamera, ModelKeyNVR, ModelKeyChime, ModelKeyLight, ModelKeyViewer,
+	ModelKeySpeaker, ModelKeyBridge, ModelKeyDoorlock, ModelKeySensor,
+	ModelKeyAIProcessor, ModelKeyAIPort, ModelKeyLinkStation, ModelKeyLiveView,
+	ModelKeyEvent,
+}
+
+func (mk ModelKey) Valid() bool    { return slices.Contains(AllModelKeys, mk) }
+func (mk ModelKey) String() string { return string(mk) }
+
+// ProtectDeviceState is the connection state of a Protect device.
+type ProtectDeviceState string
+
+const (
+	ProtectDeviceStateConnected    ProtectDeviceState = "CONNECTED"
+	ProtectDeviceStateConnecting   ProtectDeviceState = "CONNECTING"
+	ProtectDeviceStateDisconnected ProtectDeviceState = "DISCONNECTED"
+)
+
+var AllProtectDeviceStates = []ProtectDeviceState{
+	ProtectDeviceStateConnected, ProtectDeviceStateConnecting, ProtectDeviceStateDisconnected,
+}
+
+func (pds ProtectDeviceState) Valid() bool    { return slices.Contains(AllProtectDeviceStates, pds) }
+func (pds ProtectDeviceState) String() string { return string(pds) }
+
+// VideoMode is a camera's video mode. Cameras list the modes they support in
+// FeatureFlags.VideoModes.
+type VideoMode string
+
+const (
+	VideoModeDefault       VideoMode = "default"
+	VideoModeHighFps       VideoMode = "highFps"
+	VideoModeSport         VideoMode = "sport"
+	VideoModeSlowShutter   VideoMode = "slowShutter"
+	VideoModeLprReflex     VideoMode = "lprReflex"
+	VideoModeLprNoneReflex VideoMode = "lprNoneReflex"
+)
+
+var AllVideoModes = []VideoMode{
+	VideoModeDefault, VideoModeHighFps, VideoModeSport, VideoModeSlowShutter,
+	VideoModeLprReflex, VideoModeLprNoneReflex,
+}
+
+func (vm VideoMode) Valid() bool    { return slices.Contains(AllVideoModes, vm) }
+func (vm VideoMode) String() string { return string(vm) }
+
+// HdrType is a camera's HDR mode.
+type HdrType string
+
+const (
+	HdrTypeAuto HdrType = "auto"
+	HdrTypeOn   HdrType = "on"
+	HdrTypeOff  HdrType = "off"
+)
+
+var AllHdrTypes = []HdrType{HdrTypeAuto, HdrTypeOn, HdrTypeOff}
+
+func (ht HdrType) Valid() bool    { return slices.Contains(AllHdrTypes, ht) }
+func (ht HdrType) String() string { return string(ht) }
+
+// LightMode is what turns a light on.
+type LightMode string
+
+const (
+	LightModeAlways LightMode = "always"
+	LightModeMotion LightMode = "motion"
+	LightModeOff    LightMode = "off"
+)
+
+var AllLightModes = []LightMode{LightModeAlways, LightModeMotion, LightModeOff}
+
+func (lm LightMode) Valid() bool    { return slices.Contains(AllLightModes, lm) }
+func (lm LightMode) String() string { return string(lm) }
+
+// LightEnableAt is when a light's mode applies.
+type LightEnableAt string
+
+const (
+	LightEnableAtFullTime LightEnableAt = "fulltime"
+	LightEnableAtDark     LightEnableAt = "dark"
+)
+
+var AllLightEnableAts = []LightEnableAt{LightEnableAtFullTime, LightEnableAtDark}
+
+func (lea LightEnableAt) Valid() bool    { return slices.Contains(AllLightEnableAts, lea) }
+func (lea LightEnableAt) String() string { return string(lea) }
+
+// DeviceState is the state of a Network device.
+type DeviceState string
+
+const (
+	DeviceStateOnline                DeviceState = "ONLINE"
+	DeviceStateOffline               DeviceState = "OFFLINE"
+	DeviceStatePendingAdoption       DeviceState = "PENDING_ADOPTION"
+	DeviceStateUpdating              DeviceState = "UPDATING"
+	DeviceStateGettingReady          DeviceState = "GETTING_READY"
+	DeviceStateAdopting              DeviceState = "ADOPTING"
+	DeviceStateDeleting              DeviceState = "DELETING"
+	DeviceStateConnectionInterrupted DeviceState = "CONNECTION_INTERRUPTED"
+	DeviceStateIsolated              DeviceState = "ISOLATED"
+)
+
+var AllDeviceStates = []DeviceState{
+	DeviceStateOnline, DeviceStateOffline, DeviceStatePendingAdoption,
+	DeviceStateUpdating, DeviceStateGettingReady, DeviceStateAdopting,
+	DeviceStateDeleting, DeviceStateConnectionInterrupted, DeviceStateIsolated,
+}
+
+func (ds DeviceState) Valid() bool    { return slices.Contains(AllDeviceStates, ds) }
+func (ds DeviceState) String() string { return string(ds) }
+
+// PortState is the link state of a Network device port.
+type PortState string
+
+const (
+	PortStateUp      PortState = "UP"
+	PortStateDown    PortState = "DOWN"
+	PortStateUnknown PortState = "UNKNOWN"
+)
+
+var AllPortStates = []PortState{PortStateUp, PortStateDown, PortStateUnknown}
+
+func (ps PortState) Valid() bool    { return slices.Contains(AllPortStates, ps) }
+func (ps PortState) String() string { return string(ps) }
+
+// ClientType is how a Network client is connected.
+type ClientType string
+
+const (
+	ClientTypeWired    ClientType = "WIRED"
+	ClientTypeWireless ClientType = "WIRELESS"
+	ClientTypeVPN      ClientType = "VPN"
+	ClientTypeTeleport ClientType = "TELEPORT"
+)
+
+var AllClientTypes = []ClientType{ClientTypeWired, ClientTypeWireless, ClientTypeVPN, ClientTypeTeleport}
+
+func (ct ClientType) Valid() bool    { return slices.Contains(AllClientTypes, ct) }
+func (ct ClientType) String() string { return string(ct) }
+
+// ClientAccessType is the kind of network access a Network client has.
+type ClientAccessType string
+
+const (
+	ClientAccessTypeDefault ClientAccessType = "DEFAULT"
+	ClientAccessTypeGuest   ClientAccessType = "GUEST"
+)
+
+var AllClientAccessTypes = []ClientAccessType{ClientAccessTypeDefault, ClientAccessTypeGuest}
+
+func (cat ClientAccessType) Valid() bool    { return slices.Contains(AllClientAccessTypes, cat) }
+func (cat ClientAccessType) String() string { return string(cat) }
+
+// ClientAction is an action which can be executed on a Network client.
+type ClientAction string
+
+const (
+	ClientActionAuthorizeGuestAccess   ClientAction = "AUTHORIZE_GUEST_ACCESS"
+	ClientActionUnauthorizeGuestAccess ClientAction = "UNAUTHORIZE_GUEST_ACCESS"
+)
+
+var AllClientActions = []ClientAction{ClientActionAuthorizeGuestAccess, ClientActionUnauthorizeGuestAccess}
+
+func (ca ClientAction) Valid() bool    { return slices.Contains(AllClientActions, ca) }
+func (ca ClientAction) String() string { return string(ca) }
+
+// DeviceAction is an action which can be executed on a Network device.
+type DeviceAction string
+
+const (
+	DeviceActionRestart DeviceAction = "RESTART"
+)
+
+var AllDeviceActions = []DeviceAction{DeviceActionRestart}
+
+func (da DeviceAction) Valid() bool    { return slices.Contains(AllDeviceActions, da) }
+func (da DeviceAction) String() string { return string(da) }
+
+// PortAction is an action which can be executed on a Network device port.
+type PortAction string
+
+const (
+	PortActionPowerCycle PortAction = "POWER_CYCLE"
+)
+
+var AllPortActions = []PortAction{PortActionPowerCycle}
+
+func (pa PortAction) Valid() bool    { return slices.Contains(AllPortActions, pa) }
+func (pa PortAction) String() string { return string(pa) }
+
+// EnumStrings returns enum values, such as one of the All<Type> lists, as
+// strings.
+func EnumStrings[E ~string](values []E) []string {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		strs = append(strs, string(value))
+	}
+	return strs
+}
@@ -0,0 +1,65 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+func TestEnumsRoundTripUnknownValues(t *testing.T) {
+	input := `{"id":"cam1","modelKey":"camera","state":"REBOOTING","videoMode":"superSlowMo","hdrType":"auto"}`
+
+	var camera struct {
+		ID        string                   `json:"id"`
+		ModelKey  types.ModelKey           `json:"modelKey"`
+		State     types.ProtectDeviceState `json:"state"`
+		VideoMode types.VideoMode          `json:"videoMode"`
+		HdrType   types.HdrType            `json:"hdrType"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(input), &camera))
+
+	assert.Equal(t, types.ModelKeyCamera, camera.ModelKey)
+	assert.True(t, camera.ModelKey.Valid())
+	assert.True(t, camera.HdrType.Valid())
+	assert.False(t, camera.State.Valid())
+	assert.False(t, camera.VideoMode.Valid())
+	assert.Equal(t, "superSlowMo", camera.VideoMode.String())
+
+	data, err := json.Marshal(camera)
+	require.NoError(t, err)
+	assert.JSONEq(t, input, string(data))
+}
+
+func TestDeviceDecodesTypedStates(t *testing.T) {
+	var device types.Device
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"state": "ONLINE",
+		"interfaces": {"ports": [{"idx": 1, "state": "UP"}, {"idx": 2, "state": "FLAPPING"}]}
+	}`), &device))
+
+	assert.Equal(t, types.DeviceStateOnline, device.State)
+	assert.Equal(t, types.PortStateUp, device.Interfaces.Ports[0].State)
+	assert.False(t, device.Interfaces.Ports[1].State.Valid())
+}
+
+func TestUnixMillis(t *testing.T) {
+	var sensor types.Sensor
+	require.NoError(t, json.Unmarshal([]byte(`{"motionDetectedAt": 1700000000123, "leakDetectedAt": 0}`), &sensor))
+
+	assert.Equal(t, time.UnixMilli(1700000000123), sensor.MotionDetectedAt.Time())
+	assert.True(t, sensor.LeakDetectedAt.IsZero())
+	assert.True(t, sensor.LeakDetectedAt.Time().IsZero())
+
+	data, err := json.Marshal(struct {
+		At types.UnixMillis `json:"at"`
+	}{At: types.NewUnixMillis(time.UnixMilli(1700000000123))})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"at": 1700000000123}`, string(data))
+
+	assert.Equal(t, types.UnixMillis(0), types.NewUnixMillis(time.Time{}))
+}
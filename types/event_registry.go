@@ -0,0 +1,244 @@
+package types
+
+// EventStream names the stream an event type is delivered on. Its value is
+// also the name of the stream's Go type, e.g. ProtectEvent.
+type EventStream string
+
+const (
+	ProtectEventStream       EventStream = "ProtectEvent"
+	ProtectDeviceEventStream EventStream = "ProtectDeviceEvent"
+	NetworkEventStream       EventStream = "NetworkEvent"
+)
+
+// EventRegistration declares a single event type carried by one of the
+// event streams.
+type EventRegistration struct {
+	// Key is the value of the discriminating JSON field of the item. That
+	// is `type` for ProtectEvent and NetworkEvent items and `modelKey` for
+	// ProtectDeviceEvent items.
+	Key string
+	// Event is the zero value of the Go type the item is decoded into.
+	Event interface{}
+	// Stream is the stream the event is delivered on.
+	Stream EventStream
+	// Description is a short, human-readable summary of the event.
+	Description string
+}
+
+// EventRegistry is the single source of truth for every event type unified
+// knows how to decode. generate_stream_handlers renders the unmarshal
+// switches, the All* lists, the client stream handlers and docs/events.md
+// from it, so adding an event type means adding its struct and an entry
+// here, then running `mage generateStreamHandlers`.
+//
+// Order matters: generated code follows the order of this list.
+var EventRegistry = []EventRegistration{
+	// Protect events
+	{
+		Key:         "ring",
+		Event:       RingEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A doorbell was rung",
+	},
+	{
+		Key:         "sensorExtremeValues",
+		Event:       SensorExtremeValuesEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A sensor reading went outside of its configured thresholds",
+	},
+	{
+		Key:         "sensorWaterLeak",
+		Event:       SensorWaterLeakEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A sensor detected a water leak",
+	},
+	{
+		Key:         "sensorTamper",
+		Event:       SensorTamperEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A sensor was tampered with",
+	},
+	{
+		Key:         "sensorBatteryLow",
+		Event:       SensorBatteryLowEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A sensor's battery is running low",
+	},
+	{
+		Key:         "sensorAlarm",
+		Event:       SensorAlarmEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A sensor heard a smoke or CO alarm",
+	},
+	{
+		Key:         "sensorOpened",
+		Event:       SensorOpenedEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A door or window sensor was opened",
+	},
+	{
+		Key:         "sensorClosed",
+		Event:       SensorClosedEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A door or window sensor was closed",
+	},
+	{
+		Key:         "sensorMotion",
+		Event:       SensorMotionEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A sensor detected motion",
+	},
+	{
+		Key:         "lightMotion",
+		Event:       LightMotionEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A light detected motion",
+	},
+	{
+		Key:         "motion",
+		Event:       CameraMotionEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A camera detected motion",
+	},
+	{
+		Key:         "smartAudioDetect",
+		Event:       CameraSmartAudioDetectEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A camera recognized a sound, e.g. a smoke alarm or glass break",
+	},
+	{
+		Key:         "smartDetectZone",
+		Event:       CameraSmartDetectZoneEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A camera recognized an object, e.g. a person or vehicle, in a zone",
+	},
+	{
+		Key:         "smartDetectLine",
+		Event:       CameraSmartDetectLineEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A camera recognized an object crossing a line",
+	},
+	{
+		Key:         "smartDetectLoiterZone",
+		Event:       CameraSmartDetectLoiterZoneEvent{},
+		Stream:      ProtectEventStream,
+		Description: "A camera recognized an object loitering in a zone",
+	},
+	{
+		Key:         "composite",
+		Event:       CompositeEvent{},
+		Stream:      ProtectEventStream,
+		Description: "Synthesized by the cep package when a rule matched a pattern of other events. Never sent by Protect",
+	},
+
+	// Protect device events
+	{
+		Key:         "camera",
+		Event:       ProtectCameraEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A camera was added, updated or removed",
+	},
+	{
+		Key:         "nvr",
+		Event:       ProtectNVREvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "The NVR was updated",
+	},
+	{
+		Key:         "chime",
+		Event:       ProtectChimeEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A chime was added, updated or removed",
+	},
+	{
+		Key:         "light",
+		Event:       ProtectLightEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A light was added, updated or removed",
+	},
+	{
+		Key:         "viewer",
+		Event:       ProtectViewerEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A viewer was added, updated or removed",
+	},
+	{
+		Key:         "speaker",
+		Event:       ProtectSpeakerEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A speaker was added, updated or removed",
+	},
+	{
+		Key:         "bridge",
+		Event:       ProtectBridgeEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A bridge was added, updated or removed",
+	},
+	{
+		Key:         "doorlock",
+		Event:       ProtectDoorlockEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A door lock was added, updated or removed",
+	},
+	{
+		Key:         "sensor",
+		Event:       ProtectSensorEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A sensor was added, updated or removed",
+	},
+	{
+		Key:         "aiProcessor",
+		Event:       ProtectAIProcessorEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "An AI processor was added, updated or removed",
+	},
+	{
+		Key:         "aiPort",
+		Event:       ProtectAIPortEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "An AI port was added, updated or removed",
+	},
+	{
+		Key:         "linkStation",
+		Event:       ProtectLinkStationEvent{},
+		Stream:      ProtectDeviceEventStream,
+		Description: "A link station was added, updated or removed",
+	},
+	// Network events
+	{
+		Key:         "deviceStateChanged",
+		Event:       DeviceStateChangedEvent{},
+		Stream:      NetworkEventStream,
+		Description: "A device's state changed, e.g. it went offline, or it restarted",
+	},
+	{
+		Key:         "deviceAdopted",
+		Event:       DeviceAdoptedEvent{},
+		Stream:      NetworkEventStream,
+		Description: "A device was adopted",
+	},
+	{
+		Key:         "clientConnected",
+		Event:       ClientConnectedEvent{},
+		Stream:      NetworkEventStream,
+		Description: "A client connected",
+	},
+	{
+		Key:         "clientDisconnected",
+		Event:       ClientDisconnectedEvent{},
+		Stream:      NetworkEventStream,
+		Description: "A client disconnected",
+	},
+	{
+		Key:         "voucherActivated",
+		Event:       VoucherActivatedEvent{},
+		Stream:      NetworkEventStream,
+		Description: "A voucher was used for the first time",
+	},
+	{
+		Key:         "voucherExpired",
+		Event:       VoucherExpiredEvent{},
+		Stream:      NetworkEventStream,
+		Description: "A voucher expired",
+	},
+}
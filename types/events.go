@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 type ProtectEvent struct {
@@ -27,38 +28,8 @@ func (pe *ProtectEvent) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	switch item.Type {
-	case "ring":
-		pe.Item = &RingEvent{}
-	case "sensorExtremeValues":
-		pe.Item = &SensorExtremeValuesEvent{}
-	case "sensorWaterLeak":
-		pe.Item = &SensorWaterLeakEvent{}
-	case "sensorTamper":
-		pe.Item = &SensorTamperEvent{}
-	case "sensorBatteryLow":
-		pe.Item = &SensorBatteryLowEvent{}
-	case "sensorAlarm":
-		pe.Item = &SensorAlarmEvent{}
-	case "sensorOpened":
-		pe.Item = &SensorOpenedEvent{}
-	case "sensorClosed":
-		pe.Item = &SensorClosedEvent{}
-	case "sensorMotion":
-		pe.Item = &SensorMotionEvent{}
-	case "lightMotion":
-		pe.Item = &LightMotionEvent{}
-	case "motion":
-		pe.Item = &CameraMotionEvent{}
-	case "smartAudioDetect":
-		pe.Item = &CameraSmartAudioDetectEvent{}
-	case "smartDetectZone":
-		pe.Item = &CameraSmartDetectZoneEvent{}
-	case "smartDetectLine":
-		pe.Item = &CameraSmartDetectLineEvent{}
-	case "smartDetectLoiterZone":
-		pe.Item = &CameraSmartDetectLoiterZoneEvent{}
-	default:
+	pe.Item = newProtectEventItem(item.Type)
+	if pe.Item == nil {
 		return fmt.Errorf("ProtectEvent unrecognized type '%s'", item.Type)
 	}
 
@@ -72,13 +43,57 @@ func (pe *ProtectEvent) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// EventItem returns the fields common to every Protect event item, or nil if
+// the event has not been decoded.
+func (pe *ProtectEvent) EventItem() *ProtectEventItem {
+	item, ok := pe.Item.(interface{ EventItem() *ProtectEventItem })
+	if !ok {
+		return nil
+	}
+	return item.EventItem()
+}
+
+// SmartDetectTypes returns the object or audio types detected by a smart
+// detection event, e.g. "person", or nil for any other kind of event.
+func (pe *ProtectEvent) SmartDetectTypes() []string {
+	switch item := pe.Item.(type) {
+	case *CameraSmartAudioDetectEvent:
+		return item.SmartDetectTypes
+	case *CameraSmartDetectZoneEvent:
+		return item.SmartDetectTypes
+	case *CameraSmartDetectLineEvent:
+		return item.SmartDetectTypes
+	case *CameraSmartDetectLoiterZoneEvent:
+		return item.SmartDetectTypes
+	}
+	return nil
+}
+
 type ProtectEventItem struct {
-	ID       string `json:"id"`
-	ModelKey string `json:"modelKey"`
-	Type     string `json:"type"`
-	Start    int64  `json:"start"`
-	End      int64  `json:"end"`
-	Device   string `json:"device"`
+	ID       string     `json:"id"`
+	ModelKey string     `json:"modelKey"`
+	Type     string     `json:"type"`
+	Start    UnixMillis `json:"start"`
+	End      UnixMillis `json:"end"`
+	Device   string     `json:"device"`
+}
+
+// EventItem returns pei. Every typed Protect event embeds ProtectEventItem, so
+// this gives uniform access to their common fields.
+func (pei *ProtectEventItem) EventItem() *ProtectEventItem {
+	return pei
+}
+
+// StartTime returns Start, which Protect reports in milliseconds since the
+// Unix epoch, as a time.Time. Returns the zero time if Start is unset.
+func (pei *ProtectEventItem) StartTime() time.Time {
+	return pei.Start.Time()
+}
+
+// EndTime returns End as a time.Time. Returns the zero time if the event
+// has not ended yet.
+func (pei *ProtectEventItem) EndTime() time.Time {
+	return pei.End.Time()
 }
 
 type TextObject struct {
@@ -183,22 +198,22 @@ type CameraSmartDetectLoiterZoneEvent struct {
 	SmartDetectTypes []string `json:"smartDetectTypes"`
 }
 
-var AllProtectEvents = []interface{}{
-	RingEvent{},
-	SensorExtremeValuesEvent{},
-	SensorWaterLeakEvent{},
-	SensorTamperEvent{},
-	SensorBatteryLowEvent{},
-	SensorAlarmEvent{},
-	SensorOpenedEvent{},
-	SensorClosedEvent{},
-	SensorMotionEvent{},
-	LightMotionEvent{},
-	CameraMotionEvent{},
-	CameraSmartAudioDetectEvent{},
-	CameraSmartDetectZoneEvent{},
-	CameraSmartDetectLineEvent{},
-	CameraSmartDetectLoiterZoneEvent{},
+// CompositeEventType is the type of CompositeEvent items.
+const CompositeEventType = "composite"
+
+// CompositeEvent is synthesized by the cep package when one of its rules
+// matches a pattern of other events, e.g. a door opening followed by a person
+// being detected. Start and End are the times of the first and last events
+// which matched.
+type CompositeEvent struct {
+	ProtectEventItem
+	// Rule is the name of the rule which matched.
+	Rule string `json:"rule"`
+	// Devices are the IDs of the devices involved, in the order they first
+	// appear in Events.
+	Devices []string `json:"devices"`
+	// Events are the events which matched the rule, oldest first.
+	Events []*ProtectEvent `json:"events"`
 }
 
 type ProtectDeviceEvent struct {
@@ -225,33 +240,9 @@ func (pde *ProtectDeviceEvent) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	switch item.ModelKey {
-	case "nvr":
-		pde.Item = &ProtectNVREvent{}
-	case "camera":
-		pde.Item = &ProtectCameraEvent{}
-	case "chime":
-		pde.Item = &ProtectChimeEvent{}
-	case "light":
-		pde.Item = &ProtectLightEvent{}
-	case "viewer":
-		pde.Item = &ProtectViewerEvent{}
-	case "speaker":
-		pde.Item = &ProtectSpeakerEvent{}
-	case "bridge":
-		pde.Item = &ProtectBridgeEvent{}
-	case "doorlock":
-		pde.Item = &ProtectDoorlockEvent{}
-	case "sensor":
-		pde.Item = &ProtectSensorEvent{}
-	case "aiProcessor":
-		pde.Item = &ProtectAIProcessorEvent{}
-	case "aiPort":
-		pde.Item = &ProtectAIPortEvent{}
-	case "linkStation":
-		pde.Item = &ProtectLinkStationEvent{}
-	default:
-		return fmt.Errorf("ProtectDeviceEvent unrecognized type '%s'", pde.ModelKey)
+	pde.Item = newProtectDeviceEventItem(item.ModelKey)
+	if pde.Item == nil {
+		return fmt.Errorf("ProtectDeviceEvent unrecognized type '%s'", item.ModelKey)
 	}
 
 	err = json.Unmarshal(pde.RawItem, pde.Item)
@@ -260,15 +251,16 @@ func (pde *ProtectDeviceEvent) UnmarshalJSON(data []byte) error {
 	}
 
 	pde.ModelKey = item.ModelKey
+	pde.ItemType = item.ModelKey
 
 	return nil
 }
 
 type ProtectDeviceEventItem struct {
-	ID       string `json:"id"`
-	ModelKey string `json:"modelKey"`
-	Name     string `json:"name"`
-	State    string `json:"state"`
+	ID       string             `json:"id"`
+	ModelKey string             `json:"modelKey"`
+	Name     string             `json:"name"`
+	State    ProtectDeviceState `json:"state"`
 }
 
 type ProtectCameraEvent Camera
@@ -306,18 +298,3 @@ type ProtectAIPortEvent struct {
 type ProtectLinkStationEvent struct {
 	ProtectDeviceEventItem
 }
-
-var AllProtectDeviceEvents = []interface{}{
-	ProtectCameraEvent{},
-	ProtectNVREvent{},
-	ProtectChimeEvent{},
-	ProtectLightEvent{},
-	ProtectViewerEvent{},
-	ProtectSpeakerEvent{},
-	ProtectBridgeEvent{},
-	ProtectDoorlockEvent{},
-	ProtectSensorEvent{},
-	ProtectAIProcessorEvent{},
-	ProtectAIPortEvent{},
-	ProtectLinkStationEvent{},
-}
@@ -0,0 +1,184 @@
+package types
+
+// (!) DO NOT EDIT (!) Generated by generate_stream_handlers
+
+// AllProtectEvents lists the zero value of every ProtectEvent item type.
+var AllProtectEvents = []interface{}{
+	RingEvent{},
+	SensorExtremeValuesEvent{},
+	SensorWaterLeakEvent{},
+	SensorTamperEvent{},
+	SensorBatteryLowEvent{},
+	SensorAlarmEvent{},
+	SensorOpenedEvent{},
+	SensorClosedEvent{},
+	SensorMotionEvent{},
+	LightMotionEvent{},
+	CameraMotionEvent{},
+	CameraSmartAudioDetectEvent{},
+	CameraSmartDetectZoneEvent{},
+	CameraSmartDetectLineEvent{},
+	CameraSmartDetectLoiterZoneEvent{},
+	CompositeEvent{},
+}
+
+// ProtectEventTypes lists every recognized ProtectEvent item key.
+var ProtectEventTypes = []string{
+	"ring",
+	"sensorExtremeValues",
+	"sensorWaterLeak",
+	"sensorTamper",
+	"sensorBatteryLow",
+	"sensorAlarm",
+	"sensorOpened",
+	"sensorClosed",
+	"sensorMotion",
+	"lightMotion",
+	"motion",
+	"smartAudioDetect",
+	"smartDetectZone",
+	"smartDetectLine",
+	"smartDetectLoiterZone",
+	"composite",
+}
+
+func newProtectEventItem(key string) interface{} {
+	switch key {
+	case "ring":
+		return &RingEvent{}
+	case "sensorExtremeValues":
+		return &SensorExtremeValuesEvent{}
+	case "sensorWaterLeak":
+		return &SensorWaterLeakEvent{}
+	case "sensorTamper":
+		return &SensorTamperEvent{}
+	case "sensorBatteryLow":
+		return &SensorBatteryLowEvent{}
+	case "sensorAlarm":
+		return &SensorAlarmEvent{}
+	case "sensorOpened":
+		return &SensorOpenedEvent{}
+	case "sensorClosed":
+		return &SensorClosedEvent{}
+	case "sensorMotion":
+		return &SensorMotionEvent{}
+	case "lightMotion":
+		return &LightMotionEvent{}
+	case "motion":
+		return &CameraMotionEvent{}
+	case "smartAudioDetect":
+		return &CameraSmartAudioDetectEvent{}
+	case "smartDetectZone":
+		return &CameraSmartDetectZoneEvent{}
+	case "smartDetectLine":
+		return &CameraSmartDetectLineEvent{}
+	case "smartDetectLoiterZone":
+		return &CameraSmartDetectLoiterZoneEvent{}
+	case "composite":
+		return &CompositeEvent{}
+	default:
+		return nil
+	}
+}
+
+// AllProtectDeviceEvents lists the zero value of every ProtectDeviceEvent item type.
+var AllProtectDeviceEvents = []interface{}{
+	ProtectCameraEvent{},
+	ProtectNVREvent{},
+	ProtectChimeEvent{},
+	ProtectLightEvent{},
+	ProtectViewerEvent{},
+	ProtectSpeakerEvent{},
+	ProtectBridgeEvent{},
+	ProtectDoorlockEvent{},
+	ProtectSensorEvent{},
+	ProtectAIProcessorEvent{},
+	ProtectAIPortEvent{},
+	ProtectLinkStationEvent{},
+}
+
+// ProtectDeviceEventTypes lists every recognized ProtectDeviceEvent item key.
+var ProtectDeviceEventTypes = []string{
+	"camera",
+	"nvr",
+	"chime",
+	"light",
+	"viewer",
+	"speaker",
+	"bridge",
+	"doorlock",
+	"sensor",
+	"aiProcessor",
+	"aiPort",
+	"linkStation",
+}
+
+func newProtectDeviceEventItem(key string) interface{} {
+	switch key {
+	case "camera":
+		return &ProtectCameraEvent{}
+	case "nvr":
+		return &ProtectNVREvent{}
+	case "chime":
+		return &ProtectChimeEvent{}
+	case "light":
+		return &ProtectLightEvent{}
+	case "viewer":
+		return &ProtectViewerEvent{}
+	case "speaker":
+		return &ProtectSpeakerEvent{}
+	case "bridge":
+		return &ProtectBridgeEvent{}
+	case "doorlock":
+		return &ProtectDoorlockEvent{}
+	case "sensor":
+		return &ProtectSensorEvent{}
+	case "aiProcessor":
+		return &ProtectAIProcessorEvent{}
+	case "aiPort":
+		return &ProtectAIPortEvent{}
+	case "linkStation":
+		return &ProtectLinkStationEvent{}
+	default:
+		return nil
+	}
+}
+
+// AllNetworkEvents lists the zero value of every NetworkEvent item type.
+var AllNetworkEvents = []interface{}{
+	DeviceStateChangedEvent{},
+	DeviceAdoptedEvent{},
+	ClientConnectedEvent{},
+	ClientDisconnectedEvent{},
+	VoucherActivatedEvent{},
+	VoucherExpiredEvent{},
+}
+
+// NetworkEventTypes lists every recognized NetworkEvent item key.
+var NetworkEventTypes = []string{
+	"deviceStateChanged",
+	"deviceAdopted",
+	"clientConnected",
+	"clientDisconnected",
+	"voucherActivated",
+	"voucherExpired",
+}
+
+func newNetworkEventItem(key string) interface{} {
+	switch key {
+	case "deviceStateChanged":
+		return &DeviceStateChangedEvent{}
+	case "deviceAdopted":
+		return &DeviceAdoptedEvent{}
+	case "clientConnected":
+		return &ClientConnectedEvent{}
+	case "clientDisconnected":
+		return &ClientDisconnectedEvent{}
+	case "voucherActivated":
+		return &VoucherActivatedEvent{}
+	case "voucherExpired":
+		return &VoucherExpiredEvent{}
+	default:
+		return nil
+	}
+}
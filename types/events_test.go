@@ -3,7 +3,6 @@ package types_test
 import (
 	"fmt"
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,16 +11,11 @@ import (
 	"github.com/ClifHouck/unified/types"
 )
 
-func rawTypeNameToJSONEventName(rawTypeName string) string {
-	jsonTypeID := strings.ToLower(rawTypeName[0:1]) + rawTypeName[1:len(rawTypeName)-5]
-	jsonTypeID = strings.ReplaceAll(jsonTypeID, "camera", "")
-	jsonTypeID = strings.ToLower(jsonTypeID[0:1]) + jsonTypeID[1:]
-	return jsonTypeID
-}
-
 func TestAllProtectEventTypesUnmarshalJSON(t *testing.T) {
-	for _, eventObj := range types.AllProtectEvents {
-		jsonEventName := rawTypeNameToJSONEventName(reflect.TypeOf(eventObj).Name())
+	for _, registration := range types.EventRegistry {
+		if registration.Stream != types.ProtectEventStream {
+			continue
+		}
 		testCase := struct {
 			json          string
 			jsonEventName string
@@ -38,19 +32,65 @@ func TestAllProtectEventTypesUnmarshalJSON(t *testing.T) {
 				"type": "%s"
 			  }
 			}`,
-			jsonEventName,
-			eventObj,
+			registration.Key,
+			registration.Event,
 		}
 		testCase.json = fmt.Sprintf(testCase.json, testCase.jsonEventName)
-		t.Run(jsonEventName, func(t *testing.T) {
+		t.Run(testCase.jsonEventName, func(t *testing.T) {
 			var event types.ProtectEvent
 			err := event.UnmarshalJSON([]byte(testCase.json))
 			require.NoError(t, err)
 
 			assert.Equal(t, "add", event.Type)
 			assert.Equal(t, testCase.jsonEventName, event.ItemType)
-			assert.Equal(t, reflect.TypeOf(eventObj).String(),
+			assert.Equal(t, reflect.TypeOf(testCase.eventObj).String(),
+				reflect.TypeOf(event.Item).String()[1:])
+		})
+	}
+}
+
+func TestAllProtectDeviceEventTypesUnmarshalJSON(t *testing.T) {
+	for _, registration := range types.EventRegistry {
+		if registration.Stream != types.ProtectDeviceEventStream {
+			continue
+		}
+		json := fmt.Sprintf(`{
+		  "type": "update",
+		  "item": {
+			"id": "66d025b301ebc903e80003ea",
+			"modelKey": "%s",
+			"name": "Front Door",
+			"state": "CONNECTED"
+		  }
+		}`, registration.Key)
+		t.Run(registration.Key, func(t *testing.T) {
+			var event types.ProtectDeviceEvent
+			err := event.UnmarshalJSON([]byte(json))
+			require.NoError(t, err)
+
+			assert.Equal(t, "update", event.Type)
+			assert.Equal(t, registration.Key, event.ModelKey)
+			assert.Equal(t, registration.Key, event.ItemType)
+			assert.Equal(t, reflect.TypeOf(registration.Event).String(),
 				reflect.TypeOf(event.Item).String()[1:])
 		})
 	}
 }
+
+func TestProtectEventUnmarshalJSONUnknownType(t *testing.T) {
+	var event types.ProtectEvent
+	err := event.UnmarshalJSON([]byte(`{"type": "add", "item": {"type": "notAThing"}}`))
+	require.Error(t, err)
+}
+
+func TestProtectEventSmartDetectTypes(t *testing.T) {
+	var event types.ProtectEvent
+	err := event.UnmarshalJSON([]byte(`{"type": "add", "item": {"type": "smartDetectLine",
+		"smartDetectTypes": ["person", "vehicle"]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"person", "vehicle"}, event.SmartDetectTypes())
+
+	err = event.UnmarshalJSON([]byte(`{"type": "add", "item": {"type": "motion"}}`))
+	require.NoError(t, err)
+	assert.Nil(t, event.SmartDetectTypes())
+}
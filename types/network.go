@@ -70,29 +70,29 @@ type DeviceListPage struct {
 }
 
 type DeviceListEntry struct {
-	ID         string   `json:"id"`
-	Name       string   `json:"name"`
-	Model      string   `json:"model"`
-	MacAddress string   `json:"macAddress"`
-	IPAddress  string   `json:"ipAddress"`
-	State      string   `json:"state"`
-	Features   []string `json:"features"`
-	Interfaces []string `json:"interfaces"`
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Model      string      `json:"model"`
+	MacAddress string      `json:"macAddress"`
+	IPAddress  string      `json:"ipAddress"`
+	State      DeviceState `json:"state"`
+	Features   []string    `json:"features"`
+	Interfaces []string    `json:"interfaces"`
 }
 
 type Device struct {
-	ID                string    `json:"id"`
-	Name              string    `json:"name"`
-	Model             string    `json:"model"`
-	Supported         bool      `json:"supported"`
-	MacAddress        string    `json:"macAddress"`
-	IPAddress         string    `json:"ipAddress"`
-	State             string    `json:"state"`
-	FirmwareVersion   string    `json:"firmwareVersion"`
-	FirmwareUpdatable bool      `json:"firmwareUpdatable"`
-	AdoptedAt         time.Time `json:"adoptedAt"`
-	ProvisionedAt     time.Time `json:"provisionedAt"`
-	ConfigurationID   string    `json:"configurationId"`
+	ID                string      `json:"id"`
+	Name              string      `json:"name"`
+	Model             string      `json:"model"`
+	Supported         bool        `json:"supported"`
+	MacAddress        string      `json:"macAddress"`
+	IPAddress         string      `json:"ipAddress"`
+	State             DeviceState `json:"state"`
+	FirmwareVersion   string      `json:"firmwareVersion"`
+	FirmwareUpdatable bool        `json:"firmwareUpdatable"`
+	AdoptedAt         time.Time   `json:"adoptedAt"`
+	ProvisionedAt     time.Time   `json:"provisionedAt"`
+	ConfigurationID   string      `json:"configurationId"`
 	Uplink            struct {
 		DeviceID string `json:"deviceId"`
 	} `json:"uplink"`
@@ -103,13 +103,7 @@ type Device struct {
 		} `json:"accessPoint"`
 	} `json:"features"`
 	Interfaces struct {
-		Ports []struct {
-			Idx          int    `json:"idx"`
-			State        string `json:"state"`
-			Connector    string `json:"connector"`
-			MaxSpeedMbps int    `json:"maxSpeedMbps"`
-			SpeedMbps    int    `json:"speedMbps"`
-		} `json:"ports"`
+		Ports  []*DevicePort `json:"ports"`
 		Radios []struct {
 			WlanStandard string `json:"wlanStandard"`
 			// TODO: This differed from UniFi API docs. It's not a string.
@@ -120,6 +114,34 @@ type Device struct {
 	} `json:"interfaces"`
 }
 
+type DevicePort struct {
+	Idx          int       `json:"idx"`
+	State        PortState `json:"state"`
+	Connector    string    `json:"connector"`
+	MaxSpeedMbps int       `json:"maxSpeedMbps"`
+	SpeedMbps    int       `json:"speedMbps"`
+	// PoE is only reported for ports which can supply power.
+	PoE *DevicePortPoE `json:"poe,omitempty"`
+}
+
+type DevicePortPoE struct {
+	Standard string    `json:"standard"`
+	Type     int       `json:"type"`
+	Enabled  bool      `json:"enabled"`
+	State    PortState `json:"state"`
+}
+
+// BelowMaxSpeed returns true if the port is up, but negotiated a slower
+// speed than it supports.
+func (dp *DevicePort) BelowMaxSpeed() bool {
+	return dp.State == PortStateUp && dp.SpeedMbps > 0 && dp.SpeedMbps < dp.MaxSpeedMbps
+}
+
+// IsPoE returns true if the port can supply power.
+func (dp *DevicePort) IsPoE() bool {
+	return dp.PoE != nil
+}
+
 type DeviceStatistics struct {
 	UptimeSec            int64     `json:"uptimeSec"`
 	LastHeartbeatAt      time.Time `json:"lastHeartbeatAt"`
@@ -158,28 +180,60 @@ type ClientListPage struct {
 }
 
 type Client struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	ConnectedAt time.Time `json:"connectedAt"`
-	IPAddress   string    `json:"ipAddress"`
-	Type        string    `json:"type"`
-	// TODO: Not sure what 'access' is yet based on API docs.
-	// Access      string    `json:"access"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	ConnectedAt time.Time  `json:"connectedAt"`
+	IPAddress   string     `json:"ipAddress"`
+	Type        ClientType `json:"type"`
+	// MacAddress and UplinkDeviceID are only reported for WIRED and
+	// WIRELESS clients. UplinkDeviceID is the switch or access point the
+	// client is connected to.
+	MacAddress     string       `json:"macAddress,omitempty"`
+	UplinkDeviceID string       `json:"uplinkDeviceId,omitempty"`
+	Access         ClientAccess `json:"access"`
 }
 
-// TODO: Add action verification or enum
+type ClientAccess struct {
+	Type ClientAccessType `json:"type"`
+	// Authorized is only reported for GUEST access, and is whether the guest
+	// has been authorized, e.g. with a voucher or AUTHORIZE_GUEST_ACCESS.
+	Authorized *bool `json:"authorized,omitempty"`
+}
+
+// IsGuest returns true if the client is on a guest network.
+func (c *Client) IsGuest() bool {
+	return c.Access.Type == ClientAccessTypeGuest
+}
+
+// IsAuthorizedGuest returns true if the client is an authorized guest.
+func (c *Client) IsAuthorizedGuest() bool {
+	return c.IsGuest() && c.Access.Authorized != nil && *c.Access.Authorized
+}
+
+// ClientActionRequest is built with NewAuthorizeGuestAccessRequest or
+// NewUnauthorizeGuestAccessRequest.
 type ClientActionRequest struct {
-	Action string `json:"action"`
+	Action ClientAction `json:"action"`
+	GuestAccessLimits
+}
+
+// GuestAccessLimits limit a guest authorized with AUTHORIZE_GUEST_ACCESS. A
+// zero limit is left unset, i.e. unlimited or the site's default.
+type GuestAccessLimits struct {
+	TimeLimitMinutes     int `json:"timeLimitMinutes,omitempty"`
+	DataUsageLimitMBytes int `json:"dataUsageLimitMBytes,omitempty"`
+	RxRateLimitKbps      int `json:"rxRateLimitKbps,omitempty"`
+	TxRateLimitKbps      int `json:"txRateLimitKbps,omitempty"`
 }
 
-// TODO: Add action verification or enum
+// DeviceActionRequest is built with NewDeviceRestartRequest.
 type DeviceActionRequest struct {
-	Action string `json:"action"`
+	Action DeviceAction `json:"action"`
 }
 
-// TODO: Add action verification or enum
+// DevicePortActionRequest is built with NewPortPowerCycleRequest.
 type DevicePortActionRequest struct {
-	Action string `json:"action"`
+	Action PortAction `json:"action"`
 }
 
 type VoucherListPage struct {
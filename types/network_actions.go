@@ -0,0 +1,66 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// NewDeviceRestartRequest returns a request which restarts a device.
+func NewDeviceRestartRequest() *DeviceActionRequest {
+	return &DeviceActionRequest{Action: DeviceActionRestart}
+}
+
+// NewPortPowerCycleRequest returns a request which power cycles the PoE
+// device connected to a port.
+func NewPortPowerCycleRequest() *DevicePortActionRequest {
+	return &DevicePortActionRequest{Action: PortActionPowerCycle}
+}
+
+// NewAuthorizeGuestAccessRequest returns a request which authorizes a client
+// on the guest network, within limits.
+func NewAuthorizeGuestAccessRequest(limits GuestAccessLimits) *ClientActionRequest {
+	return &ClientActionRequest{Action: ClientActionAuthorizeGuestAccess, GuestAccessLimits: limits}
+}
+
+// NewUnauthorizeGuestAccessRequest returns a request which revokes a client's
+// guest network authorization.
+func NewUnauthorizeGuestAccessRequest() *ClientActionRequest {
+	return &ClientActionRequest{Action: ClientActionUnauthorizeGuestAccess}
+}
+
+// Validate checks the action is known, and that limits are only given to
+// AUTHORIZE_GUEST_ACCESS.
+func (r *ClientActionRequest) Validate() error {
+	if !r.Action.Valid() {
+		return fmt.Errorf("unknown client action '%s', expected one of %v", r.Action, AllClientActions)
+	}
+	if r.Action != ClientActionAuthorizeGuestAccess && r.GuestAccessLimits != (GuestAccessLimits{}) {
+		return fmt.Errorf("client action '%s' doesn't take limits", r.Action)
+	}
+	return r.GuestAccessLimits.Validate()
+}
+
+// Validate checks no limit is negative.
+func (gal GuestAccessLimits) Validate() error {
+	if gal.TimeLimitMinutes < 0 || gal.DataUsageLimitMBytes < 0 ||
+		gal.RxRateLimitKbps < 0 || gal.TxRateLimitKbps < 0 {
+		return errors.New("guest access limits must not be negative")
+	}
+	return nil
+}
+
+// Validate checks the action is known.
+func (r *DeviceActionRequest) Validate() error {
+	if !r.Action.Valid() {
+		return fmt.Errorf("unknown device action '%s', expected one of %v", r.Action, AllDeviceActions)
+	}
+	return nil
+}
+
+// Validate checks the action is known.
+func (r *DevicePortActionRequest) Validate() error {
+	if !r.Action.Valid() {
+		return fmt.Errorf("unknown port action '%s', expected one of %v", r.Action, AllPortActions)
+	}
+	return nil
+}
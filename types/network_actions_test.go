@@ -0,0 +1,24 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+func TestClientActionRequestValidate(t *testing.T) {
+	assert.NoError(t, types.NewAuthorizeGuestAccessRequest(types.GuestAccessLimits{TimeLimitMinutes: 30}).Validate())
+	assert.NoError(t, types.NewUnauthorizeGuestAccessRequest().Validate())
+
+	assert.Error(t, types.NewAuthorizeGuestAccessRequest(types.GuestAccessLimits{TxRateLimitKbps: -1}).Validate())
+	assert.Error(t, (&types.ClientActionRequest{
+		Action:            types.ClientActionUnauthorizeGuestAccess,
+		GuestAccessLimits: types.GuestAccessLimits{TimeLimitMinutes: 30},
+	}).Validate())
+	assert.Error(t, (&types.ClientActionRequest{Action: "BLOCK"}).Validate())
+
+	assert.NoError(t, types.NewDeviceRestartRequest().Validate())
+	assert.Error(t, (&types.DevicePortActionRequest{Action: "RESET"}).Validate())
+}
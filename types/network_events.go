@@ -0,0 +1,140 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NetworkEvent is a change to a Network site. The Network integration API has
+// no event subscriptions, so these are synthesized by client.SubscribeNetworkEvents
+// from the differences between successive polls.
+type NetworkEvent struct {
+	// Type is always "add": every event is a new occurrence.
+	Type string `json:"type"`
+	// Polymorphic object that maps to an Event
+	Item     interface{}     `json:"-"`
+	ItemType string          `json:"-"`
+	RawItem  json.RawMessage `json:"item"`
+}
+
+// NewNetworkEvent wraps a typed event item, e.g. *DeviceAdoptedEvent, in a
+// NetworkEvent.
+func NewNetworkEvent(item interface{ EventItem() *NetworkEventItem }) (*NetworkEvent, error) {
+	rawItem, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkEvent{
+		Type:     "add",
+		Item:     item,
+		ItemType: item.EventItem().Type,
+		RawItem:  rawItem,
+	}, nil
+}
+
+func (ne *NetworkEvent) UnmarshalJSON(data []byte) error {
+	type event NetworkEvent
+
+	err := json.Unmarshal(data, (*event)(ne))
+	if err != nil {
+		return err
+	}
+
+	var item NetworkEventItem
+	err = json.Unmarshal(ne.RawItem, &item)
+	if err != nil {
+		return err
+	}
+
+	ne.Item = newNetworkEventItem(item.Type)
+	if ne.Item == nil {
+		return fmt.Errorf("NetworkEvent unrecognized type '%s'", item.Type)
+	}
+
+	err = json.Unmarshal(ne.RawItem, ne.Item)
+	if err != nil {
+		return err
+	}
+
+	ne.ItemType = item.Type
+
+	return nil
+}
+
+// EventItem returns the fields common to every Network event item, or nil if
+// the event has not been decoded.
+func (ne *NetworkEvent) EventItem() *NetworkEventItem {
+	item, ok := ne.Item.(interface{ EventItem() *NetworkEventItem })
+	if !ok {
+		return nil
+	}
+	return item.EventItem()
+}
+
+type NetworkEventItem struct {
+	// ID is the ID of the device, client or voucher the event is about.
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	SiteID SiteID `json:"siteId"`
+	// Timestamp is the time of the poll which saw the change.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventItem returns nei. Every typed Network event embeds NetworkEventItem,
+// so this gives uniform access to their common fields.
+func (nei *NetworkEventItem) EventItem() *NetworkEventItem {
+	return nei
+}
+
+// Keys of the Network event types.
+const (
+	DeviceStateChangedEventType = "deviceStateChanged"
+	DeviceAdoptedEventType      = "deviceAdopted"
+	ClientConnectedEventType    = "clientConnected"
+	ClientDisconnectedEventType = "clientDisconnected"
+	VoucherActivatedEventType   = "voucherActivated"
+	VoucherExpiredEventType     = "voucherExpired"
+)
+
+type DeviceStateChangedEvent struct {
+	NetworkEventItem
+	Name          string      `json:"name"`
+	Model         string      `json:"model"`
+	PreviousState DeviceState `json:"previousState"`
+	State         DeviceState `json:"state"`
+	// Restarted is true if the device's uptime went backwards between polls,
+	// so it restarted even if its state looks unchanged. Only detected when
+	// device statistics are polled.
+	Restarted bool `json:"restarted,omitempty"`
+}
+
+type DeviceAdoptedEvent struct {
+	NetworkEventItem
+	Name       string      `json:"name"`
+	Model      string      `json:"model"`
+	MacAddress string      `json:"macAddress"`
+	IPAddress  string      `json:"ipAddress"`
+	State      DeviceState `json:"state"`
+}
+
+type ClientConnectedEvent struct {
+	NetworkEventItem
+	Client *Client `json:"client"`
+}
+
+type ClientDisconnectedEvent struct {
+	NetworkEventItem
+	// Client is the client as it was last seen.
+	Client *Client `json:"client"`
+}
+
+type VoucherActivatedEvent struct {
+	NetworkEventItem
+	Voucher *Voucher `json:"voucher"`
+}
+
+type VoucherExpiredEvent struct {
+	NetworkEventItem
+	Voucher *Voucher `json:"voucher"`
+}
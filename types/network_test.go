@@ -0,0 +1,28 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+func TestClientDecodesAccess(t *testing.T) {
+	var clients []*types.Client
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"type": "WIRED", "macAddress": "b8:27:eb:00:00:01", "uplinkDeviceId": "sw1", "access": {"type": "DEFAULT"}},
+		{"type": "WIRELESS", "access": {"type": "GUEST", "authorized": true}},
+		{"type": "WIRELESS", "access": {"type": "GUEST", "authorized": false}},
+		{"type": "VPN", "access": {"type": "DEFAULT"}}
+	]`), &clients))
+
+	assert.Equal(t, "sw1", clients[0].UplinkDeviceID)
+	assert.False(t, clients[0].IsGuest())
+	assert.True(t, clients[1].IsAuthorizedGuest())
+	assert.True(t, clients[2].IsGuest())
+	assert.False(t, clients[2].IsAuthorizedGuest())
+	assert.Empty(t, clients[3].MacAddress)
+}
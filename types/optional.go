@@ -0,0 +1,97 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Optional is a value which may be unset. Patch requests use it to tell a
+// field that should be left alone, which is omitted from the request, apart
+// from one which should be set to its zero value, like `false` or `0`.
+//
+// Fields of type Optional must be tagged `omitzero` for unset values to be
+// omitted when marshalled.
+type Optional[T any] struct {
+	value T
+	set   bool
+}
+
+// Some returns an Optional set to value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, set: true}
+}
+
+// None returns an unset Optional. It is the same as the zero value.
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// Get returns the value, and whether it is set.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set
+}
+
+// ValueOr returns the value if set, and fallback otherwise.
+func (o Optional[T]) ValueOr(fallback T) T {
+	if !o.set {
+		return fallback
+	}
+	return o.value
+}
+
+// IsSet returns true if a value is set.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsZero returns true if no value is set, so that `omitzero` omits unset
+// fields. A value which is set, even to its zero value, is not zero.
+func (o Optional[T]) IsZero() bool {
+	return !o.set
+}
+
+// Set sets the value.
+func (o *Optional[T]) Set(value T) {
+	o.value = value
+	o.set = true
+}
+
+// Unset clears the value.
+func (o *Optional[T]) Unset() {
+	var zero T
+	o.value = zero
+	o.set = false
+}
+
+// String formats the value, or "<unset>".
+func (o Optional[T]) String() string {
+	if !o.set {
+		return "<unset>"
+	}
+	return fmt.Sprint(o.value)
+}
+
+// MarshalJSON marshals the value, or null if unset.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON sets the value. A JSON null leaves it unset.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Unset()
+		return nil
+	}
+
+	var value T
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+	o.Set(value)
+	return nil
+}
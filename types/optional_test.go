@@ -0,0 +1,59 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+func TestPatchRequestSendsZeroValuesOnlyWhenSet(t *testing.T) {
+	req := (&types.CameraPatchRequest{}).
+		WithLedEnabled(false).
+		WithOsdNameEnabled(false).
+		WithMicVolume(0)
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"ledSettings": {"isEnabled": false},
+		"osdSettings": {"isNameEnabled": false},
+		"micVolume": 0
+	}`, string(data))
+
+	data, err = json.Marshal(&types.CameraPatchRequest{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(data))
+}
+
+func TestPatchRequestUnmarshalJSONRoundTrip(t *testing.T) {
+	original := `{
+		"lightDeviceSettings": {"ledLevel": 0, "isIndicatorEnabled": false},
+		"lightModeSettings": {"mode": "off"}
+	}`
+
+	var req types.LightPatchRequest
+	require.NoError(t, json.Unmarshal([]byte(original), &req))
+
+	level, ok := req.LightDeviceSettings.LedLevel.Get()
+	assert.True(t, ok)
+	assert.Equal(t, 0, level)
+	assert.False(t, req.LightDeviceSettings.PirSensitivity.IsSet())
+	assert.False(t, req.Name.IsSet())
+
+	data, err := json.Marshal(&req)
+	require.NoError(t, err)
+	assert.JSONEq(t, original, string(data))
+}
+
+func TestOptionalNullIsUnset(t *testing.T) {
+	var req types.SensorPatchRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"name": null, "motionSettings": {"sensitivity": 0}}`), &req))
+
+	assert.False(t, req.Name.IsSet())
+	assert.Equal(t, "fallback", req.Name.ValueOr("fallback"))
+	assert.Equal(t, 0, req.MotionSettings.Sensitivity.ValueOr(50))
+}
@@ -91,11 +91,12 @@ type ProtectInfo struct {
 }
 
 type Camera struct {
-	ID           string `json:"id"`
-	ModelKey     string `json:"modelKey"`
-	State        string `json:"state"`
-	Name         string `json:"name"`
-	IsMicEnabled bool   `json:"isMicEnabled"`
+	ID           string             `json:"id"`
+	ModelKey     ModelKey           `json:"modelKey"`
+	State        ProtectDeviceState `json:"state"`
+	Name         string             `json:"name"`
+	Mac          string             `json:"mac"`
+	IsMicEnabled bool               `json:"isMicEnabled"`
 	OsdSettings  struct {
 		IsNameEnabled  bool `json:"isNameEnabled"`
 		IsDateEnabled  bool `json:"isDateEnabled"`
@@ -106,23 +107,23 @@ type Camera struct {
 		IsEnabled bool `json:"isEnabled"`
 	} `json:"ledSettings"`
 	LcdMessage struct {
-		Type    string `json:"type"`
-		ResetAt int    `json:"resetAt"`
-		Text    string `json:"text"`
+		Type    string     `json:"type"`
+		ResetAt UnixMillis `json:"resetAt"`
+		Text    string     `json:"text"`
 	} `json:"lcdMessage"`
-	MicVolume        int    `json:"micVolume"`
-	ActivePatrolSlot int    `json:"activePatrolSlot"`
-	VideoMode        string `json:"videoMode"`
-	HdrType          string `json:"hdrType"`
+	MicVolume        int       `json:"micVolume"`
+	ActivePatrolSlot int       `json:"activePatrolSlot"`
+	VideoMode        VideoMode `json:"videoMode"`
+	HdrType          HdrType   `json:"hdrType"`
 	FeatureFlags     struct {
-		SupportFullHdSnapshot bool     `json:"supportFullHdSnapshot"`
-		HasHdr                bool     `json:"hasHdr"`
-		SmartDetectTypes      []string `json:"smartDetectTypes"`
-		SmartDetectAudioTypes []string `json:"smartDetectAudioTypes"`
-		VideoModes            []string `json:"videoModes"`
-		HasMic                bool     `json:"hasMic"`
-		HasLedStatus          bool     `json:"hasLedStatus"`
-		HasSpeaker            bool     `json:"hasSpeaker"`
+		SupportFullHdSnapshot bool        `json:"supportFullHdSnapshot"`
+		HasHdr                bool        `json:"hasHdr"`
+		SmartDetectTypes      []string    `json:"smartDetectTypes"`
+		SmartDetectAudioTypes []string    `json:"smartDetectAudioTypes"`
+		VideoModes            []VideoMode `json:"videoModes"`
+		HasMic                bool        `json:"hasMic"`
+		HasLedStatus          bool        `json:"hasLedStatus"`
+		HasSpeaker            bool        `json:"hasSpeaker"`
 	} `json:"featureFlags"`
 	SmartDetectSettings struct {
 		ObjectTypes []string `json:"objectTypes"`
@@ -130,40 +131,49 @@ type Camera struct {
 	} `json:"smartDetectSettings"`
 }
 
-type lcdMessage struct {
-	Type    string `json:"type,omitempty"`
-	ResetAt int    `json:"resetAt,omitempty"`
-	Text    string `json:"text,omitempty"`
+// Fields of a patch request are only sent when set, e.g. with Some(false).
+// See the builder methods in protect_patch.go.
+type CameraPatchRequest struct {
+	Name                Optional[string]               `json:"name,omitzero" flag:"name" help:"Name of the camera"`
+	OsdSettings         CameraOsdSettingsPatch         `json:"osdSettings,omitzero"`
+	LedSettings         CameraLedSettingsPatch         `json:"ledSettings,omitzero"`
+	LcdMessage          CameraLcdMessagePatch          `json:"lcdMessage,omitzero"`
+	MicVolume           Optional[int]                  `json:"micVolume,omitzero" flag:"mic-volume" help:"Microphone volume, from 0 to 100"`
+	VideoMode           Optional[VideoMode]            `json:"videoMode,omitzero" flag:"video-mode" help:"Video mode, one of the camera's featureFlags.videoModes"`
+	HdrType             Optional[HdrType]              `json:"hdrType,omitzero" flag:"hdr-type" help:"HDR mode, e.g. auto, on or off"`
+	SmartDetectSettings CameraSmartDetectSettingsPatch `json:"smartDetectSettings,omitzero"`
 }
 
-type CameraPatchRequest struct {
-	Name        string `json:"name,omitempty"`
-	OsdSettings struct {
-		IsNameEnabled  bool `json:"isNameEnabled,omitempty"`
-		IsDateEnabled  bool `json:"isDateEnabled,omitempty"`
-		IsLogoEnabled  bool `json:"isLogoEnabled,omitempty"`
-		IsDebugEnabled bool `json:"isDebugEnabled,omitempty"`
-	} `json:"osdSettings,omitzero"`
-	LedSettings struct {
-		IsEnabled bool `json:"isEnabled,omitempty"`
-	} `json:"ledSettings,omitzero"`
-	LcdMessage          lcdMessage `json:"lcdMessage,omitzero"`
-	MicVolume           int        `json:"micVolume,omitzero"`
-	VideoMode           string     `json:"videoMode,omitempty"`
-	HdrType             string     `json:"hdrType,omitempty"`
-	SmartDetectSettings struct {
-		ObjectTypes []string `json:"objectTypes,omitempty"`
-		AudioTypes  []string `json:"audioTypes,omitempty"`
-	} `json:"smartDetectSettings,omitzero"`
+type CameraOsdSettingsPatch struct {
+	IsNameEnabled  Optional[bool] `json:"isNameEnabled,omitzero" flag:"osd-name" help:"Show the camera name on its video"`
+	IsDateEnabled  Optional[bool] `json:"isDateEnabled,omitzero" flag:"osd-date" help:"Show the date on the camera's video"`
+	IsLogoEnabled  Optional[bool] `json:"isLogoEnabled,omitzero" flag:"osd-logo" help:"Show the logo on the camera's video"`
+	IsDebugEnabled Optional[bool] `json:"isDebugEnabled,omitzero" flag:"osd-debug" help:"Show debug information on the camera's video"`
+}
+
+type CameraLedSettingsPatch struct {
+	IsEnabled Optional[bool] `json:"isEnabled,omitzero" flag:"led" help:"Turn the camera's status LED on or off"`
+}
+
+type CameraLcdMessagePatch struct {
+	Type    Optional[string]     `json:"type,omitzero" flag:"lcd-message-type" help:"Doorbell message type, e.g. CUSTOM_MESSAGE or LEAVE_PACKAGE_AT_DOOR"`
+	ResetAt Optional[UnixMillis] `json:"resetAt,omitzero" flag:"lcd-message-reset-at" help:"When the doorbell message resets, in ms since the Unix epoch"`
+	Text    Optional[string]     `json:"text,omitzero" flag:"lcd-message" help:"Doorbell message text"`
+}
+
+type CameraSmartDetectSettingsPatch struct {
+	ObjectTypes Optional[[]string] `json:"objectTypes,omitzero" flag:"smart-detect-objects" help:"Objects to detect, e.g. person,vehicle. Empty turns detection off"`
+	AudioTypes  Optional[[]string] `json:"audioTypes,omitzero" flag:"smart-detect-audio" help:"Sounds to detect, e.g. alrmSmoke. Empty turns detection off"`
 }
 
 type Viewer struct {
-	ID          string `json:"id"`
-	ModelKey    string `json:"modelKey"`
-	State       string `json:"state"`
-	Name        string `json:"name"`
-	Liveview    string `json:"liveview"`
-	StreamLimit int    `json:"streamLimit"`
+	ID          string             `json:"id"`
+	ModelKey    ModelKey           `json:"modelKey"`
+	State       ProtectDeviceState `json:"state"`
+	Name        string             `json:"name"`
+	Mac         string             `json:"mac"`
+	Liveview    string             `json:"liveview"`
+	StreamLimit int                `json:"streamLimit"`
 }
 
 type ViewerSettingsRequest struct {
@@ -229,13 +239,14 @@ type CameraTalkbackSessionResponse struct {
 }
 
 type Light struct {
-	ID                string `json:"id"`
-	ModelKey          string `json:"modelKey"`
-	State             string `json:"state"`
-	Name              string `json:"name"`
+	ID                string             `json:"id"`
+	ModelKey          ModelKey           `json:"modelKey"`
+	State             ProtectDeviceState `json:"state"`
+	Name              string             `json:"name"`
+	Mac               string             `json:"mac"`
 	LightModeSettings struct {
-		Mode     string `json:"mode"`
-		EnableAt string `json:"enableAt"`
+		Mode     LightMode     `json:"mode"`
+		EnableAt LightEnableAt `json:"enableAt"`
 	} `json:"lightModeSettings"`
 	LightDeviceSettings struct {
 		IsIndicatorEnabled bool `json:"isIndicatorEnabled"`
@@ -243,33 +254,37 @@ type Light struct {
 		PirSensitivity     int  `json:"pirSensitivity"`
 		LedLevel           int  `json:"ledLevel"`
 	} `json:"lightDeviceSettings"`
-	IsDark              bool   `json:"isDark"`
-	IsLightOn           bool   `json:"isLightOn"`
-	IsLightForceEnabled bool   `json:"isLightForceEnabled"`
-	LastMotion          int    `json:"lastMotion"`
-	IsPirMotionDetected bool   `json:"isPirMotionDetected"`
-	Camera              string `json:"camera"`
+	IsDark              bool       `json:"isDark"`
+	IsLightOn           bool       `json:"isLightOn"`
+	IsLightForceEnabled bool       `json:"isLightForceEnabled"`
+	LastMotion          UnixMillis `json:"lastMotion"`
+	IsPirMotionDetected bool       `json:"isPirMotionDetected"`
+	Camera              string     `json:"camera"`
 }
 
 type LightPatchRequest struct {
-	Name                string `json:"name,omitempty"`
-	IsLightForceEnabled bool   `json:"isLightForceEnabled,omitempty"`
-	LightModeSettings   struct {
-		Mode     string `json:"mode,omitempty"`
-		EnableAt string `json:"enableAt,omitempty"`
-	} `json:"lightModeSettings,omitzero"`
-	LightDeviceSettings struct {
-		IsIndicatorEnabled bool `json:"isIndicatorEnabled,omitempty"`
-		PirDuration        int  `json:"pirDuration,omitempty"`
-		PirSensitivity     int  `json:"pirSensitivity,omitempty"`
-		LedLevel           int  `json:"ledLevel,omitempty"`
-	} `json:"lightDeviceSettings,omitzero"`
+	Name                Optional[string]         `json:"name,omitzero" flag:"name" help:"Name of the light"`
+	IsLightForceEnabled Optional[bool]           `json:"isLightForceEnabled,omitzero" flag:"force-on" help:"Force the light on"`
+	LightModeSettings   LightModeSettingsPatch   `json:"lightModeSettings,omitzero"`
+	LightDeviceSettings LightDeviceSettingsPatch `json:"lightDeviceSettings,omitzero"`
+}
+
+type LightModeSettingsPatch struct {
+	Mode     Optional[LightMode]     `json:"mode,omitzero" flag:"light-mode" help:"Light mode, one of always, motion or off"`
+	EnableAt Optional[LightEnableAt] `json:"enableAt,omitzero" flag:"enable-at" help:"When the light mode applies, one of fulltime or dark"`
+}
+
+type LightDeviceSettingsPatch struct {
+	IsIndicatorEnabled Optional[bool] `json:"isIndicatorEnabled,omitzero" flag:"indicator" help:"Turn the status indicator on or off"`
+	PirDuration        Optional[int]  `json:"pirDuration,omitzero" flag:"pir-duration" help:"How long the light stays on after motion, in ms"`
+	PirSensitivity     Optional[int]  `json:"pirSensitivity,omitzero" flag:"pir-sensitivity" help:"Motion sensitivity, from 0 to 100"`
+	LedLevel           Optional[int]  `json:"ledLevel,omitzero" flag:"led-level" help:"Brightness, from 1 to 6"`
 }
 
 type NVR struct {
-	ID               string `json:"id"`
-	ModelKey         string `json:"modelKey"`
-	Name             string `json:"name"`
+	ID               string   `json:"id"`
+	ModelKey         ModelKey `json:"modelKey"`
+	Name             string   `json:"name"`
 	DoorbellSettings struct {
 		DefaultMessageText           string   `json:"defaultMessageText"`
 		DefaultMessageResetTimeoutMs int      `json:"defaultMessageResetTimeoutMs"`
@@ -282,11 +297,12 @@ type NVR struct {
 }
 
 type Chime struct {
-	ID           string   `json:"id"`
-	ModelKey     string   `json:"modelKey"`
-	State        string   `json:"state"`
-	Name         string   `json:"name"`
-	CameraIDs    []string `json:"cameraIds"`
+	ID           string             `json:"id"`
+	ModelKey     ModelKey           `json:"modelKey"`
+	State        ProtectDeviceState `json:"state"`
+	Name         string             `json:"name"`
+	Mac          string             `json:"mac"`
+	CameraIDs    []string           `json:"cameraIds"`
 	RingSettings []struct {
 		CameraID    string `json:"cameraId"`
 		RepeatTimes int    `json:"repeatTimes"`
@@ -296,14 +312,16 @@ type Chime struct {
 }
 
 type ChimePatchRequest struct {
-	Name         string   `json:"name,omitempty"`
-	CameraIDs    []string `json:"cameraIds,omitempty"`
-	RingSettings []struct {
-		CameraID    string `json:"cameraId,omitempty"`
-		RepeatTimes int    `json:"repeatTimes,omitempty"`
-		RingtoneID  string `json:"ringtoneId,omitempty"`
-		Volume      int    `json:"volume,omitempty"`
-	} `json:"ringSettings,omitzero"`
+	Name         Optional[string]        `json:"name,omitzero" flag:"name" help:"Name of the chime"`
+	CameraIDs    Optional[[]string]      `json:"cameraIds,omitzero" flag:"camera-ids" help:"Doorbells the chime rings for. Empty unpairs every doorbell"`
+	RingSettings []ChimeRingSettingPatch `json:"ringSettings,omitempty"`
+}
+
+type ChimeRingSettingPatch struct {
+	CameraID    Optional[string] `json:"cameraId,omitzero"`
+	RepeatTimes Optional[int]    `json:"repeatTimes,omitzero"`
+	RingtoneID  Optional[string] `json:"ringtoneId,omitzero"`
+	Volume      Optional[int]    `json:"volume,omitzero"`
 }
 
 type SensorSettings struct {
@@ -314,11 +332,11 @@ type SensorSettings struct {
 }
 
 type Sensor struct {
-	ID            string `json:"id"`
-	ModelKey      string `json:"modelKey"`
-	State         string `json:"state"`
-	Name          string `json:"name"`
-	MountType     string `json:"mountType"`
+	ID            string             `json:"id"`
+	ModelKey      ModelKey           `json:"modelKey"`
+	State         ProtectDeviceState `json:"state"`
+	Name          string             `json:"name"`
+	MountType     string             `json:"mountType"`
 	BatteryStatus struct {
 		Percentage int  `json:"percentage"`
 		IsLow      bool `json:"isLow"`
@@ -341,33 +359,44 @@ type Sensor struct {
 	HumiditySettings    SensorSettings `json:"humiditySettings"`
 	TemperatureSettings SensorSettings `json:"temperatureSettings"`
 	IsOpened            bool           `json:"isOpened"`
-	OpenStatusChangedAt int            `json:"openStatusChangedAt"`
+	OpenStatusChangedAt UnixMillis     `json:"openStatusChangedAt"`
 	IsMotionDetected    bool           `json:"isMotionDetected"`
-	MotionDetectedAt    int            `json:"motionDetectedAt"`
+	MotionDetectedAt    UnixMillis     `json:"motionDetectedAt"`
 	MotionSettings      struct {
 		IsEnabled   bool `json:"isEnabled"`
 		Sensitivity int  `json:"sensitivity"`
 	} `json:"motionSettings"`
-	AlarmTriggeredAt int `json:"alarmTriggeredAt"`
+	AlarmTriggeredAt UnixMillis `json:"alarmTriggeredAt"`
 	AlarmSettings    struct {
 		IsEnabled bool `json:"isEnabled"`
 	} `json:"alarmSettings"`
-	LeakDetectedAt      int `json:"leakDetectedAt"`
-	TamperingDetectedAt int `json:"tamperingDetectedAt"`
+	LeakDetectedAt      UnixMillis `json:"leakDetectedAt"`
+	TamperingDetectedAt UnixMillis `json:"tamperingDetectedAt"`
 }
 
 type SensorPatchRequest struct {
-	Name                string         `json:"name,omitempty"`
-	LightSettings       SensorSettings `json:"lightSettings,omitzero"`
-	HumiditySettings    SensorSettings `json:"humiditySettings,omitzero"`
-	TemperatureSettings SensorSettings `json:"temperatureSettings,omitzero"`
-	MotionSettings      struct {
-		IsEnabled   bool `json:"isEnabled,omitempty"`
-		Sensitivity int  `json:"sensitivity,omitempty"`
-	} `json:"motionSettings,omitzero"`
-	AlarmSettings struct {
-		IsEnabled bool `json:"isEnabled,omitempty"`
-	} `json:"alarmSettings,omitzero"`
+	Name                Optional[string]          `json:"name,omitzero" flag:"name" help:"Name of the sensor"`
+	LightSettings       SensorSettingsPatch       `json:"lightSettings,omitzero" flag:"light" help:"Light level"`
+	HumiditySettings    SensorSettingsPatch       `json:"humiditySettings,omitzero" flag:"humidity" help:"Humidity"`
+	TemperatureSettings SensorSettingsPatch       `json:"temperatureSettings,omitzero" flag:"temperature" help:"Temperature"`
+	MotionSettings      SensorMotionSettingsPatch `json:"motionSettings,omitzero"`
+	AlarmSettings       SensorAlarmSettingsPatch  `json:"alarmSettings,omitzero"`
+}
+
+type SensorSettingsPatch struct {
+	IsEnabled     Optional[bool]    `json:"isEnabled,omitzero" flag:"enabled" help:"turn alerts on or off"`
+	Margin        Optional[float64] `json:"margin,omitzero" flag:"margin" help:"margin around the thresholds before alerting"`
+	LowThreshold  Optional[int]     `json:"lowThreshold,omitzero" flag:"low" help:"alert below this value"`
+	HighThreshold Optional[int]     `json:"highThreshold,omitzero" flag:"high" help:"alert above this value"`
+}
+
+type SensorMotionSettingsPatch struct {
+	IsEnabled   Optional[bool] `json:"isEnabled,omitzero" flag:"motion" help:"Turn motion detection on or off"`
+	Sensitivity Optional[int]  `json:"sensitivity,omitzero" flag:"motion-sensitivity" help:"Motion sensitivity, from 0 to 100"`
+}
+
+type SensorAlarmSettingsPatch struct {
+	IsEnabled Optional[bool] `json:"isEnabled,omitzero" flag:"alarm" help:"Turn smoke and CO alarm detection on or off"`
 }
 
 type FileType int
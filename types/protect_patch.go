@@ -0,0 +1,180 @@
+package types
+
+import (
+	"time"
+)
+
+// Builder methods for Protect patch requests. Each sets a single field and
+// returns the request, so a patch can be built up in one expression:
+//
+//	req := (&types.CameraPatchRequest{}).WithLedEnabled(false).WithMicVolume(0)
+
+func (r *CameraPatchRequest) WithName(name string) *CameraPatchRequest {
+	r.Name.Set(name)
+	return r
+}
+
+func (r *CameraPatchRequest) WithOsdNameEnabled(enabled bool) *CameraPatchRequest {
+	r.OsdSettings.IsNameEnabled.Set(enabled)
+	return r
+}
+
+func (r *CameraPatchRequest) WithOsdDateEnabled(enabled bool) *CameraPatchRequest {
+	r.OsdSettings.IsDateEnabled.Set(enabled)
+	return r
+}
+
+func (r *CameraPatchRequest) WithOsdLogoEnabled(enabled bool) *CameraPatchRequest {
+	r.OsdSettings.IsLogoEnabled.Set(enabled)
+	return r
+}
+
+func (r *CameraPatchRequest) WithOsdDebugEnabled(enabled bool) *CameraPatchRequest {
+	r.OsdSettings.IsDebugEnabled.Set(enabled)
+	return r
+}
+
+func (r *CameraPatchRequest) WithLedEnabled(enabled bool) *CameraPatchRequest {
+	r.LedSettings.IsEnabled.Set(enabled)
+	return r
+}
+
+// WithLcdMessage sets the message shown on a doorbell's screen. A zero
+// resetAt leaves the message up until it is replaced.
+func (r *CameraPatchRequest) WithLcdMessage(messageType string, text string, resetAt time.Time) *CameraPatchRequest {
+	r.LcdMessage.Type.Set(messageType)
+	r.LcdMessage.Text.Set(text)
+	if !resetAt.IsZero() {
+		r.LcdMessage.ResetAt.Set(NewUnixMillis(resetAt))
+	}
+	return r
+}
+
+func (r *CameraPatchRequest) WithMicVolume(volume int) *CameraPatchRequest {
+	r.MicVolume.Set(volume)
+	return r
+}
+
+func (r *CameraPatchRequest) WithVideoMode(mode VideoMode) *CameraPatchRequest {
+	r.VideoMode.Set(mode)
+	return r
+}
+
+func (r *CameraPatchRequest) WithHdrType(hdrType HdrType) *CameraPatchRequest {
+	r.HdrType.Set(hdrType)
+	return r
+}
+
+// WithSmartDetectObjectTypes sets the objects the camera detects. An empty
+// list turns object detection off.
+func (r *CameraPatchRequest) WithSmartDetectObjectTypes(objectTypes ...string) *CameraPatchRequest {
+	r.SmartDetectSettings.ObjectTypes.Set(append([]string{}, objectTypes...))
+	return r
+}
+
+// WithSmartDetectAudioTypes sets the sounds the camera detects. An empty list
+// turns audio detection off.
+func (r *CameraPatchRequest) WithSmartDetectAudioTypes(audioTypes ...string) *CameraPatchRequest {
+	r.SmartDetectSettings.AudioTypes.Set(append([]string{}, audioTypes...))
+	return r
+}
+
+func (r *LightPatchRequest) WithName(name string) *LightPatchRequest {
+	r.Name.Set(name)
+	return r
+}
+
+func (r *LightPatchRequest) WithLightForceEnabled(enabled bool) *LightPatchRequest {
+	r.IsLightForceEnabled.Set(enabled)
+	return r
+}
+
+func (r *LightPatchRequest) WithLightMode(mode LightMode) *LightPatchRequest {
+	r.LightModeSettings.Mode.Set(mode)
+	return r
+}
+
+func (r *LightPatchRequest) WithEnableAt(enableAt LightEnableAt) *LightPatchRequest {
+	r.LightModeSettings.EnableAt.Set(enableAt)
+	return r
+}
+
+func (r *LightPatchRequest) WithIndicatorEnabled(enabled bool) *LightPatchRequest {
+	r.LightDeviceSettings.IsIndicatorEnabled.Set(enabled)
+	return r
+}
+
+func (r *LightPatchRequest) WithPirDuration(duration int) *LightPatchRequest {
+	r.LightDeviceSettings.PirDuration.Set(duration)
+	return r
+}
+
+func (r *LightPatchRequest) WithPirSensitivity(sensitivity int) *LightPatchRequest {
+	r.LightDeviceSettings.PirSensitivity.Set(sensitivity)
+	return r
+}
+
+func (r *LightPatchRequest) WithLedLevel(level int) *LightPatchRequest {
+	r.LightDeviceSettings.LedLevel.Set(level)
+	return r
+}
+
+func (r *ChimePatchRequest) WithName(name string) *ChimePatchRequest {
+	r.Name.Set(name)
+	return r
+}
+
+// WithCameraIDs sets the doorbells the chime rings for. An empty list
+// unpairs every doorbell.
+func (r *ChimePatchRequest) WithCameraIDs(cameraIDs ...string) *ChimePatchRequest {
+	r.CameraIDs.Set(append([]string{}, cameraIDs...))
+	return r
+}
+
+// WithRingSetting adds how the chime rings for a doorbell. Ring settings
+// replace the chime's existing ones as a whole.
+func (r *ChimePatchRequest) WithRingSetting(cameraID string, ringtoneID string,
+	repeatTimes int, volume int) *ChimePatchRequest {
+	r.RingSettings = append(r.RingSettings, ChimeRingSettingPatch{
+		CameraID:    Some(cameraID),
+		RingtoneID:  Some(ringtoneID),
+		RepeatTimes: Some(repeatTimes),
+		Volume:      Some(volume),
+	})
+	return r
+}
+
+func (r *SensorPatchRequest) WithName(name string) *SensorPatchRequest {
+	r.Name.Set(name)
+	return r
+}
+
+func (r *SensorPatchRequest) WithLightSettings(settings SensorSettingsPatch) *SensorPatchRequest {
+	r.LightSettings = settings
+	return r
+}
+
+func (r *SensorPatchRequest) WithHumiditySettings(settings SensorSettingsPatch) *SensorPatchRequest {
+	r.HumiditySettings = settings
+	return r
+}
+
+func (r *SensorPatchRequest) WithTemperatureSettings(settings SensorSettingsPatch) *SensorPatchRequest {
+	r.TemperatureSettings = settings
+	return r
+}
+
+func (r *SensorPatchRequest) WithMotionEnabled(enabled bool) *SensorPatchRequest {
+	r.MotionSettings.IsEnabled.Set(enabled)
+	return r
+}
+
+func (r *SensorPatchRequest) WithMotionSensitivity(sensitivity int) *SensorPatchRequest {
+	r.MotionSettings.Sensitivity.Set(sensitivity)
+	return r
+}
+
+func (r *SensorPatchRequest) WithAlarmEnabled(enabled bool) *SensorPatchRequest {
+	r.AlarmSettings.IsEnabled.Set(enabled)
+	return r
+}
@@ -0,0 +1,124 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PatchViolation is a single reason a patch request can't be applied to a
+// device.
+type PatchViolation struct {
+	// Field is the JSON path of the offending field, e.g. "videoMode".
+	Field  string `json:"field"`
+	Value  any    `json:"value"`
+	Reason string `json:"reason"`
+	// Supported lists the values the device accepts, if there is such a
+	// list.
+	Supported []string `json:"supported,omitempty"`
+}
+
+func (pv PatchViolation) String() string {
+	reason := fmt.Sprintf("%s: %v %s", pv.Field, pv.Value, pv.Reason)
+	if len(pv.Supported) > 0 {
+		reason += " (supported: " + strings.Join(pv.Supported, ", ") + ")"
+	}
+	return reason
+}
+
+// PatchValidationError is returned when a patch request asks for something
+// the device doesn't support.
+type PatchValidationError struct {
+	DeviceID   string           `json:"deviceId"`
+	Violations []PatchViolation `json:"violations"`
+}
+
+func (pve *PatchValidationError) Error() string {
+	reasons := make([]string, 0, len(pve.Violations))
+	for _, violation := range pve.Violations {
+		reasons = append(reasons, violation.String())
+	}
+	return fmt.Sprintf("patch not supported by device '%s': %s", pve.DeviceID, strings.Join(reasons, "; "))
+}
+
+// Maximum value of CameraPatchRequest.MicVolume.
+const maxMicVolume = 100
+
+// ValidateFor checks the request against the camera's FeatureFlags, returning
+// a *PatchValidationError listing every unsupported field, or nil if Protect
+// should accept it.
+func (r *CameraPatchRequest) ValidateFor(camera *Camera) error {
+	flags := camera.FeatureFlags
+	violations := []PatchViolation{}
+
+	if mode, ok := r.VideoMode.Get(); ok && !slices.Contains(flags.VideoModes, mode) {
+		violations = append(violations, PatchViolation{
+			Field:     "videoMode",
+			Value:     mode,
+			Reason:    "is not a supported video mode",
+			Supported: EnumStrings(flags.VideoModes),
+		})
+	}
+
+	if hdrType, ok := r.HdrType.Get(); ok && !flags.HasHdr && hdrType != HdrTypeOff {
+		violations = append(violations, PatchViolation{
+			Field:  "hdrType",
+			Value:  hdrType,
+			Reason: "requested, but the camera has no HDR",
+		})
+	}
+
+	if volume, ok := r.MicVolume.Get(); ok {
+		switch {
+		case !flags.HasMic:
+			violations = append(violations, PatchViolation{
+				Field:  "micVolume",
+				Value:  volume,
+				Reason: "requested, but the camera has no microphone",
+			})
+		case volume < 0 || volume > maxMicVolume:
+			violations = append(violations, PatchViolation{
+				Field:  "micVolume",
+				Value:  volume,
+				Reason: fmt.Sprintf("is outside of 0 to %d", maxMicVolume),
+			})
+		}
+	}
+
+	if enabled, ok := r.LedSettings.IsEnabled.Get(); ok && !flags.HasLedStatus {
+		violations = append(violations, PatchViolation{
+			Field:  "ledSettings.isEnabled",
+			Value:  enabled,
+			Reason: "requested, but the camera has no status LED",
+		})
+	}
+
+	objectTypes, _ := r.SmartDetectSettings.ObjectTypes.Get()
+	violations = append(violations, unsupportedValues("smartDetectSettings.objectTypes",
+		objectTypes, flags.SmartDetectTypes, "is not a supported smart detection type")...)
+
+	audioTypes, _ := r.SmartDetectSettings.AudioTypes.Get()
+	violations = append(violations, unsupportedValues("smartDetectSettings.audioTypes",
+		audioTypes, flags.SmartDetectAudioTypes, "is not a supported smart audio detection type")...)
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &PatchValidationError{DeviceID: camera.ID, Violations: violations}
+}
+
+// Returns a violation for each of requested which isn't in supported.
+func unsupportedValues(field string, requested []string, supported []string, reason string) []PatchViolation {
+	violations := []PatchViolation{}
+	for _, value := range requested {
+		if !slices.Contains(supported, value) {
+			violations = append(violations, PatchViolation{
+				Field:     field,
+				Value:     value,
+				Reason:    reason,
+				Supported: supported,
+			})
+		}
+	}
+	return violations
+}
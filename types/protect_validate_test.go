@@ -0,0 +1,58 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/types"
+)
+
+func testCamera() *types.Camera {
+	camera := &types.Camera{ID: "cam1"}
+	camera.FeatureFlags.VideoModes = []types.VideoMode{types.VideoModeDefault, types.VideoModeHighFps}
+	camera.FeatureFlags.SmartDetectTypes = []string{"person", "vehicle"}
+	camera.FeatureFlags.SmartDetectAudioTypes = []string{"alrmSmoke"}
+	camera.FeatureFlags.HasLedStatus = true
+	return camera
+}
+
+func TestCameraPatchValidateForAcceptsSupportedPatch(t *testing.T) {
+	req := (&types.CameraPatchRequest{}).
+		WithVideoMode(types.VideoModeHighFps).
+		WithLedEnabled(false).
+		WithHdrType(types.HdrTypeOff).
+		WithSmartDetectObjectTypes("person")
+
+	require.NoError(t, req.ValidateFor(testCamera()))
+}
+
+func TestCameraPatchValidateForListsViolations(t *testing.T) {
+	req := (&types.CameraPatchRequest{}).
+		WithVideoMode(types.VideoModeSport).
+		WithHdrType(types.HdrTypeAuto).
+		WithMicVolume(50).
+		WithSmartDetectObjectTypes("person", "animal").
+		WithSmartDetectAudioTypes("alrmSmoke", "alrmBabyCry")
+
+	err := req.ValidateFor(testCamera())
+
+	var validationErr *types.PatchValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "cam1", validationErr.DeviceID)
+
+	fields := []string{}
+	for _, violation := range validationErr.Violations {
+		fields = append(fields, violation.Field)
+	}
+	assert.Equal(t, []string{
+		"videoMode",
+		"hdrType",
+		"micVolume",
+		"smartDetectSettings.objectTypes",
+		"smartDetectSettings.audioTypes",
+	}, fields)
+	assert.Equal(t, "animal", validationErr.Violations[3].Value)
+	assert.Equal(t, []string{"person", "vehicle"}, validationErr.Violations[3].Supported)
+}
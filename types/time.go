@@ -0,0 +1,37 @@
+package types
+
+import (
+	"time"
+)
+
+// UnixMillis is a time in milliseconds since the Unix epoch, which is how
+// Protect reports times. It marshals as a plain integer, and 0 means unset.
+type UnixMillis int64
+
+// NewUnixMillis returns t as a UnixMillis. The zero time gives 0.
+func NewUnixMillis(t time.Time) UnixMillis {
+	if t.IsZero() {
+		return 0
+	}
+	return UnixMillis(t.UnixMilli())
+}
+
+// Time returns um as a time.Time, or the zero time if um is unset.
+func (um UnixMillis) Time() time.Time {
+	if um == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(um))
+}
+
+// IsZero reports whether um is unset.
+func (um UnixMillis) IsZero() bool {
+	return um == 0
+}
+
+func (um UnixMillis) String() string {
+	if um == 0 {
+		return "unset"
+	}
+	return um.Time().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,252 @@
+// Package vouchers lays out hotspot vouchers as printable cards, each with its
+// code, limits and a QR code, and renders sheets of them as HTML or PDF. It
+// also keeps pools of unused vouchers topped up, and prunes spent ones.
+package vouchers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/draw"
+	// Logos may be GIF, JPEG or PNG.
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/ClifHouck/unified/qr"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Card is a voucher as printed on a sheet. Limits the voucher doesn't have are
+// empty.
+type Card struct {
+	Voucher   *types.Voucher
+	Code      string
+	Duration  string
+	DataLimit string
+	RateLimit string
+	Guests    string
+	QR        *qr.Code
+}
+
+// NewCard lays out a voucher, with a QR code of qrData, e.g. its code or a
+// URL of the hotspot portal.
+func NewCard(voucher *types.Voucher, qrData string) (*Card, error) {
+	code, err := qr.EncodeString(qrData, qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("voucher %s QR code: %w", voucher.Code, err)
+	}
+	return &Card{
+		Voucher:   voucher,
+		Code:      FormatCode(voucher.Code),
+		Duration:  FormatMinutes(voucher.TimeLimitMinutes),
+		DataLimit: formatMegabytes(voucher.DataUsageLimitMBytes),
+		RateLimit: formatRateLimit(voucher.RxRateLimitKbps, voucher.TxRateLimitKbps),
+		Guests:    formatGuests(voucher.AuthorizedGuestLimit),
+		QR:        code,
+	}, nil
+}
+
+// QRSVG returns the card's QR code as an inline SVG image.
+func (c *Card) QRSVG() template.HTML {
+	//nolint:gosec // The SVG is generated, not user input.
+	return template.HTML(c.QR.SVG())
+}
+
+// Details returns the card's limits, labelled, in the order they're printed.
+func (c *Card) Details() [][2]string {
+	details := [][2]string{}
+	for _, detail := range [][2]string{
+		{"Valid for", c.Duration},
+		{"Data", c.DataLimit},
+		{"Speed", c.RateLimit},
+		{"Devices", c.Guests},
+	} {
+		if detail[1] != "" {
+			details = append(details, detail)
+		}
+	}
+	return details
+}
+
+// Details returns the limits, labelled, as cards print them.
+func (l Limits) Details() [][2]string {
+	card := &Card{
+		Duration:  FormatMinutes(l.TimeLimitMinutes),
+		DataLimit: formatMegabytes(l.DataUsageLimitMBytes),
+		RateLimit: formatRateLimit(l.RxRateLimitKbps, l.TxRateLimitKbps),
+		Guests:    formatGuests(l.AuthorizedGuestLimit),
+	}
+	return card.Details()
+}
+
+// Unused returns true if no guest has used the voucher yet, and it hasn't
+// expired.
+func Unused(voucher *types.Voucher) bool {
+	return !voucher.Expired && voucher.AuthorizedGuestCount == 0
+}
+
+// FormatCode splits a ten digit voucher code in two, as UniFi's portal shows
+// it, e.g. "12345-67890". Other codes are returned as they are.
+func FormatCode(code string) string {
+	if len(code) != 10 || strings.Trim(code, "0123456789") != "" {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
+
+// FormatMinutes formats a voucher's time limit, e.g. "1 day 12 hours".
+func FormatMinutes(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+	parts := []string{}
+	for _, unit := range []struct {
+		name    string
+		minutes int
+	}{
+		{"day", 24 * 60},
+		{"hour", 60},
+		{"minute", 1},
+	} {
+		count := minutes / unit.minutes
+		minutes %= unit.minutes
+		if count > 0 {
+			parts = append(parts, plural(count, unit.name))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatMegabytes(megabytes int) string {
+	switch {
+	case megabytes <= 0:
+		return ""
+	case megabytes%1024 == 0:
+		return strconv.Itoa(megabytes/1024) + " GB"
+	default:
+		return strconv.Itoa(megabytes) + " MB"
+	}
+}
+
+func formatKbps(kbps int) string {
+	if kbps >= 1000 {
+		return strconv.FormatFloat(float64(kbps)/1000, 'f', -1, 64) + " Mbps"
+	}
+	return strconv.Itoa(kbps) + " kbps"
+}
+
+func formatRateLimit(rxKbps, txKbps int) string {
+	limits := []string{}
+	if rxKbps > 0 {
+		limits = append(limits, formatKbps(rxKbps)+" down")
+	}
+	if txKbps > 0 {
+		limits = append(limits, formatKbps(txKbps)+" up")
+	}
+	return strings.Join(limits, ", ")
+}
+
+func formatGuests(limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	return plural(limit, "device")
+}
+
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// Logo is an image printed on every card.
+type Logo struct {
+	Image image.Image
+	// The file's content and its MIME type, embedded as is in HTML.
+	Data     []byte
+	MIMEType string
+}
+
+// LoadLogo reads a GIF, JPEG or PNG logo.
+func LoadLogo(path string) (*Logo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("logo %s: %w", path, err)
+	}
+	return &Logo{Image: img, Data: data, MIMEType: http.DetectContentType(data)}, nil
+}
+
+// NewLogo returns a logo of an image, encoded as PNG for HTML.
+func NewLogo(img image.Image) (*Logo, error) {
+	buffer := bytes.Buffer{}
+	err := png.Encode(&buffer, img)
+	if err != nil {
+		return nil, err
+	}
+	return &Logo{Image: img, Data: buffer.Bytes(), MIMEType: "image/png"}, nil
+}
+
+// Returns the logo's pixels as RGB triplets, composited onto white.
+func (l *Logo) rgb() []byte {
+	bounds := l.Image.Bounds()
+	canvas := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), l.Image, bounds.Min, draw.Over)
+
+	pixels := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		pixels = append(pixels, canvas.Pix[i:i+3]...)
+	}
+	return pixels
+}
+
+// Sheet is a set of cards printed together.
+type Sheet struct {
+	// Printed at the top of every card, e.g. "Guest Wi-Fi".
+	Title string
+	// Printed at the bottom of every card, e.g. instructions to connect.
+	Footer string
+	Logo   *Logo
+	Cards  []*Card
+}
+
+// AddCards adds a card of each voucher to the sheet, with a QR code of qrData
+// executed with the voucher.
+func (s *Sheet) AddCards(vouchers []*types.Voucher, qrData *texttemplate.Template) error {
+	for _, voucher := range vouchers {
+		data := strings.Builder{}
+		err := qrData.Execute(&data, voucher)
+		if err != nil {
+			return err
+		}
+		card, err := NewCard(voucher, data.String())
+		if err != nil {
+			return err
+		}
+		s.Cards = append(s.Cards, card)
+	}
+	return nil
+}
+
+// LogoURL returns the logo as a data URL, or an empty one without a logo.
+func (s *Sheet) LogoURL() template.URL {
+	if s.Logo == nil {
+		return ""
+	}
+	//nolint:gosec // The data is base64 encoded, which can't escape the URL.
+	return template.URL("data:" + s.Logo.MIMEType + ";base64," +
+		base64.StdEncoding.EncodeToString(s.Logo.Data))
+}
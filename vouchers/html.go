@@ -0,0 +1,36 @@
+package vouchers
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+)
+
+//go:embed sheet.html
+var defaultTemplate string
+
+// DefaultTemplate is the HTML template sheets are rendered with, unless
+// another is given. Templates are executed with the *Sheet.
+var DefaultTemplate = NewTemplate()
+
+// NewTemplate returns a copy of DefaultTemplate, for pages which show cards to
+// add to. Besides the sheet, it defines "style", the cards' CSS, and "cards",
+// which renders the cards of a *Sheet.
+func NewTemplate() *template.Template {
+	return template.Must(template.New("sheet").Parse(defaultTemplate))
+}
+
+// ParseTemplate reads an HTML template to render sheets with in place of
+// DefaultTemplate.
+func ParseTemplate(path string) (*template.Template, error) {
+	return template.ParseFiles(path)
+}
+
+// RenderHTML writes the sheet as an HTML page, with tmpl or DefaultTemplate
+// if it's nil.
+func RenderHTML(w io.Writer, sheet *Sheet, tmpl *template.Template) error {
+	if tmpl == nil {
+		tmpl = DefaultTemplate
+	}
+	return tmpl.Execute(w, sheet)
+}
@@ -0,0 +1,368 @@
+package vouchers
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ClifHouck/unified/qr"
+)
+
+// PageSize is the size of a PDF page, in points.
+type PageSize struct {
+	Width, Height float64
+}
+
+// PageSizes are the page sizes sheets can be printed on, by name.
+var PageSizes = map[string]PageSize{
+	"a4":     {Width: 595.28, Height: 841.89},
+	"letter": {Width: 612, Height: 792},
+}
+
+// Card layout, in points. Cards are business card sized, and laid out edge to
+// edge so a single cut separates two.
+const (
+	pageMargin   = 36
+	cardWidth    = 252
+	cardHeight   = 144
+	cardPadding  = 10
+	qrSize       = 92
+	qrGap        = 6
+	logoHeight   = 18
+	textColumn   = cardWidth - cardPadding*2 - qrSize - qrGap
+	footerLines  = 2
+	footerSize   = 6.5
+	footerLeader = 8
+)
+
+// The standard fonts every PDF reader has, so none are embedded.
+type font struct {
+	name     string
+	resource string
+	// Widths of the printable ASCII characters, in thousandths of the size.
+	widths [95]int
+}
+
+var (
+	helvetica = &font{name: "Helvetica", resource: "F1", widths: [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}}
+	helveticaBold = &font{name: "Helvetica-Bold", resource: "F2", widths: [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}}
+)
+
+// Returns the width of text set in the font, in points.
+func (f *font) width(text string, size float64) float64 {
+	width := 0
+	for _, b := range encodeWinAnsi(text) {
+		if b >= ' ' && b <= '~' {
+			width += f.widths[b-' ']
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// Shortens text with an ellipsis until it fits within width.
+func (f *font) truncate(text string, size, width float64) string {
+	if f.width(text, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && f.width(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// Breaks text into lines of at most width, the last truncated if there are
+// more than maxLines.
+func (f *font) wrap(text string, size, width float64, maxLines int) []string {
+	lines := []string{}
+	line := ""
+	words := strings.Fields(text)
+	for i, word := range words {
+		if line != "" && f.width(line+" "+word, size) > width {
+			if len(lines) == maxLines-1 {
+				rest := line + " " + strings.Join(words[i:], " ")
+				return append(lines, f.truncate(rest, size, width))
+			}
+			lines = append(lines, line)
+			line = ""
+		}
+		line = strings.TrimSpace(line + " " + word)
+	}
+	if line != "" {
+		lines = append(lines, f.truncate(line, size, width))
+	}
+	return lines
+}
+
+// WinAnsiEncoding's characters outside Latin-1.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// Encodes text as the standard fonts expect it. Characters WinAnsiEncoding
+// lacks become '?'.
+func encodeWinAnsi(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch b, ok := winAnsi[r]; {
+		case ok:
+			encoded = append(encoded, b)
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			encoded = append(encoded, byte(r))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// Returns text as a PDF string literal.
+func pdfString(text string) string {
+	literal := strings.Builder{}
+	literal.WriteByte('(')
+	for _, b := range encodeWinAnsi(text) {
+		switch {
+		case b == '(' || b == ')' || b == '\\':
+			literal.WriteByte('\\')
+			literal.WriteByte(b)
+		case b < ' ' || b > '~':
+			fmt.Fprintf(&literal, "\\%03o", b)
+		default:
+			literal.WriteByte(b)
+		}
+	}
+	literal.WriteByte(')')
+	return literal.String()
+}
+
+// Formats a number to a hundredth, well beyond what printers resolve.
+func pdfNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+}
+
+// A page's content stream.
+type pdfContent struct {
+	bytes.Buffer
+}
+
+func (c *pdfContent) op(operands ...any) {
+	for i, operand := range operands {
+		if i > 0 {
+			c.WriteByte(' ')
+		}
+		switch operand := operand.(type) {
+		case float64:
+			c.WriteString(pdfNumber(operand))
+		case int:
+			c.WriteString(strconv.Itoa(operand))
+		default:
+			fmt.Fprint(c, operand)
+		}
+	}
+	c.WriteByte('\n')
+}
+
+func (c *pdfContent) text(f *font, size, gray, x, y float64, text string) {
+	c.op(gray, "g")
+	c.op("BT /"+f.resource, size, "Tf", x, y, "Td", pdfString(text), "Tj ET")
+}
+
+// Draws the dark modules of a QR code, with its quiet zone, as a square of
+// size at x, y.
+func (c *pdfContent) qrCode(code *qr.Code, x, y, size float64) {
+	module := size / float64(code.Size+qr.QuietZone*2)
+	c.op(0.0, "g")
+	for row := range code.Size {
+		top := y + size - float64(row+qr.QuietZone+1)*module
+		for column := 0; column < code.Size; column++ {
+			if !code.Dark(column, row) {
+				continue
+			}
+			run := 1
+			for code.Dark(column+run, row) {
+				run++
+			}
+			c.op(x+float64(column+qr.QuietZone)*module, top, float64(run)*module, module, "re")
+			column += run - 1
+		}
+	}
+	c.op("f")
+}
+
+// A PDF file, as a list of objects numbered from 1.
+type pdfDocument struct {
+	objects [][]byte
+}
+
+func (d *pdfDocument) add(object string) int {
+	d.objects = append(d.objects, []byte(object))
+	return len(d.objects)
+}
+
+func (d *pdfDocument) set(id int, object string) {
+	d.objects[id-1] = []byte(object)
+}
+
+// Adds a stream object, compressed. dict holds its entries other than those
+// of its length and filter.
+func (d *pdfDocument) addStream(dict string, data []byte) (int, error) {
+	compressed := bytes.Buffer{}
+	writer := zlib.NewWriter(&compressed)
+	_, err := writer.Write(data)
+	if err != nil {
+		return 0, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return 0, err
+	}
+	object := fmt.Sprintf("<< %s /Filter /FlateDecode /Length %d >>\nstream\n", dict, compressed.Len())
+	return d.add(object + compressed.String() + "\nendstream"), nil
+}
+
+func (d *pdfDocument) writeTo(w io.Writer, root int) error {
+	file := bytes.Buffer{}
+	// The binary comment marks the file as binary to transfer programs.
+	file.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(d.objects))
+	for i, object := range d.objects {
+		offsets[i] = file.Len()
+		fmt.Fprintf(&file, "%d 0 obj\n", i+1)
+		file.Write(object)
+		file.WriteString("\nendobj\n")
+	}
+
+	xref := file.Len()
+	fmt.Fprintf(&file, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&file, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&file, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(d.objects)+1, root, xref)
+
+	_, err := file.WriteTo(w)
+	return err
+}
+
+// RenderPDF writes the sheet as a PDF of pages of the given size, with as many
+// cards on each as fit.
+func RenderPDF(w io.Writer, sheet *Sheet, size PageSize) error {
+	columns := max(1, int((size.Width-pageMargin*2)/cardWidth))
+	rows := max(1, int((size.Height-pageMargin*2)/cardHeight))
+	left := (size.Width - float64(columns*cardWidth)) / 2
+	top := size.Height - (size.Height-float64(rows*cardHeight))/2
+
+	doc := &pdfDocument{}
+	catalog := doc.add("")
+	pages := doc.add("")
+	resources := "/Font <<"
+	for _, f := range []*font{helvetica, helveticaBold} {
+		id := doc.add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.name))
+		resources += fmt.Sprintf(" /%s %d 0 R", f.resource, id)
+	}
+	resources += " >>"
+	if sheet.Logo != nil {
+		bounds := sheet.Logo.Image.Bounds()
+		id, err := doc.addStream(fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+			bounds.Dx(), bounds.Dy()), sheet.Logo.rgb())
+		if err != nil {
+			return err
+		}
+		resources += fmt.Sprintf(" /XObject << /Logo %d 0 R >>", id)
+	}
+
+	kids := []string{}
+	perPage := columns * rows
+	for start := 0; start < len(sheet.Cards) || start == 0; start += perPage {
+		content := &pdfContent{}
+		for i, card := range sheet.Cards[start:min(start+perPage, len(sheet.Cards))] {
+			x := left + float64(i%columns*cardWidth)
+			y := top - float64((i/columns+1)*cardHeight)
+			drawCard(content, sheet, card, x, y)
+		}
+		contentID, err := doc.addStream("", content.Bytes())
+		if err != nil {
+			return err
+		}
+		kids = append(kids, fmt.Sprintf("%d 0 R", doc.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			pages, pdfNumber(size.Width), pdfNumber(size.Height), resources, contentID))))
+	}
+	doc.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	doc.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+
+	return doc.writeTo(w, catalog)
+}
+
+// Draws a card with its bottom left corner at x, y.
+func drawCard(content *pdfContent, sheet *Sheet, card *Card, x, y float64) {
+	// Cut lines.
+	content.op(0.6, "G 0.5 w [3 3] 0 d")
+	content.op(x, y, float64(cardWidth), float64(cardHeight), "re S [] 0 d")
+
+	content.qrCode(card.QR, x+cardWidth-cardPadding-qrSize, y+(cardHeight-qrSize)/2, qrSize)
+
+	textX := x + cardPadding
+	cursor := y + cardHeight - cardPadding
+	if sheet.Logo != nil {
+		bounds := sheet.Logo.Image.Bounds()
+		scale := min(textColumn/float64(bounds.Dx()), logoHeight/float64(bounds.Dy()))
+		width, height := float64(bounds.Dx())*scale, float64(bounds.Dy())*scale
+		cursor -= height
+		content.op("q", width, 0, 0, height, textX, cursor, "cm /Logo Do Q")
+		cursor -= 4
+	}
+	if sheet.Title != "" {
+		cursor -= 10
+		content.text(helveticaBold, 10, 0, textX, cursor, helveticaBold.truncate(sheet.Title, 10, textColumn))
+		cursor -= 3
+	}
+	cursor -= 9
+	content.text(helvetica, 7, 0.4, textX, cursor, "Voucher code")
+	cursor -= 15
+	content.text(helveticaBold, 15, 0, textX, cursor, helveticaBold.truncate(card.Code, 15, textColumn))
+	cursor -= 3
+
+	footer := helvetica.wrap(sheet.Footer, footerSize, textColumn, footerLines)
+	// Details stop short of the footer, should a logo leave too little room.
+	bottom := y + cardPadding + float64(len(footer)*footerLeader)
+	for _, detail := range card.Details() {
+		if cursor-9.5 < bottom {
+			break
+		}
+		cursor -= 9.5
+		label := detail[0] + ": "
+		content.text(helvetica, 8, 0.4, textX, cursor, label)
+		labelWidth := helvetica.width(label, 8)
+		content.text(helvetica, 8, 0, textX+labelWidth, cursor,
+			helvetica.truncate(detail[1], 8, textColumn-labelWidth))
+	}
+	for i, line := range footer {
+		content.text(helvetica, footerSize, 0.4, textX,
+			y+cardPadding+float64((len(footer)-1-i)*footerLeader), line)
+	}
+}
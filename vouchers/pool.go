@@ -0,0 +1,221 @@
+package vouchers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClifHouck/unified/client"
+	"github.com/ClifHouck/unified/types"
+)
+
+// Vouchers are deleted by a filter of at most this many IDs at a time.
+const deleteBatchSize = 100
+
+// Policy is how a site's vouchers are maintained: pools kept topped up with
+// unused vouchers, and which vouchers are pruned.
+type Policy struct {
+	Pools []*Pool `mapstructure:"pools"`
+	Prune Prune   `mapstructure:"prune"`
+}
+
+// Limits are those of vouchers to generate. Zero is unlimited.
+type Limits struct {
+	AuthorizedGuestLimit int `mapstructure:"guestLimit" json:"authorizedGuestLimit"`
+	TimeLimitMinutes     int `mapstructure:"timeLimit"  json:"timeLimitMinutes"`
+	DataUsageLimitMBytes int `mapstructure:"dataLimit"  json:"dataUsageLimitMBytes"`
+	RxRateLimitKbps      int `mapstructure:"rxLimit"    json:"rxRateLimitKbps"`
+	TxRateLimitKbps      int `mapstructure:"txLimit"    json:"txRateLimitKbps"`
+}
+
+// Request returns a request to generate count vouchers of the limits.
+func (l Limits) Request(name string, count int) *types.VoucherGenerateRequest {
+	return &types.VoucherGenerateRequest{
+		Count:                count,
+		Name:                 name,
+		AuthorizedGuestLimit: l.AuthorizedGuestLimit,
+		TimeLimitMinutes:     l.TimeLimitMinutes,
+		DataUsageLimitMBytes: l.DataUsageLimitMBytes,
+		RxRateLimitKbps:      l.RxRateLimitKbps,
+		TxRateLimitKbps:      l.TxRateLimitKbps,
+	}
+}
+
+// Pool is a set of vouchers, by name prefix, which should always have at least
+// Minimum unused vouchers. Vouchers it generates are named Name, or Prefix,
+// with the pool's limits.
+type Pool struct {
+	Prefix  string `mapstructure:"prefix"`
+	Name    string `mapstructure:"name"`
+	Minimum int    `mapstructure:"minimum"`
+	Limits  `mapstructure:",squash"`
+}
+
+// Prune is which vouchers to delete. Only those whose names start with one of
+// Prefixes are, or any voucher without prefixes.
+type Prune struct {
+	Expired bool `mapstructure:"expired"`
+	// Deleting a voucher may end the access of the guests who used it, so
+	// vouchers which are used up but haven't expired are only pruned if asked.
+	FullyUsed bool     `mapstructure:"fullyUsed"`
+	Prefixes  []string `mapstructure:"prefixes"`
+	// How often to prune when maintaining continuously, e.g. 24h. Zero prunes
+	// every time pools are topped up.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// Enabled returns true if any vouchers are pruned.
+func (p *Prune) Enabled() bool {
+	return p.Expired || p.FullyUsed
+}
+
+// Validate returns an error describing the first problem with the policy.
+func (p *Policy) Validate() error {
+	if len(p.Pools) == 0 && !p.Prune.Enabled() {
+		return errors.New("policy has no pools, and prunes nothing")
+	}
+	for i, pool := range p.Pools {
+		if pool.Prefix == "" {
+			return fmt.Errorf("pool %d has no prefix", i+1)
+		}
+		if pool.Minimum <= 0 {
+			return fmt.Errorf("pool '%s' needs a minimum of at least 1", pool.Prefix)
+		}
+		if pool.Name != "" && !strings.HasPrefix(pool.Name, pool.Prefix) {
+			return fmt.Errorf("pool '%s' names its vouchers '%s', which it wouldn't count", pool.Prefix, pool.Name)
+		}
+	}
+	for _, prefix := range append(p.poolPrefixes(), p.Prune.Prefixes...) {
+		if strings.ContainsAny(prefix, "'*") {
+			return fmt.Errorf("prefix '%s' can't contain ' or *", prefix)
+		}
+	}
+	return nil
+}
+
+func (p *Policy) poolPrefixes() []string {
+	prefixes := []string{}
+	for _, pool := range p.Pools {
+		prefixes = append(prefixes, pool.Prefix)
+	}
+	return prefixes
+}
+
+// FullyUsed returns true if a voucher has been used by as many guests as it
+// allows.
+func FullyUsed(voucher *types.Voucher) bool {
+	return voucher.AuthorizedGuestLimit > 0 && voucher.AuthorizedGuestCount >= voucher.AuthorizedGuestLimit
+}
+
+// PrefixFilter returns a filter of vouchers whose names start with any of the
+// prefixes.
+func PrefixFilter(prefixes ...string) types.Filter {
+	likes := []string{}
+	for _, prefix := range prefixes {
+		likes = append(likes, fmt.Sprintf("name.like('%s*')", prefix))
+	}
+	if len(likes) == 1 {
+		return types.Filter(likes[0])
+	}
+	return types.Filter("or(" + strings.Join(likes, ", ") + ")")
+}
+
+// PoolStatus is how many unused vouchers a pool has, and how many it's short.
+type PoolStatus struct {
+	Pool    *Pool
+	Unused  int
+	Missing int
+}
+
+// CheckPool counts a pool's unused vouchers.
+func CheckPool(network types.NetworkV1, siteID types.SiteID, pool *Pool) (*PoolStatus, error) {
+	all, err := client.AllVouchers(network, siteID, PrefixFilter(pool.Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("pool '%s': %w", pool.Prefix, err)
+	}
+	status := &PoolStatus{Pool: pool}
+	for _, voucher := range all {
+		// Match the prefix exactly, however the API's like matches.
+		if strings.HasPrefix(voucher.Name, pool.Prefix) && Unused(voucher) {
+			status.Unused++
+		}
+	}
+	status.Missing = max(0, pool.Minimum-status.Unused)
+	return status, nil
+}
+
+// TopUp generates the vouchers a pool is missing.
+func TopUp(network types.NetworkV1, siteID types.SiteID, status *PoolStatus) ([]*types.Voucher, error) {
+	if status.Missing == 0 {
+		return []*types.Voucher{}, nil
+	}
+	pool := status.Pool
+	generated, err := network.VoucherGenerate(siteID, pool.Request(cmp.Or(pool.Name, pool.Prefix), status.Missing))
+	if err != nil {
+		return nil, fmt.Errorf("pool '%s': %w", pool.Prefix, err)
+	}
+	return generated, nil
+}
+
+// PruneCandidates lists the vouchers prune would delete.
+func PruneCandidates(network types.NetworkV1, siteID types.SiteID, prune *Prune) ([]*types.Voucher, error) {
+	if !prune.Enabled() {
+		return []*types.Voucher{}, nil
+	}
+	filters := []string{}
+	if len(prune.Prefixes) > 0 {
+		filters = append(filters, string(PrefixFilter(prune.Prefixes...)))
+	}
+	if !prune.FullyUsed {
+		filters = append(filters, "expired.eq(true)")
+	}
+	filter := strings.Join(filters, ", ")
+	if len(filters) > 1 {
+		filter = "and(" + filter + ")"
+	}
+
+	all, err := client.AllVouchers(network, siteID, types.Filter(filter))
+	if err != nil {
+		return nil, err
+	}
+	candidates := []*types.Voucher{}
+	for _, voucher := range all {
+		if len(prune.Prefixes) > 0 && !hasAnyPrefix(voucher.Name, prune.Prefixes) {
+			continue
+		}
+		if (prune.Expired && voucher.Expired) || (prune.FullyUsed && FullyUsed(voucher)) {
+			candidates = append(candidates, voucher)
+		}
+	}
+	return candidates, nil
+}
+
+// Delete deletes vouchers by a filter of their IDs, so only those given are
+// deleted. Returns how many the API reports it deleted.
+func Delete(network types.NetworkV1, siteID types.SiteID, vouchers []*types.Voucher) (int, error) {
+	deleted := 0
+	for start := 0; start < len(vouchers); start += deleteBatchSize {
+		ids := []string{}
+		for _, voucher := range vouchers[start:min(start+deleteBatchSize, len(vouchers))] {
+			ids = append(ids, "'"+voucher.ID+"'")
+		}
+		response, err := network.VoucherDeleteByFilter(siteID,
+			types.Filter("id.in("+strings.Join(ids, ", ")+")"))
+		if err != nil {
+			return deleted, err
+		}
+		deleted += response.VouchersDeleted
+	}
+	return deleted, nil
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
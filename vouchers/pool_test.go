@@ -0,0 +1,173 @@
+package vouchers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/types"
+	"github.com/ClifHouck/unified/vouchers"
+)
+
+// Serves a site's vouchers, ignoring filters, and records the requests which
+// change them.
+type voucherNetwork struct {
+	types.NetworkV1
+
+	vouchers  []*types.Voucher
+	filters   []types.Filter
+	generated []*types.VoucherGenerateRequest
+	deleted   []types.Filter
+}
+
+func (vn *voucherNetwork) Vouchers(
+	_ types.SiteID, filter types.Filter, _ *types.PageArguments,
+) ([]*types.Voucher, *types.Page, error) {
+	vn.filters = append(vn.filters, filter)
+	return vn.vouchers, &types.Page{Count: len(vn.vouchers), TotalCount: len(vn.vouchers)}, nil
+}
+
+func (vn *voucherNetwork) VoucherGenerate(
+	_ types.SiteID, request *types.VoucherGenerateRequest,
+) ([]*types.Voucher, error) {
+	vn.generated = append(vn.generated, request)
+	generated := []*types.Voucher{}
+	for i := range request.Count {
+		generated = append(generated, &types.Voucher{ID: fmt.Sprintf("new-%d", i), Name: request.Name})
+	}
+	return generated, nil
+}
+
+func (vn *voucherNetwork) VoucherDeleteByFilter(
+	_ types.SiteID, filter types.Filter,
+) (*types.VoucherDeleteResponse, error) {
+	vn.deleted = append(vn.deleted, filter)
+	return &types.VoucherDeleteResponse{VouchersDeleted: 1}, nil
+}
+
+func poolNetwork() *voucherNetwork {
+	return &voucherNetwork{vouchers: []*types.Voucher{
+		{ID: "unused", Name: "Event day"},
+		{ID: "partly-used", Name: "Event day", AuthorizedGuestLimit: 2, AuthorizedGuestCount: 1},
+		{ID: "used", Name: "Event day", AuthorizedGuestLimit: 1, AuthorizedGuestCount: 1},
+		{ID: "expired", Name: "Event day", Expired: true},
+		{ID: "lobby", Name: "Lobby", Expired: true},
+		{ID: "other", Name: "event day"},
+	}}
+}
+
+func TestTopUp(t *testing.T) {
+	network := poolNetwork()
+	pool := &vouchers.Pool{
+		Prefix:  "Event",
+		Minimum: 3,
+		Limits:  vouchers.Limits{TimeLimitMinutes: 1440, AuthorizedGuestLimit: 1},
+	}
+
+	status, err := vouchers.CheckPool(network, "site", pool)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Filter{"name.like('Event*')"}, network.filters)
+	assert.Equal(t, 1, status.Unused)
+	assert.Equal(t, 2, status.Missing)
+
+	generated, err := vouchers.TopUp(network, "site", status)
+	require.NoError(t, err)
+	assert.Len(t, generated, 2)
+	assert.Equal(t, []*types.VoucherGenerateRequest{
+		{Count: 2, Name: "Event", AuthorizedGuestLimit: 1, TimeLimitMinutes: 1440},
+	}, network.generated)
+
+	// A full pool generates nothing.
+	pool.Minimum = 1
+	status, err = vouchers.CheckPool(network, "site", pool)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Missing)
+	generated, err = vouchers.TopUp(network, "site", status)
+	require.NoError(t, err)
+	assert.Empty(t, generated)
+	assert.Len(t, network.generated, 1)
+}
+
+func ids(vouchers []*types.Voucher) []string {
+	ids := []string{}
+	for _, voucher := range vouchers {
+		ids = append(ids, voucher.ID)
+	}
+	return ids
+}
+
+func TestPruneCandidates(t *testing.T) {
+	tests := []struct {
+		prune  vouchers.Prune
+		filter types.Filter
+		ids    []string
+	}{
+		{vouchers.Prune{Expired: true}, "expired.eq(true)", []string{"expired", "lobby"}},
+		{vouchers.Prune{FullyUsed: true}, "", []string{"used"}},
+		{
+			vouchers.Prune{Expired: true, FullyUsed: true, Prefixes: []string{"Event", "Conference"}},
+			"or(name.like('Event*'), name.like('Conference*'))",
+			[]string{"used", "expired"},
+		},
+		{
+			vouchers.Prune{Expired: true, Prefixes: []string{"Lobby"}},
+			"and(name.like('Lobby*'), expired.eq(true))",
+			[]string{"lobby"},
+		},
+	}
+	for _, test := range tests {
+		network := poolNetwork()
+		candidates, err := vouchers.PruneCandidates(network, "site", &test.prune)
+		require.NoError(t, err)
+		assert.Equal(t, []types.Filter{test.filter}, network.filters)
+		assert.Equal(t, test.ids, ids(candidates))
+	}
+
+	network := poolNetwork()
+	candidates, err := vouchers.PruneCandidates(network, "site", &vouchers.Prune{})
+	require.NoError(t, err)
+	assert.Empty(t, candidates)
+	assert.Empty(t, network.filters)
+}
+
+func TestDelete(t *testing.T) {
+	network := &voucherNetwork{}
+	toDelete := []*types.Voucher{}
+	for i := range 101 {
+		toDelete = append(toDelete, &types.Voucher{ID: fmt.Sprintf("v%d", i)})
+	}
+
+	deleted, err := vouchers.Delete(network, "site", toDelete)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	require.Len(t, network.deleted, 2)
+	assert.Contains(t, string(network.deleted[0]), "id.in('v0', 'v1', ")
+	assert.Equal(t, types.Filter("id.in('v100')"), network.deleted[1])
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy vouchers.Policy
+		err    string
+	}{
+		{vouchers.Policy{}, "policy has no pools, and prunes nothing"},
+		{vouchers.Policy{Pools: []*vouchers.Pool{{Minimum: 1}}}, "pool 1 has no prefix"},
+		{vouchers.Policy{Pools: []*vouchers.Pool{{Prefix: "A"}}}, "pool 'A' needs a minimum of at least 1"},
+		{
+			vouchers.Policy{Pools: []*vouchers.Pool{{Prefix: "A", Name: "B", Minimum: 1}}},
+			"pool 'A' names its vouchers 'B', which it wouldn't count",
+		},
+		{
+			vouchers.Policy{Prune: vouchers.Prune{Expired: true, Prefixes: []string{"it's"}}},
+			"prefix 'it's' can't contain ' or *",
+		},
+	}
+	for _, test := range tests {
+		require.EqualError(t, test.policy.Validate(), test.err)
+	}
+
+	policy := vouchers.Policy{Pools: []*vouchers.Pool{{Prefix: "A", Name: "A day", Minimum: 1}}}
+	require.NoError(t, policy.Validate())
+}
@@ -0,0 +1,168 @@
+package vouchers_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ClifHouck/unified/types"
+	"github.com/ClifHouck/unified/vouchers"
+)
+
+func voucher(code string) *types.Voucher {
+	return &types.Voucher{
+		ID:                   "voucher-" + code,
+		Code:                 code,
+		Name:                 "Lobby",
+		AuthorizedGuestLimit: 2,
+		TimeLimitMinutes:     24*60 + 90,
+		DataUsageLimitMBytes: 2048,
+		RxRateLimitKbps:      10000,
+		TxRateLimitKbps:      512,
+	}
+}
+
+func TestNewCard(t *testing.T) {
+	card, err := vouchers.NewCard(voucher("1234567890"), "1234567890")
+	require.NoError(t, err)
+
+	assert.Equal(t, "12345-67890", card.Code)
+	assert.Equal(t, [][2]string{
+		{"Valid for", "1 day 1 hour 30 minutes"},
+		{"Data", "2 GB"},
+		{"Speed", "10 Mbps down, 512 kbps up"},
+		{"Devices", "2 devices"},
+	}, card.Details())
+
+	// Unlimited vouchers only list their duration.
+	card, err = vouchers.NewCard(&types.Voucher{Code: "ABC", TimeLimitMinutes: 480}, "ABC")
+	require.NoError(t, err)
+	assert.Equal(t, "ABC", card.Code)
+	assert.Equal(t, [][2]string{{"Valid for", "8 hours"}}, card.Details())
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "00001-00002", vouchers.FormatCode("0000100002"))
+	assert.Equal(t, "12345678901", vouchers.FormatCode("12345678901"))
+	assert.Equal(t, "12345abcde", vouchers.FormatCode("12345abcde"))
+
+	assert.Empty(t, vouchers.FormatMinutes(0))
+	assert.Equal(t, "1 minute", vouchers.FormatMinutes(1))
+	assert.Equal(t, "2 days", vouchers.FormatMinutes(2*24*60))
+}
+
+func sheet(t *testing.T, count int) *vouchers.Sheet {
+	t.Helper()
+	sheet := &vouchers.Sheet{
+		Title:  "Guest Wi-Fi (Lobby)",
+		Footer: "Join the Guest network and enter the code when asked.",
+	}
+	for i := range count {
+		card, err := vouchers.NewCard(voucher(fmt.Sprintf("%010d", i)), "WIFI:S:Guest;;")
+		require.NoError(t, err)
+		sheet.Cards = append(sheet.Cards, card)
+	}
+	return sheet
+}
+
+func TestRenderHTML(t *testing.T) {
+	s := sheet(t, 2)
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.Black)
+	logo, err := vouchers.NewLogo(img)
+	require.NoError(t, err)
+	s.Logo = logo
+
+	out := bytes.Buffer{}
+	require.NoError(t, vouchers.RenderHTML(&out, s, nil))
+	html := out.String()
+	assert.Equal(t, 2, strings.Count(html, `<div class="card">`))
+	assert.Contains(t, html, `<div class="code">00000-00001</div>`)
+	assert.Contains(t, html, `<span>Speed:</span> 10 Mbps down, 512 kbps up`)
+	assert.Contains(t, html, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	assert.Contains(t, html, `src="data:image/png;base64,`)
+
+	custom := template.Must(template.New("custom").Parse(`{{range .Cards}}{{.Voucher.Name}} {{.Code}};{{end}}`))
+	out.Reset()
+	require.NoError(t, vouchers.RenderHTML(&out, s, custom))
+	assert.Equal(t, "Lobby 00000-00000;Lobby 00000-00001;", out.String())
+}
+
+// Returns the decompressed content of the PDF's streams.
+func pdfStreams(t *testing.T, pdf []byte) []string {
+	t.Helper()
+	streams := []string{}
+	for _, match := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		reader, err := zlib.NewReader(bytes.NewReader(match[1]))
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		streams = append(streams, string(content))
+	}
+	return streams
+}
+
+func TestRenderPDF(t *testing.T) {
+	out := bytes.Buffer{}
+	require.NoError(t, vouchers.RenderPDF(&out, sheet(t, 11), vouchers.PageSizes["a4"]))
+	pdf := out.Bytes()
+
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	// Ten cards fit on a page.
+	assert.Contains(t, string(pdf), "/Type /Pages /Kids [6 0 R 8 0 R] /Count 2")
+
+	// Every cross reference points at its object.
+	xref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	require.NotNil(t, xref)
+	offset, err := strconv.Atoi(string(xref[1]))
+	require.NoError(t, err)
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[offset:], -1)
+	require.Len(t, entries, 8)
+	for i, entry := range entries {
+		objectOffset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf[objectOffset:], fmt.Appendf(nil, "%d 0 obj\n", i+1)), "object %d", i+1)
+	}
+
+	streams := pdfStreams(t, pdf)
+	require.Len(t, streams, 2)
+	assert.Equal(t, 10, strings.Count(streams[0], "(Voucher code) Tj"))
+	assert.Contains(t, streams[0], "BT /F2 10 Tf 55.64 760.95 Td (Guest Wi-Fi \\(Lobby\\)) Tj ET")
+	assert.Contains(t, streams[1], "(00000-00010) Tj")
+	assert.NotContains(t, streams[1], "(00000-00009) Tj")
+	// The footer wraps within the text column.
+	assert.Contains(t, streams[1], "(Join the Guest network and enter the code) Tj")
+	assert.Contains(t, streams[1], "(when asked.) Tj")
+}
+
+func TestRenderPDFLogo(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	// Transparent pixels print white.
+	img.Set(1, 0, color.NRGBA{})
+	logo, err := vouchers.NewLogo(img)
+	require.NoError(t, err)
+
+	s := sheet(t, 1)
+	s.Logo = logo
+	out := bytes.Buffer{}
+	require.NoError(t, vouchers.RenderPDF(&out, s, vouchers.PageSizes["letter"]))
+
+	assert.Contains(t, out.String(), "/XObject << /Logo 5 0 R >>")
+	streams := pdfStreams(t, out.Bytes())
+	require.Len(t, streams, 2)
+	assert.Equal(t, "\xff\x00\x00\xff\xff\xff", streams[0])
+	assert.Contains(t, streams[1], "/Logo Do Q")
+}